@@ -2,10 +2,17 @@ package main
 
 import (
 	"encoding/json"
+	"flag"
 	"log"
-	"net"
 	"net/http"
+	"os"
+	"time"
+	"vendepass/internal/auth"
+	"vendepass/internal/client"
+	"vendepass/internal/codec"
 	"vendepass/internal/models"
+
+	"github.com/google/uuid"
 )
 
 const (
@@ -15,18 +22,53 @@ const (
 	CONN_TYPE = "tcp"
 )
 
+// pool holds the long-lived connections to the TCP server shared by every HTTP request.
+var pool *client.Pool
+
+// tokens verifies the session tokens before they are forwarded to the TCP server.
+// It is nil when no token secret is configured, in which case every token is left for the server to check.
+var tokens *auth.Signer
+
 // main initializes and starts the HTTP server for the application.
 // It sets up the routes for various API endpoints and listens on the specified port.
 func main() {
+	maxMessageSize := flag.Uint("max-message-size", codec.DefaultMaxMessageSize, "maximum size in bytes of a single request or response")
+	poolSize := flag.Int("pool-size", 4, "number of connections kept open to the TCP server")
+	timeout := flag.Duration("timeout", 30*time.Second, "maximum time to wait for a response of the TCP server")
+	tokenSecret := flag.String("token-secret", os.Getenv("VENDEPASS_TOKEN_SECRET"), "secret shared with the server to verify session tokens locally")
+	flag.Parse()
+
+	if *tokenSecret != "" {
+		tokens = auth.NewSigner([]byte(*tokenSecret), 0)
+	}
+
+	pool = client.NewPool(CONN_TYPE, CONN_HOST+":"+CONN_PORT, *poolSize, codec.New(uint32(*maxMessageSize)), *timeout)
+	defer pool.Close()
+
 	http.HandleFunc("/login", handleLogin)
 	http.HandleFunc("/logout", handleLogout)
-	http.HandleFunc("/user", handleGetUser)
+	http.HandleFunc("/sessions", handleSessions)
+	http.HandleFunc("/token", handleRefreshToken)
+	http.HandleFunc("/register", handleRegister)
+	http.HandleFunc("/user", handleUser)
+	http.HandleFunc("/user/password", handleChangePassword)
 	http.HandleFunc("/route", handleGetRoute)
 	http.HandleFunc("/flights", handleGetFlights)
+	http.HandleFunc("/seat-map", handleGetSeatMap)
 	http.HandleFunc("/reservation", handleReservation)
 	http.HandleFunc("/cart", handleGetCart)
+	http.HandleFunc("/checkout", handleCheckout)
 	http.HandleFunc("/ticket", handleTicket)
 	http.HandleFunc("/tickets", handleGetTickets)
+	http.HandleFunc("/booking", handleBooking)
+	http.HandleFunc("/waitlist", handleWaitlist)
+	http.HandleFunc("/admin/tickets", handleGetUserTickets)
+	http.HandleFunc("/admin/role", handleSetRole)
+	http.HandleFunc("/admin/flight", handleAdminFlight)
+	http.HandleFunc("/admin/fare", handleAdminFare)
+	http.HandleFunc("/admin/airport", handleAdminAirport)
+	http.HandleFunc("/admin/schedule", handleAdminSchedule)
+	http.HandleFunc("/notifications", handleGetNotifications)
 	log.Fatal(http.ListenAndServe(port, nil))
 }
 
@@ -64,11 +106,427 @@ func handleGetTickets(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	token := r.Header.Get("Authorization")
+	token, ok := authorizedToken(w, r)
+	if !ok {
+		return
+	}
+
+	writeAndReturnResponse(w, models.Request{
+		Action: "tickets",
+		Auth:   token,
+	})
+}
+
+// handleBooking is an HTTP handler function that handles requests for finding and canceling bookings.
+// It checks the HTTP method of the request and calls the appropriate handler function based on the method.
+// If the method is neither GET nor DELETE, it returns a 405 Method Not Allowed status with an error message.
+//
+// Parameters:
+//   - w: http.ResponseWriter to write the HTTP response.
+//   - r: *http.Request to read the HTTP request.
+func handleBooking(w http.ResponseWriter, r *http.Request) {
+	allowCrossOrigin(w, r)
+	switch r.Method {
+	case http.MethodGet:
+		handleFindBooking(w, r)
+	case http.MethodDelete:
+		handleCancelBooking(w, r)
+	default:
+		http.Error(w, "only GET or DELETE allowed", http.StatusMethodNotAllowed)
+		return
+	}
+}
+
+// handleFindBooking handles HTTP GET requests to look a booking up by the lastName and reference query parameters.
+// It needs no authorization token, so customers can check a booking without logging in.
+//
+// Parameters:
+//   - w: http.ResponseWriter to write the HTTP response.
+//   - r: *http.Request to read the HTTP request.
+func handleFindBooking(w http.ResponseWriter, r *http.Request) {
+	queryParams := r.URL.Query()
+
+	writeAndReturnResponse(w, models.Request{
+		Action: "find-booking",
+		Data: models.FindBookingRequest{
+			LastName:  queryParams.Get("lastName"),
+			Reference: queryParams.Get("reference"),
+		},
+	})
+}
+
+// handleCancelBooking handles HTTP DELETE requests to cancel every leg of a booking of the user.
+// It decodes the request body into a CancelBookingRequest struct; if the decoding fails, it returns a 400 Bad Request status.
+//
+// Parameters:
+//   - w: http.ResponseWriter to write the HTTP response.
+//   - r: *http.Request to read the HTTP request.
+func handleCancelBooking(w http.ResponseWriter, r *http.Request) {
+	token, ok := authorizedToken(w, r)
+	if !ok {
+		return
+	}
+
+	var cancelRequest models.CancelBookingRequest
+
+	err := json.NewDecoder(r.Body).Decode(&cancelRequest)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	writeAndReturnResponse(w, models.Request{
+		Action: "cancel-booking",
+		Auth:   token,
+		Data:   cancelRequest,
+	})
+}
+
+// handleWaitlist is an HTTP handler function that handles requests for the waitlists of full flights.
+// GET lists the user's waitlist entries, POST joins the waitlist of a flight and DELETE leaves it.
+// If the method is neither GET, POST nor DELETE, it returns a 405 Method Not Allowed status with an error message.
+//
+// Parameters:
+//   - w: http.ResponseWriter to write the HTTP response.
+//   - r: *http.Request to read the HTTP request.
+func handleWaitlist(w http.ResponseWriter, r *http.Request) {
+	allowCrossOrigin(w, r)
+	switch r.Method {
+	case http.MethodGet:
+		handleGetWaitlist(w, r)
+	case http.MethodPost:
+		handleJoinWaitlist(w, r)
+	case http.MethodDelete:
+		handleLeaveWaitlist(w, r)
+	default:
+		http.Error(w, "only GET, POST or DELETE allowed", http.StatusMethodNotAllowed)
+		return
+	}
+}
+
+// handleGetWaitlist handles HTTP GET requests to list the waitlist entries of the user, with their positions.
+//
+// Parameters:
+//   - w: http.ResponseWriter to write the HTTP response.
+//   - r: *http.Request to read the HTTP request.
+func handleGetWaitlist(w http.ResponseWriter, r *http.Request) {
+	token, ok := authorizedToken(w, r)
+	if !ok {
+		return
+	}
+
+	writeAndReturnResponse(w, models.Request{
+		Action: "waitlist",
+		Auth:   token,
+	})
+}
+
+// handleJoinWaitlist handles HTTP POST requests to join the waitlist of a full flight.
+// It decodes the request body into a JoinWaitlistRequest struct; if the decoding fails, it returns a 400 Bad Request status.
+//
+// Parameters:
+//   - w: http.ResponseWriter to write the HTTP response.
+//   - r: *http.Request to read the HTTP request.
+func handleJoinWaitlist(w http.ResponseWriter, r *http.Request) {
+	token, ok := authorizedToken(w, r)
+	if !ok {
+		return
+	}
+
+	var joinRequest models.JoinWaitlistRequest
+
+	err := json.NewDecoder(r.Body).Decode(&joinRequest)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	writeAndReturnResponse(w, models.Request{
+		Action: "join-waitlist",
+		Auth:   token,
+		Data:   joinRequest,
+	})
+}
+
+// handleLeaveWaitlist handles HTTP DELETE requests to leave a waitlist.
+// It decodes the request body into a LeaveWaitlistRequest struct; if the decoding fails, it returns a 400 Bad Request status.
+//
+// Parameters:
+//   - w: http.ResponseWriter to write the HTTP response.
+//   - r: *http.Request to read the HTTP request.
+func handleLeaveWaitlist(w http.ResponseWriter, r *http.Request) {
+	token, ok := authorizedToken(w, r)
+	if !ok {
+		return
+	}
+
+	var leaveRequest models.LeaveWaitlistRequest
+
+	err := json.NewDecoder(r.Body).Decode(&leaveRequest)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	writeAndReturnResponse(w, models.Request{
+		Action: "leave-waitlist",
+		Auth:   token,
+		Data:   leaveRequest,
+	})
+}
+
+// handleGetUserTickets handles HTTP GET requests to retrieve the tickets of any user, named by the username query parameter.
+// The server only answers it for admins.
+// If the method is not GET, it returns a 405 Method Not Allowed status with an error message.
+//
+// Parameters:
+//   - w: http.ResponseWriter to write the HTTP response.
+//   - r: *http.Request to read the HTTP request.
+func handleGetUserTickets(w http.ResponseWriter, r *http.Request) {
+	allowCrossOrigin(w, r)
+
+	if r.Method != http.MethodGet {
+		http.Error(w, "only GET allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	token, ok := authorizedToken(w, r)
+	if !ok {
+		return
+	}
+
+	writeAndReturnResponse(w, models.Request{
+		Action: "user-tickets",
+		Auth:   token,
+		Data: models.UserTicketsRequest{
+			Username: r.URL.Query().Get("username"),
+		},
+	})
+}
+
+// handleSetRole handles HTTP PUT requests to change the role of a user.
+// It decodes the request body into a SetRoleRequest struct and sends it to the server, which only answers it for admins.
+// If the method is not PUT, it returns a 405 Method Not Allowed status with an error message.
+// If the decoding fails, it returns a 400 Bad Request status.
+//
+// Parameters:
+//   - w: http.ResponseWriter to write the HTTP response.
+//   - r: *http.Request to read the HTTP request.
+func handleSetRole(w http.ResponseWriter, r *http.Request) {
+	allowCrossOrigin(w, r)
+
+	if r.Method != http.MethodPut {
+		http.Error(w, "only PUT allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	token, ok := authorizedToken(w, r)
+	if !ok {
+		return
+	}
+
+	var roleRequest models.SetRoleRequest
+
+	err := json.NewDecoder(r.Body).Decode(&roleRequest)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	writeAndReturnResponse(w, models.Request{
+		Action: "set-role",
+		Auth:   token,
+		Data:   roleRequest,
+	})
+}
+
+// handleGetNotifications handles HTTP GET requests to retrieve the notifications waiting for the authenticated user.
+// If the method is not GET, it returns a 405 Method Not Allowed status with an error message.
+//
+// Parameters:
+//   - w: http.ResponseWriter to write the HTTP response.
+//   - r: *http.Request to read the HTTP request.
+func handleGetNotifications(w http.ResponseWriter, r *http.Request) {
+	allowCrossOrigin(w, r)
+
+	if r.Method != http.MethodGet {
+		http.Error(w, "only GET allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	token, ok := authorizedToken(w, r)
+	if !ok {
+		return
+	}
+
+	writeAndReturnResponse(w, models.Request{Action: "notifications", Auth: token})
+}
+
+// handleAdminFlight is an HTTP handler function that handles the administrative requests for flights.
+// A POST request creates a flight, a PUT request changes its capacity and a DELETE request cancels it.
+// The server only answers them for admins.
+// If the method is neither POST, PUT nor DELETE, it returns a 405 Method Not Allowed status with an error message.
+// If the decoding of the request body fails, it returns a 400 Bad Request status.
+//
+// Parameters:
+//   - w: http.ResponseWriter to write the HTTP response.
+//   - r: *http.Request to read the HTTP request.
+func handleAdminFlight(w http.ResponseWriter, r *http.Request) {
+	allowCrossOrigin(w, r)
+
+	var action string
+	var data interface{}
+
+	switch r.Method {
+	case http.MethodPost:
+		action, data = "admin-create-flight", &models.CreateFlightRequest{}
+	case http.MethodPut:
+		action, data = "admin-update-flight", &models.UpdateFlightRequest{}
+	case http.MethodDelete:
+		action, data = "admin-cancel-flight", &models.CancelFlightRequest{}
+	default:
+		http.Error(w, "only POST, PUT or DELETE allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	token, ok := authorizedToken(w, r)
+	if !ok {
+		return
+	}
+
+	err := json.NewDecoder(r.Body).Decode(data)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	writeAndReturnResponse(w, models.Request{
+		Action: action,
+		Auth:   token,
+		Data:   data,
+	})
+}
+
+// handleAdminFare is an HTTP handler function that handles the requests of admins to change the fare of a flight.
+// It checks the HTTP method of the request to ensure it's a PUT request.
+// If the method is not PUT, it returns a 405 Method Not Allowed status with an error message.
+// If the decoding of the request body fails, it returns a 400 Bad Request status.
+//
+// Parameters:
+//   - w: http.ResponseWriter to write the HTTP response.
+//   - r: *http.Request to read the HTTP request.
+func handleAdminFare(w http.ResponseWriter, r *http.Request) {
+	allowCrossOrigin(w, r)
+	if r.Method != http.MethodPut {
+		http.Error(w, "only PUT allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	token, ok := authorizedToken(w, r)
+	if !ok {
+		return
+	}
+
+	var fareRequest models.UpdateFareRequest
+
+	err := json.NewDecoder(r.Body).Decode(&fareRequest)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	writeAndReturnResponse(w, models.Request{
+		Action: "admin-update-fare",
+		Auth:   token,
+		Data:   fareRequest,
+	})
+}
+
+// handleAdminAirport is an HTTP handler function that handles the administrative requests for airports.
+// A POST request adds an airport, a PUT request renames or relocates it and a DELETE request decommissions it.
+// The server only answers them for admins.
+// If the method is neither POST, PUT nor DELETE, it returns a 405 Method Not Allowed status with an error message.
+// If the decoding of the request body fails, it returns a 400 Bad Request status.
+//
+// Parameters:
+//   - w: http.ResponseWriter to write the HTTP response.
+//   - r: *http.Request to read the HTTP request.
+func handleAdminAirport(w http.ResponseWriter, r *http.Request) {
+	allowCrossOrigin(w, r)
+
+	var action string
+	var data interface{}
+
+	switch r.Method {
+	case http.MethodPost:
+		action, data = "admin-create-airport", &models.CreateAirportRequest{}
+	case http.MethodPut:
+		action, data = "admin-update-airport", &models.UpdateAirportRequest{}
+	case http.MethodDelete:
+		action, data = "admin-decommission-airport", &models.DecommissionAirportRequest{}
+	default:
+		http.Error(w, "only POST, PUT or DELETE allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	token, ok := authorizedToken(w, r)
+	if !ok {
+		return
+	}
+
+	err := json.NewDecoder(r.Body).Decode(data)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	writeAndReturnResponse(w, models.Request{
+		Action: action,
+		Auth:   token,
+		Data:   data,
+	})
+}
+
+// handleAdminSchedule is an HTTP handler function that handles the administrative requests for recurring flights.
+// A POST request creates a schedule, generating its flights, and a DELETE request stops it from generating new ones.
+// The server only answers them for admins.
+// If the method is neither POST nor DELETE, it returns a 405 Method Not Allowed status with an error message.
+// If the decoding of the request body fails, it returns a 400 Bad Request status.
+//
+// Parameters:
+//   - w: http.ResponseWriter to write the HTTP response.
+//   - r: *http.Request to read the HTTP request.
+func handleAdminSchedule(w http.ResponseWriter, r *http.Request) {
+	allowCrossOrigin(w, r)
+
+	var action string
+	var data interface{}
+
+	switch r.Method {
+	case http.MethodPost:
+		action, data = "admin-create-schedule", &models.CreateScheduleRequest{}
+	case http.MethodDelete:
+		action, data = "admin-delete-schedule", &models.DeleteScheduleRequest{}
+	default:
+		http.Error(w, "only POST or DELETE allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	token, ok := authorizedToken(w, r)
+	if !ok {
+		return
+	}
+
+	err := json.NewDecoder(r.Body).Decode(data)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
 
 	writeAndReturnResponse(w, models.Request{
-		Action: "tickets",
+		Action: action,
 		Auth:   token,
+		Data:   data,
 	})
 }
 
@@ -102,7 +560,10 @@ func handleTicket(w http.ResponseWriter, r *http.Request) {
 //   - w: http.ResponseWriter to write the HTTP response.
 //   - r: *http.Request to read the HTTP request.
 func handleBuyTicket(w http.ResponseWriter, r *http.Request) {
-	token := r.Header.Get("Authorization")
+	token, ok := authorizedToken(w, r)
+	if !ok {
+		return
+	}
 	var buyTicket models.BuyTicket
 
 	err := json.NewDecoder(r.Body).Decode(&buyTicket)
@@ -129,7 +590,10 @@ func handleBuyTicket(w http.ResponseWriter, r *http.Request) {
 //   - r: *http.Request to read the HTTP request.
 func handleCancelTicket(w http.ResponseWriter, r *http.Request) {
 
-	token := r.Header.Get("Authorization")
+	token, ok := authorizedToken(w, r)
+	if !ok {
+		return
+	}
 
 	var ticketId models.CancelBuyRequest
 
@@ -164,7 +628,10 @@ func handleGetCart(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	token := r.Header.Get("Authorization")
+	token, ok := authorizedToken(w, r)
+	if !ok {
+		return
+	}
 
 	writeAndReturnResponse(w, models.Request{
 		Action: "cart",
@@ -172,9 +639,38 @@ func handleGetCart(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+// handleCheckout is an HTTP handler function that buys every reservation in the user's cart as a single order.
+// It checks the HTTP method of the request to ensure it's a POST request.
+// If the method is not POST, it returns a 405 Method Not Allowed status with an error message.
+// It extracts the user's authorization token from the request headers and sends a checkout request
+// to the server using the writeAndReturnResponse function.
+//
+// Parameters:
+//   - w: http.ResponseWriter to write the HTTP response.
+//   - r: *http.Request to read the HTTP request.
+func handleCheckout(w http.ResponseWriter, r *http.Request) {
+	allowCrossOrigin(w, r)
+
+	if r.Method != http.MethodPost {
+		http.Error(w, "only POST allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	token, ok := authorizedToken(w, r)
+	if !ok {
+		return
+	}
+
+	writeAndReturnResponse(w, models.Request{
+		Action: "checkout",
+		Auth:   token,
+	})
+}
+
 // handleReservation is an HTTP handler function that handles requests for making and canceling reservations.
-// It checks the HTTP method of the request and calls the appropriate handler function based on the method.
-// If the method is neither POST nor DELETE, it returns a 405 Method Not Allowed status with an error message.
+// It checks the HTTP method of the request and calls the appropriate handler function based on the method:
+// POST makes reservations, PUT extends the hold of a reservation and DELETE cancels one.
+// If the method is none of them, it returns a 405 Method Not Allowed status with an error message.
 //
 // Parameters:
 //   - w: http.ResponseWriter to write the HTTP response.
@@ -184,10 +680,12 @@ func handleReservation(w http.ResponseWriter, r *http.Request) {
 	switch r.Method {
 	case http.MethodPost:
 		handleMakeReservations(w, r)
+	case http.MethodPut:
+		handleExtendReservation(w, r)
 	case http.MethodDelete:
 		handleCancelReservation(w, r)
 	default:
-		http.Error(w, "only POST or DELETE allowed", http.StatusMethodNotAllowed)
+		http.Error(w, "only POST, PUT or DELETE allowed", http.StatusMethodNotAllowed)
 		return
 	}
 }
@@ -203,7 +701,10 @@ func handleReservation(w http.ResponseWriter, r *http.Request) {
 //   - r: *http.Request to read the HTTP request.
 func handleMakeReservations(w http.ResponseWriter, r *http.Request) {
 	// Extract the authorization token from the request headers
-	token := r.Header.Get("Authorization")
+	token, ok := authorizedToken(w, r)
+	if !ok {
+		return
+	}
 
 	var flightIds models.FlightsRequest
 
@@ -233,7 +734,10 @@ func handleMakeReservations(w http.ResponseWriter, r *http.Request) {
 //   - r: *http.Request to read the HTTP request.
 func handleCancelReservation(w http.ResponseWriter, r *http.Request) {
 
-	token := r.Header.Get("Authorization")
+	token, ok := authorizedToken(w, r)
+	if !ok {
+		return
+	}
 
 	var reservationId models.CancelReservationRequest
 
@@ -251,6 +755,36 @@ func handleCancelReservation(w http.ResponseWriter, r *http.Request) {
 
 }
 
+// handleExtendReservation is an HTTP handler function that processes requests for extending the hold of a reservation.
+// It extracts the user's authorization token from the request headers and decodes the request body into an ExtendReservationRequest struct.
+// If the decoding fails, it returns a 400 Bad Request status.
+// It then constructs a Request object with the appropriate action, authorization token, and reservation ID,
+// and sends it to the server using the writeAndReturnResponse function.
+//
+// Parameters:
+//   - w: http.ResponseWriter to write the HTTP response.
+//   - r: *http.Request to read the HTTP request.
+func handleExtendReservation(w http.ResponseWriter, r *http.Request) {
+	token, ok := authorizedToken(w, r)
+	if !ok {
+		return
+	}
+
+	var reservationId models.ExtendReservationRequest
+
+	err := json.NewDecoder(r.Body).Decode(&reservationId)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	writeAndReturnResponse(w, models.Request{
+		Action: "extend-reservation",
+		Auth:   token,
+		Data:   reservationId,
+	})
+}
+
 // handleGetFlights is an HTTP handler function that retrieves flight information based on the provided flight IDs.
 // It checks the HTTP method of the request to ensure it's a POST request.
 // If the method is not POST, it returns a 405 Method Not Allowed status with an error message.
@@ -270,7 +804,10 @@ func handleGetFlights(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	token := r.Header.Get("Authorization")
+	token, ok := authorizedToken(w, r)
+	if !ok {
+		return
+	}
 
 	var flightIds models.FlightsRequest
 
@@ -287,10 +824,44 @@ func handleGetFlights(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+// handleGetSeatMap handles HTTP GET requests for the seat map of the flight named by the flightId query parameter.
+// If the method is not GET, it returns a 405 Method Not Allowed status with an error message,
+// and if the flight ID is not a valid UUID, it returns a 400 Bad Request status.
+//
+// Parameters:
+//   - w: http.ResponseWriter to write the HTTP response.
+//   - r: *http.Request to read the HTTP request.
+func handleGetSeatMap(w http.ResponseWriter, r *http.Request) {
+	allowCrossOrigin(w, r)
+
+	if r.Method != http.MethodGet {
+		http.Error(w, "only GET allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	flightId, err := uuid.Parse(r.URL.Query().Get("flightId"))
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	token, ok := authorizedToken(w, r)
+	if !ok {
+		return
+	}
+
+	writeAndReturnResponse(w, models.Request{
+		Action: "seat-map",
+		Auth:   token,
+		Data:   models.SeatMapRequest{FlightId: flightId},
+	})
+}
+
 // handleGetRoute is an HTTP handler function that retrieves route information based on the provided source and destination.
 // It checks the HTTP method of the request to ensure it's a GET request.
 // If the method is not GET, it returns a 405 Method Not Allowed status with an error message.
-// It extracts the source and destination from the request query parameters and the user's authorization token from the request headers.
+// It extracts the source, the destination, the optional travel date ("date", as 2006-01-02), the optional cabin class ("cabin")
+// and the optional cost the route minimizes ("sort": distance, price or duration) from the request query parameters and the user's authorization token from the request headers.
 // It then constructs a Request object with the appropriate action, authorization token, and route request data,
 // and sends it to the server using the writeAndReturnResponse function.
 //
@@ -307,21 +878,50 @@ func handleGetRoute(w http.ResponseWriter, r *http.Request) {
 
 	src := queryParams.Get("src")
 	dest := queryParams.Get("dest")
+	date := queryParams.Get("date")
+	cabin := queryParams.Get("cabin")
+	sort := queryParams.Get("sort")
 
-	token := r.Header.Get("Authorization")
+	token, ok := authorizedToken(w, r)
+	if !ok {
+		return
+	}
 	writeAndReturnResponse(w, models.Request{
 		Action: "route",
 		Auth:   token,
 		Data: models.RouteRequest{
 			Source: src,
 			Dest:   dest,
+			Date:   date,
+			Cabin:  models.CabinClass(cabin),
+			Sort:   models.RouteSort(sort),
 		},
 	})
 }
 
+// handleUser is an HTTP handler function that handles requests for the authenticated user's account.
+// It checks the HTTP method of the request and calls the appropriate handler function based on the method.
+// If the method is neither GET, PUT nor DELETE, it returns a 405 Method Not Allowed status with an error message.
+//
+// Parameters:
+//   - w: http.ResponseWriter to write the HTTP response.
+//   - r: *http.Request to read the HTTP request.
+func handleUser(w http.ResponseWriter, r *http.Request) {
+	allowCrossOrigin(w, r)
+	switch r.Method {
+	case http.MethodGet:
+		handleGetUser(w, r)
+	case http.MethodPut:
+		handleUpdateProfile(w, r)
+	case http.MethodDelete:
+		handleDeleteAccount(w, r)
+	default:
+		http.Error(w, "only GET, PUT or DELETE allowed", http.StatusMethodNotAllowed)
+		return
+	}
+}
+
 // handleGetUser is an HTTP handler function that retrieves user information.
-// It checks the HTTP method of the request to ensure it's a GET request.
-// If the method is not GET, it returns a 405 Method Not Allowed status with an error message.
 // It extracts the user's authorization token from the request headers and constructs a Request object
 // with the appropriate action and authorization token.
 // The constructed Request object is then sent to the server using the writeAndReturnResponse function.
@@ -330,15 +930,201 @@ func handleGetRoute(w http.ResponseWriter, r *http.Request) {
 //   - w: http.ResponseWriter to write the HTTP response.
 //   - r: *http.Request to read the HTTP request.
 func handleGetUser(w http.ResponseWriter, r *http.Request) {
+	token, ok := authorizedToken(w, r)
+	if !ok {
+		return
+	}
+
+	writeAndReturnResponse(w, models.Request{Action: "get-user", Auth: token})
+}
+
+// handleUpdateProfile is an HTTP handler function that changes the name and/or username of the authenticated user.
+// It extracts the user's authorization token from the request headers and decodes the request body into an UpdateProfileRequest struct.
+// If the decoding fails, it returns a 400 Bad Request status.
+// It then constructs a Request object with the appropriate action, authorization token, and profile data,
+// and sends it to the server using the writeAndReturnResponse function.
+//
+// Parameters:
+//   - w: http.ResponseWriter to write the HTTP response.
+//   - r: *http.Request to read the HTTP request.
+func handleUpdateProfile(w http.ResponseWriter, r *http.Request) {
+	token, ok := authorizedToken(w, r)
+	if !ok {
+		return
+	}
+
+	var profile models.UpdateProfileRequest
+
+	err := json.NewDecoder(r.Body).Decode(&profile)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	writeAndReturnResponse(w, models.Request{
+		Action: "update-profile",
+		Auth:   token,
+		Data:   profile,
+	})
+}
+
+// handleDeleteAccount is an HTTP handler function that deletes the authenticated user's account.
+// It extracts the user's authorization token from the request headers and decodes the request body into a DeleteAccountRequest struct,
+// which carries the password confirming the deletion. If the decoding fails, it returns a 400 Bad Request status.
+// It then constructs a Request object with the appropriate action, authorization token, and password,
+// and sends it to the server using the writeAndReturnResponse function.
+//
+// Parameters:
+//   - w: http.ResponseWriter to write the HTTP response.
+//   - r: *http.Request to read the HTTP request.
+func handleDeleteAccount(w http.ResponseWriter, r *http.Request) {
+	token, ok := authorizedToken(w, r)
+	if !ok {
+		return
+	}
+
+	var deleteRequest models.DeleteAccountRequest
+
+	err := json.NewDecoder(r.Body).Decode(&deleteRequest)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	writeAndReturnResponse(w, models.Request{
+		Action: "delete-account",
+		Auth:   token,
+		Data:   deleteRequest,
+	})
+}
+
+// handleChangePassword handles HTTP PUT requests to change the authenticated user's password.
+// It checks the request method to ensure it's a PUT request and decodes the request body into a ChangePasswordRequest struct.
+// If the method is not PUT, it returns a 405 Method Not Allowed status with an error message.
+// If the decoding fails, it returns a 400 Bad Request status.
+// It then constructs a Request object with the appropriate action, authorization token, and passwords,
+// and sends it to the server using the writeAndReturnResponse function.
+//
+// Parameters:
+//   - w: http.ResponseWriter to write the HTTP response.
+//   - r: *http.Request to read the HTTP request.
+func handleChangePassword(w http.ResponseWriter, r *http.Request) {
 	allowCrossOrigin(w, r)
-	if r.Method != http.MethodGet {
-		http.Error(w, "only GET allowed", http.StatusMethodNotAllowed)
+
+	if r.Method != http.MethodPut {
+		http.Error(w, "only PUT allowed", http.StatusMethodNotAllowed)
 		return
 	}
 
-	token := r.Header.Get("Authorization")
+	token, ok := authorizedToken(w, r)
+	if !ok {
+		return
+	}
 
-	writeAndReturnResponse(w, models.Request{Action: "get-user", Auth: token})
+	var changeRequest models.ChangePasswordRequest
+
+	err := json.NewDecoder(r.Body).Decode(&changeRequest)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	writeAndReturnResponse(w, models.Request{
+		Action: "change-password",
+		Auth:   token,
+		Data:   changeRequest,
+	})
+}
+
+// handleRegister handles HTTP POST requests to create a new user account.
+// It checks the request method to ensure it's a POST request and decodes the request body into a RegisterRequest struct.
+// If the method is not POST, it returns a 405 Method Not Allowed status with an error message.
+// If the decoding fails, it returns a 400 Bad Request status.
+// It then constructs a Request object with the appropriate action and account data, and sends it to the server using the writeAndReturnResponse function.
+//
+// Parameters:
+//   - w: http.ResponseWriter to write the HTTP response.
+//   - r: *http.Request to read the HTTP request.
+func handleRegister(w http.ResponseWriter, r *http.Request) {
+	allowCrossOrigin(w, r)
+
+	if r.Method != http.MethodPost {
+		http.Error(w, "only POST allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var registerRequest models.RegisterRequest
+	err := json.NewDecoder(r.Body).Decode(&registerRequest)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	writeAndReturnResponse(w, models.Request{
+		Action: "register",
+		Data:   registerRequest,
+	})
+}
+
+// handleSessions is an HTTP handler function that handles requests for the authenticated user's sessions.
+// A GET request lists the sessions of the user, and a DELETE request revokes the session given in the request body.
+// If the method is neither GET nor DELETE, it returns a 405 Method Not Allowed status with an error message.
+// If the decoding of the request body fails, it returns a 400 Bad Request status.
+//
+// Parameters:
+//   - w: http.ResponseWriter to write the HTTP response.
+//   - r: *http.Request to read the HTTP request.
+func handleSessions(w http.ResponseWriter, r *http.Request) {
+	allowCrossOrigin(w, r)
+
+	token, ok := authorizedToken(w, r)
+	if !ok {
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		writeAndReturnResponse(w, models.Request{Action: "sessions", Auth: token})
+	case http.MethodDelete:
+		var revokeRequest models.RevokeSessionRequest
+
+		err := json.NewDecoder(r.Body).Decode(&revokeRequest)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		writeAndReturnResponse(w, models.Request{
+			Action: "revoke-session",
+			Auth:   token,
+			Data:   revokeRequest,
+		})
+	default:
+		http.Error(w, "only GET or DELETE allowed", http.StatusMethodNotAllowed)
+		return
+	}
+}
+
+// handleRefreshToken handles HTTP POST requests to exchange a session token that is still valid for a new one,
+// with a new expiry time.
+// If the method is not POST, it returns a 405 Method Not Allowed status with an error message.
+//
+// Parameters:
+//   - w: http.ResponseWriter to write the HTTP response.
+//   - r: *http.Request to read the HTTP request.
+func handleRefreshToken(w http.ResponseWriter, r *http.Request) {
+	allowCrossOrigin(w, r)
+	if r.Method != http.MethodPost {
+		http.Error(w, "only POST allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	token, ok := authorizedToken(w, r)
+	if !ok {
+		return
+	}
+
+	writeAndReturnResponse(w, models.Request{Action: "refresh-token", Auth: token})
 }
 
 // handleLogout handles HTTP GET requests to log out the authenticated user.
@@ -356,7 +1142,10 @@ func handleLogout(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	token := r.Header.Get("Authorization")
+	token, ok := authorizedToken(w, r)
+	if !ok {
+		return
+	}
 
 	writeAndReturnResponse(w, models.Request{Action: "logout", Auth: token})
 }
@@ -365,6 +1154,7 @@ func handleLogout(w http.ResponseWriter, r *http.Request) {
 // It checks the request method to ensure it's a POST request and retrieves the user's login credentials from the request body.
 // If the method is not POST, it returns a 405 Method Not Allowed status with an error message.
 // If the decoding of the login credentials fails, it returns a 400 Bad Request status.
+// When the credentials do not name the device, the User-Agent header is used, so the user can tell their sessions apart.
 // It then constructs a Request object with the appropriate action and login credentials, and sends it to the server using the writeAndReturnResponse function.
 //
 // Parameters:
@@ -385,44 +1175,54 @@ func handleLogin(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if logCred.Device == "" {
+		logCred.Device = r.UserAgent()
+	}
+
 	writeAndReturnResponse(w, models.Request{
 		Action: "login",
 		Data:   logCred,
 	})
 }
 
-// writeAndReturnResponse is a function that establishes a connection to the server,
-// sends a request to the server, receives the server's response, and writes the response to the HTTP response writer.
+// authorizedToken reads the session token from the Authorization header and, when a token secret is configured,
+// verifies it locally, so requests with forged or expired tokens are answered without a round trip to the TCP server.
+// Rejected requests are answered with a 401 Unauthorized status.
 //
 // Parameters:
-// - w: http.ResponseWriter to write the HTTP response.
-// - req: models.Request representing the request to be sent to the server.
-func writeAndReturnResponse(w http.ResponseWriter, req models.Request) {
-	conn, err := net.Dial(CONN_TYPE, CONN_HOST+":"+CONN_PORT)
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-		return
-	}
-	defer conn.Close()
+//   - w: http.ResponseWriter to write the HTTP response when the token is rejected.
+//   - r: *http.Request to read the token from.
+//
+// Return:
+//   - The token, to be forwarded to the server.
+//   - false if the token was rejected and the response was already written, true otherwise.
+func authorizedToken(w http.ResponseWriter, r *http.Request) (string, bool) {
+	token := r.Header.Get("Authorization")
 
-	buffer, _ := json.Marshal(req)
-	_, writeErr := conn.Write(buffer)
-	if writeErr != nil {
-		http.Error(w, writeErr.Error(), http.StatusInternalServerError)
-		return
+	if tokens == nil {
+		return token, true
 	}
 
-	receive := make([]byte, 2048)
-	n, readErr := conn.Read(receive)
-	if readErr != nil {
-		http.Error(w, readErr.Error(), http.StatusInternalServerError)
-		return
+	if _, err := tokens.Verify(token); err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(models.Response{Error: err.Error()})
+		return "", false
 	}
 
-	var responseData models.Response
-	err = json.Unmarshal(receive[:n], &responseData)
+	return token, true
+}
+
+// writeAndReturnResponse is a function that sends a request to the server over one of the pooled connections,
+// waits for the server's response, and writes the response to the HTTP response writer.
+//
+// Parameters:
+// - w: http.ResponseWriter to write the HTTP response.
+// - req: models.Request representing the request to be sent to the server.
+func writeAndReturnResponse(w http.ResponseWriter, req models.Request) {
+	responseData, err := pool.Do(req)
 	if err != nil {
-		http.Error(w, "Failed to decode response from server", http.StatusInternalServerError)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
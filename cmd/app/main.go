@@ -1,22 +1,72 @@
 package main
 
 import (
+	"flag"
 	"fmt"
 	"net"
 	"os"
 	"time"
+	"vendepass/internal/auth"
+	"vendepass/internal/codec"
 	"vendepass/internal/dao"
+	"vendepass/internal/models"
+	"vendepass/internal/pricing"
 	"vendepass/internal/server"
 )
 
 const (
-	port      = ":8888"
-	timeLimit = 30 * time.Minute
+	port           = ":8888"
+	sessionTimeout = 30 * time.Minute
 )
 
 // main function is the entry point of the application.
 // It sets up the server, handles incoming connections, and manages flight reservations.
 func main() {
+	maxMessageSize := flag.Uint("max-message-size", codec.DefaultMaxMessageSize, "maximum size in bytes of a single request or response")
+	storage := flag.String("storage", dao.MemoryStorage, "storage backend for flights, schedules, clients, bookings and airports: memory, bolt or journal")
+	dbPath := flag.String("db", "vendepass.db", "database file used by the bolt storage, or directory used by the journal storage")
+	snapshotInterval := flag.Duration("snapshot-interval", 5*time.Minute, "time between two snapshots of the journal storage")
+	tokenSecret := flag.String("token-secret", os.Getenv("VENDEPASS_TOKEN_SECRET"), "secret used to sign the session tokens, shared with the api; a random one is used if empty")
+	tokenTTL := flag.Duration("token-ttl", auth.DefaultTokenTTL, "time a session token is accepted before it must be refreshed")
+	admin := flag.String("admin", "", "username of a client promoted to admin at startup")
+	pricingRules := flag.String("pricing-rules", "", "JSON file with the rules adjusting the fares to load factor and time to departure; base fares are charged if empty")
+	holdDuration := flag.Duration("hold-duration", server.DefaultHoldDuration, "time a reservation holds its seat before it expires, unless it is bought or extended")
+	maxHoldExtensions := flag.Uint("max-hold-extensions", server.DefaultMaxHoldExtensions, "number of times the hold of a reservation can be extended")
+	flag.Parse()
+
+	server.Codec = codec.New(uint32(*maxMessageSize))
+	server.HoldDuration = *holdDuration
+	server.MaxHoldExtensions = *maxHoldExtensions
+
+	if *tokenSecret == "" {
+		fmt.Println("Nenhum segredo de tokens configurado; os tokens deixarão de valer ao reiniciar o servidor")
+		server.Tokens = auth.NewSigner(auth.RandomSecret(), *tokenTTL)
+	} else {
+		server.Tokens = auth.NewSigner([]byte(*tokenSecret), *tokenTTL)
+	}
+
+	if *pricingRules != "" {
+		rules, err := pricing.LoadRules(*pricingRules)
+		if err != nil {
+			fmt.Println("Regras de preço não carregadas", err)
+			os.Exit(1)
+		}
+		server.Pricing = rules
+	}
+
+	if err := dao.Configure(*storage, *dbPath); err != nil {
+		fmt.Println("Armazenamento não configurado", err)
+		os.Exit(1)
+	}
+	defer dao.Close()
+
+	if *admin != "" {
+		if err := promoteToAdmin(*admin); err != nil {
+			fmt.Println("Administrador não configurado", err)
+			os.Exit(1)
+		}
+	}
+
 	listener, err := net.Listen("tcp", port)
 	if err != nil {
 		fmt.Println("Listener não criado", err)
@@ -26,12 +76,16 @@ func main() {
 
 	fmt.Println("servidor ouvindo na porta :8888")
 
-	go server.CleanupSessions(timeLimit)
+	go server.CleanupSessions(sessionTimeout)
+	go dao.SnapshotPeriodically(*snapshotInterval)
 
 	for _, flight := range dao.GetFlightDAO().FindAll() {
-		go flight.ProcessReservations()
+		flight.Start()
 	}
 
+	fmt.Printf("%d voos programados criados\n", server.GenerateFlights(time.Now(), server.ScheduleHorizon))
+	go server.GenerateFlightsPeriodically(time.Hour)
+
 	for {
 		conn, err := listener.Accept()
 		if err != nil {
@@ -43,3 +97,24 @@ func main() {
 	}
 
 }
+
+// promoteToAdmin gives the admin role to a client, so a new installation has someone allowed to
+// run the administrative actions and to grant roles to other clients.
+//
+// Parameters:
+//   - username: The username of the client to be promoted.
+//
+// Return:
+//   - An error if the client does not exist or could not be stored.
+func promoteToAdmin(username string) error {
+	client, err := dao.GetClientDAO().FindByUsername(username)
+	if err != nil {
+		return err
+	}
+	if client.GetRole() == models.RoleAdmin {
+		return nil
+	}
+
+	client.Role = models.RoleAdmin
+	return dao.GetClientDAO().Update(client)
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"vendepass/internal/auth"
+	"vendepass/internal/dao"
+	"vendepass/internal/models"
+)
+
+// main replaces every plain text password by its bcrypt hash.
+// With the memory storage it rewrites the clients stub file; with the bolt or journal storage
+// it rewrites the clients already imported into them. Passwords that are already hashed are kept,
+// so running it more than once is harmless.
+func main() {
+	storage := flag.String("storage", dao.MemoryStorage, "storage to migrate: memory (the stub file), bolt or journal")
+	dbPath := flag.String("db", "vendepass.db", "database file used by the bolt storage, or directory used by the journal storage")
+	stubPath := flag.String("file", filepath.Join("internal", "stubs", "clients.json"), "clients stub file migrated by the memory storage")
+	flag.Parse()
+
+	var migrated int
+	var err error
+
+	if *storage == dao.MemoryStorage {
+		migrated, err = migrateStubFile(*stubPath)
+	} else {
+		migrated, err = migrateStorage(*storage, *dbPath)
+	}
+
+	if err != nil {
+		fmt.Println("Erro na migração:", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("%d senhas convertidas\n", migrated)
+}
+
+// migrateStubFile hashes the plain text passwords of the clients stub file and rewrites it.
+//
+// Parameters:
+//   - path: The path of the clients stub file.
+//
+// Return:
+//   - The number of passwords that were hashed.
+//   - An error if the file could not be read, a password could not be hashed or the file could not be written.
+func migrateStubFile(path string) (int, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return 0, err
+	}
+
+	var clients []*models.Client
+	if err := json.Unmarshal(b, &clients); err != nil {
+		return 0, err
+	}
+
+	migrated := 0
+	for _, client := range clients {
+		changed, err := hashClientPassword(client)
+		if err != nil {
+			return migrated, err
+		}
+		if changed {
+			migrated++
+		}
+	}
+
+	if migrated == 0 {
+		return 0, nil
+	}
+
+	b, err = json.MarshalIndent(clients, "", "  ")
+	if err != nil {
+		return 0, err
+	}
+
+	return migrated, os.WriteFile(path, append(b, '\n'), 0644)
+}
+
+// migrateStorage hashes the plain text passwords of the clients kept by a durable storage.
+//
+// Parameters:
+//   - storage: The storage backend, as accepted by dao.Configure.
+//   - path: The path of the storage.
+//
+// Return:
+//   - The number of passwords that were hashed.
+//   - An error if the storage could not be opened or a client could not be updated.
+func migrateStorage(storage string, path string) (int, error) {
+	if err := dao.Configure(storage, path); err != nil {
+		return 0, err
+	}
+	defer dao.Close()
+
+	migrated := 0
+	for _, client := range dao.GetClientDAO().FindAll() {
+		changed, err := hashClientPassword(client)
+		if err != nil {
+			return migrated, err
+		}
+		if !changed {
+			continue
+		}
+		if err := dao.GetClientDAO().Update(client); err != nil {
+			return migrated, err
+		}
+		migrated++
+	}
+
+	return migrated, nil
+}
+
+// hashClientPassword replaces the password of a client by its hash, unless it is already hashed.
+func hashClientPassword(client *models.Client) (bool, error) {
+	if auth.IsHashed(client.Password) {
+		return false, nil
+	}
+
+	hash, err := auth.HashPassword(client.Password)
+	if err != nil {
+		return false, fmt.Errorf("client %s: %w", client.Username, err)
+	}
+
+	client.Password = hash
+	return true, nil
+}
@@ -2,40 +2,51 @@ package main
 
 import (
 	"encoding/json"
+	"flag"
 	"fmt"
 	"io"
 	"log"
-	"net"
 	"os"
 	"path/filepath"
 	"sync"
-	"vendepass/internal/dao"
+	"vendepass/internal/client"
+	"vendepass/internal/codec"
 	"vendepass/internal/models"
 
 	"github.com/google/uuid"
 )
 
 type Auth struct {
-	Token uuid.UUID `json:"token"`
+	Token string `json:"token"`
 }
 
 func main() {
 	// var clients []models.Client
 	// readJson(&clients)
 
+	// As senhas guardadas são hashes, então as credenciais de teste vêm de um arquivo
+	// com a lista de models.LoginCredentials em texto puro. O arquivo padrão traz as senhas
+	// dos clientes de internal/stubs/clients.json.
+	credentialsPath := flag.String("credentials", filepath.Join("internal", "stubs", "credentials.json"), "arquivo JSON com as credenciais dos clientes de teste")
+	flag.Parse()
+
+	var credentials []models.LoginCredentials
+	b, err := os.ReadFile(*credentialsPath)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := json.Unmarshal(b, &credentials); err != nil || len(credentials) == 0 {
+		log.Fatal("Error reading credentials:", err)
+	}
+
 	var response *models.Response
-	var tokens []uuid.UUID
+	var tokens []string
 	var res Auth
 	var bytes []byte
-	clients := dao.GetClientDAO().FindAll()
 	for i := 0; i < 100; i++ {
-		client := clients[i%len(clients)]
 		response = writeAndReturnResponse(models.Request{
 			Action: "login",
-			Data: models.LoginCredentials{
-				Username: client.Username,
-				Password: client.Password,
-			},
+			Data:   credentials[i%len(credentials)],
 		})
 		var error models.Response
 
@@ -57,7 +68,7 @@ func main() {
 	for _, token := range tokens {
 		wg.Add(1)
 
-		go func(t uuid.UUID) {
+		go func(t string) {
 			defer wg.Done() // Marca essa goroutine como concluída ao terminar
 
 			// Espera até que a sinalização para iniciar as reservas seja recebida
@@ -65,7 +76,7 @@ func main() {
 
 			response := writeAndReturnResponse(models.Request{
 				Action: "reservation",
-				Auth:   t.String(),
+				Auth:   t,
 				Data: models.FlightsRequest{
 					FlightIds: []uuid.UUID{id},
 				},
@@ -113,28 +124,11 @@ func readJson(clients *[]models.Client) {
 
 }
 
-func writeAndReturnResponse(req models.Request) *models.Response {
-	conn, err := net.Dial("tcp", "localhost"+":"+"8888")
-	if err != nil {
-		return nil
-	}
-	defer conn.Close()
-
-	buffer, _ := json.Marshal(req)
-
-	_, writeErr := conn.Write(buffer)
-	if writeErr != nil {
-		return nil
-	}
+// pool pipelines every request of the test over a few long-lived connections.
+var pool = client.NewPool("tcp", "localhost"+":"+"8888", 4, codec.New(codec.DefaultMaxMessageSize), 0)
 
-	receive := make([]byte, 2048)
-	n, readErr := conn.Read(receive)
-	if readErr != nil {
-		return nil
-	}
-
-	var responseData models.Response
-	err = json.Unmarshal(receive[:n], &responseData)
+func writeAndReturnResponse(req models.Request) *models.Response {
+	responseData, err := pool.Do(req)
 	if err != nil {
 		return nil
 	}
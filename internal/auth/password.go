@@ -0,0 +1,49 @@
+// Package auth implements the credential handling of the server.
+package auth
+
+import (
+	"strings"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// HashPassword hashes a plain text password with bcrypt, using the default cost.
+//
+// Parameters:
+//   - password: The plain text password.
+//
+// Return:
+//   - The bcrypt hash of the password.
+//   - An error if the password could not be hashed, e.g. when it is longer than 72 bytes.
+func HashPassword(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", err
+	}
+	return string(hash), nil
+}
+
+// PasswordMatches checks if a plain text password matches a stored bcrypt hash.
+// Stored values that are not bcrypt hashes never match, so credentials that were not migrated cannot be used.
+//
+// Parameters:
+//   - hash: The stored bcrypt hash.
+//   - password: The plain text password provided by the user.
+//
+// Return:
+//   - true if the password matches the hash, false otherwise.
+func PasswordMatches(hash string, password string) bool {
+	if !IsHashed(hash) {
+		return false
+	}
+	return bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)) == nil
+}
+
+// IsHashed reports whether a stored password is already a bcrypt hash.
+func IsHashed(password string) bool {
+	if !strings.HasPrefix(password, "$2") {
+		return false
+	}
+	_, err := bcrypt.Cost([]byte(password))
+	return err == nil
+}
@@ -0,0 +1,142 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// DefaultTokenTTL is how long a token is accepted after it is issued, unless the signer is given another duration.
+const DefaultTokenTTL = 15 * time.Minute
+
+var (
+	// ErrInvalidToken is returned for tokens that are malformed or whose signature does not match.
+	ErrInvalidToken = errors.New("invalid token")
+	// ErrExpiredToken is returned for well signed tokens whose expiry time has passed.
+	ErrExpiredToken = errors.New("token expired")
+)
+
+// tokenHeader is the fixed header of every token, in the JWT format.
+var tokenHeader = base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"HS256","typ":"JWT"}`))
+
+// Claims are the data carried by a token. A token names the session it was issued for,
+// so logging out or revoking the session invalidates the token before it expires.
+type Claims struct {
+	SessionId uuid.UUID `json:"sid"`
+	ClientId  uuid.UUID `json:"sub"`
+	IssuedAt  int64     `json:"iat"`
+	ExpiresAt int64     `json:"exp"`
+}
+
+// Expiry returns the expiry time of the claims.
+func (c Claims) Expiry() time.Time {
+	return time.Unix(c.ExpiresAt, 0)
+}
+
+// Signer issues and verifies tokens signed with HMAC-SHA256, in the JWT compact format.
+// The server and the gateway verify the same tokens as long as they share the secret.
+type Signer struct {
+	secret []byte
+	ttl    time.Duration
+}
+
+// NewSigner creates a Signer.
+//
+// Parameters:
+//   - secret: The key used to sign the tokens. It should have at least 32 random bytes.
+//   - ttl: How long an issued token is accepted. If zero, DefaultTokenTTL is used.
+//
+// Return:
+//   - A pointer to the new Signer.
+func NewSigner(secret []byte, ttl time.Duration) *Signer {
+	if ttl == 0 {
+		ttl = DefaultTokenTTL
+	}
+	return &Signer{secret: secret, ttl: ttl}
+}
+
+// RandomSecret returns a new random secret, for servers started without a configured one.
+// Tokens signed with it are only accepted until the process exits.
+func RandomSecret() []byte {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		panic(err)
+	}
+	return secret
+}
+
+// Issue creates a token for a session of a client, valid from now until the signer's TTL elapses.
+//
+// Parameters:
+//   - sessionId: The ID of the session the token belongs to.
+//   - clientId: The ID of the client owning the session.
+//
+// Return:
+//   - The signed token.
+//   - The claims carried by the token.
+func (s *Signer) Issue(sessionId uuid.UUID, clientId uuid.UUID) (string, Claims) {
+	now := time.Now()
+	claims := Claims{
+		SessionId: sessionId,
+		ClientId:  clientId,
+		IssuedAt:  now.Unix(),
+		ExpiresAt: now.Add(s.ttl).Unix(),
+	}
+
+	// Marshalling a struct of UUIDs and integers cannot fail.
+	payload, _ := json.Marshal(claims)
+	unsigned := tokenHeader + "." + base64.RawURLEncoding.EncodeToString(payload)
+
+	return unsigned + "." + s.sign(unsigned), claims
+}
+
+// Verify checks the signature and the expiry time of a token.
+//
+// Parameters:
+//   - token: The token to be verified.
+//
+// Return:
+//   - The claims carried by the token.
+//   - ErrInvalidToken if the token is malformed or not signed with the signer's secret,
+//     ErrExpiredToken if it has expired, or nil if it is valid.
+func (s *Signer) Verify(token string) (Claims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 || parts[0] != tokenHeader {
+		return Claims{}, ErrInvalidToken
+	}
+
+	expected := s.sign(parts[0] + "." + parts[1])
+	if !hmac.Equal([]byte(parts[2]), []byte(expected)) {
+		return Claims{}, ErrInvalidToken
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return Claims{}, ErrInvalidToken
+	}
+
+	var claims Claims
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return Claims{}, ErrInvalidToken
+	}
+
+	if !time.Now().Before(claims.Expiry()) {
+		return claims, ErrExpiredToken
+	}
+
+	return claims, nil
+}
+
+// sign returns the encoded HMAC-SHA256 signature of the header and payload of a token.
+func (s *Signer) sign(unsigned string) string {
+	mac := hmac.New(sha256.New, s.secret)
+	mac.Write([]byte(unsigned))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
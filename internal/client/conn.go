@@ -0,0 +1,201 @@
+// Package client implements the client side of the TCP protocol spoken by the server.
+// A single connection carries many requests at the same time, each tagged with an ID that the
+// server echoes back, so responses are matched to their requests even when they arrive out of order.
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+	"vendepass/internal/codec"
+	"vendepass/internal/models"
+
+	"github.com/google/uuid"
+)
+
+// ErrClosed is returned for requests sent over, or still waiting on, a connection that was closed.
+var ErrClosed = errors.New("connection closed")
+
+// ErrTimeout is returned when the server does not answer a request in time.
+var ErrTimeout = errors.New("request timed out")
+
+// ErrInvalidResponse is returned when the response to a request arrives but cannot be decoded.
+var ErrInvalidResponse = errors.New("invalid response")
+
+// reply is what the read loop hands to the request waiting for it.
+type reply struct {
+	response models.Response
+	err      error
+}
+
+// Conn is a long-lived connection to the server that can be shared by many goroutines.
+type Conn struct {
+	conn    net.Conn
+	codec   *codec.Codec
+	timeout time.Duration
+
+	writeMu sync.Mutex
+
+	mu      sync.Mutex
+	pending map[string]chan reply
+	err     error
+}
+
+// Dial connects to the server and starts reading its responses in the background.
+//
+// Parameters:
+//   - network: The network of the server, usually "tcp".
+//   - address: The address of the server.
+//   - c: The codec used to frame the messages.
+//   - timeout: How long Do waits for a response. Zero waits forever.
+//
+// Return:
+//   - A pointer to the connection, or nil if the server could not be reached.
+//   - An error if the server could not be reached.
+func Dial(network, address string, c *codec.Codec, timeout time.Duration) (*Conn, error) {
+	netConn, err := net.Dial(network, address)
+	if err != nil {
+		return nil, err
+	}
+
+	conn := &Conn{
+		conn:    netConn,
+		codec:   c,
+		timeout: timeout,
+		pending: make(map[string]chan reply),
+	}
+
+	go conn.readLoop()
+
+	return conn, nil
+}
+
+// Do sends a request and waits for its response.
+// If the request has no ID, a new one is generated before sending it.
+//
+// Parameters:
+//   - request: The request to be sent.
+//
+// Return:
+//   - The response of the server to this request.
+//   - ErrClosed if the connection broke before the response arrived, ErrTimeout if the server took too long,
+//     ErrInvalidResponse if the response could not be decoded, or the error that prevented the request from being written.
+func (c *Conn) Do(request models.Request) (models.Response, error) {
+	if request.Id == "" {
+		request.Id = uuid.NewString()
+	}
+
+	wait := make(chan reply, 1)
+
+	c.mu.Lock()
+	if c.err != nil {
+		c.mu.Unlock()
+		return models.Response{}, c.err
+	}
+	c.pending[request.Id] = wait
+	c.mu.Unlock()
+
+	c.writeMu.Lock()
+	err := c.codec.Write(c.conn, request)
+	c.writeMu.Unlock()
+
+	if err != nil {
+		c.forget(request.Id)
+		c.fail()
+		return models.Response{}, err
+	}
+
+	var timeout <-chan time.Time
+	if c.timeout > 0 {
+		timer := time.NewTimer(c.timeout)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+
+	select {
+	case reply, ok := <-wait:
+		if !ok {
+			return models.Response{}, ErrClosed
+		}
+		return reply.response, reply.err
+	case <-timeout:
+		c.forget(request.Id)
+		return models.Response{}, ErrTimeout
+	}
+}
+
+// Close closes the connection. Requests still waiting for a response fail with ErrClosed.
+func (c *Conn) Close() error {
+	c.fail()
+	return c.conn.Close()
+}
+
+// Broken reports whether the connection can no longer be used.
+func (c *Conn) Broken() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.err != nil
+}
+
+// readLoop reads responses until the connection breaks and hands each of them to the
+// request waiting for its ID. Responses nobody is waiting for anymore are discarded.
+// A response that cannot be decoded fails the request named by its ID, when the ID can still be read;
+// otherwise it is skipped.
+func (c *Conn) readLoop() {
+	for {
+		var payload json.RawMessage
+		err := c.codec.Read(c.conn, &payload)
+		if err != nil {
+			if codec.IsFormatError(err) {
+				continue
+			}
+			c.fail()
+			c.conn.Close()
+			return
+		}
+
+		var result reply
+		if err := json.Unmarshal(payload, &result.response); err != nil {
+			var tagged struct{ Id string }
+			if json.Unmarshal(payload, &tagged) != nil || tagged.Id == "" {
+				continue
+			}
+			result = reply{response: models.Response{Id: tagged.Id}, err: fmt.Errorf("%w: %v", ErrInvalidResponse, err)}
+		}
+
+		c.mu.Lock()
+		wait, exists := c.pending[result.response.Id]
+		delete(c.pending, result.response.Id)
+		c.mu.Unlock()
+
+		if exists {
+			wait <- result
+		}
+	}
+}
+
+// forget stops waiting for the response of the request with the given ID.
+func (c *Conn) forget(id string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.pending, id)
+}
+
+// fail marks the connection as broken and releases every request still waiting for a response.
+func (c *Conn) fail() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.err != nil {
+		return
+	}
+
+	c.err = ErrClosed
+	for id, wait := range c.pending {
+		close(wait)
+		delete(c.pending, id)
+	}
+}
@@ -0,0 +1,98 @@
+package client
+
+import (
+	"sync"
+	"time"
+	"vendepass/internal/codec"
+	"vendepass/internal/models"
+)
+
+// Pool keeps a fixed number of long-lived connections to the server and spreads the requests
+// among them. Connections are dialed on first use and replaced once they break.
+type Pool struct {
+	network string
+	address string
+	codec   *codec.Codec
+	timeout time.Duration
+
+	mu    sync.Mutex
+	conns []*Conn
+	next  int
+}
+
+// NewPool creates a pool of size connections to the server. No connection is dialed until
+// the first request is sent.
+//
+// Parameters:
+//   - network: The network of the server, usually "tcp".
+//   - address: The address of the server.
+//   - size: The number of connections kept open. Values below one are treated as one.
+//   - c: The codec used to frame the messages.
+//   - timeout: How long each request waits for its response. Zero waits forever.
+//
+// Return:
+//   - A pointer to the new pool.
+func NewPool(network, address string, size int, c *codec.Codec, timeout time.Duration) *Pool {
+	if size < 1 {
+		size = 1
+	}
+	return &Pool{
+		network: network,
+		address: address,
+		codec:   c,
+		timeout: timeout,
+		conns:   make([]*Conn, size),
+	}
+}
+
+// Do sends a request over one of the pooled connections and waits for its response.
+// Requests are never retried, since the server may already have processed them.
+//
+// Parameters:
+//   - request: The request to be sent.
+//
+// Return:
+//   - The response of the server to this request.
+//   - An error if no connection could be established or the request failed.
+func (p *Pool) Do(request models.Request) (models.Response, error) {
+	conn, err := p.get()
+	if err != nil {
+		return models.Response{}, err
+	}
+	return conn.Do(request)
+}
+
+// Close closes every connection of the pool.
+func (p *Pool) Close() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for i, conn := range p.conns {
+		if conn != nil {
+			conn.Close()
+			p.conns[i] = nil
+		}
+	}
+}
+
+// get returns the next connection in round-robin order, dialing it again if it is missing or broken.
+func (p *Pool) get() (*Conn, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	i := p.next
+	p.next = (p.next + 1) % len(p.conns)
+
+	conn := p.conns[i]
+	if conn != nil && !conn.Broken() {
+		return conn, nil
+	}
+
+	conn, err := Dial(p.network, p.address, p.codec, p.timeout)
+	if err != nil {
+		return nil, err
+	}
+	p.conns[i] = conn
+
+	return conn, nil
+}
@@ -0,0 +1,125 @@
+// Package codec implements the framing used by the server and the HTTP gateway to exchange
+// JSON messages over a TCP stream.
+//
+// Every message is written as a 4-byte big-endian length prefix followed by the JSON payload,
+// so the reader always knows how many bytes belong to the message regardless of its size.
+package codec
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// DefaultMaxMessageSize is the largest payload accepted when no other limit is configured.
+const DefaultMaxMessageSize = 4 << 20
+
+// headerSize is the number of bytes used by the length prefix.
+const headerSize = 4
+
+// ErrMessageTooLarge is returned when a message exceeds the configured maximum size.
+// After a read fails with this error the stream is no longer aligned and the connection must be closed.
+var ErrMessageTooLarge = errors.New("message exceeds maximum size")
+
+// Codec encodes and decodes length-prefixed JSON messages.
+type Codec struct {
+	MaxMessageSize uint32
+}
+
+// New creates a Codec that rejects payloads larger than maxMessageSize bytes.
+// A zero value falls back to DefaultMaxMessageSize.
+func New(maxMessageSize uint32) *Codec {
+	if maxMessageSize == 0 {
+		maxMessageSize = DefaultMaxMessageSize
+	}
+	return &Codec{MaxMessageSize: maxMessageSize}
+}
+
+// Write marshals v into JSON and writes it to w as a single framed message.
+// The header and the payload are written with one call, so concurrent writers that
+// serialize their calls never interleave partial messages.
+//
+// Parameters:
+//   - w: The destination of the message, usually a net.Conn.
+//   - v: The value to be marshalled.
+//
+// Return:
+//   - An error if the value could not be marshalled, is larger than MaxMessageSize or could not be written.
+func (c *Codec) Write(w io.Writer, v interface{}) error {
+	frame, err := c.Frame(v)
+	if err != nil {
+		return err
+	}
+
+	_, err = w.Write(frame)
+	return err
+}
+
+// Frame marshals v into JSON and returns it as a single framed message, ready to be written,
+// so a message that cannot be encoded is detected before anything is written.
+//
+// Parameters:
+//   - v: The value to be marshalled.
+//
+// Return:
+//   - The length prefix followed by the JSON payload.
+//   - An error if the value could not be marshalled, or ErrMessageTooLarge if it is larger than MaxMessageSize.
+func (c *Codec) Frame(v interface{}) ([]byte, error) {
+	payload, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	if uint64(len(payload)) > uint64(c.MaxMessageSize) {
+		return nil, fmt.Errorf("%w: %d bytes", ErrMessageTooLarge, len(payload))
+	}
+
+	frame := make([]byte, headerSize+len(payload))
+	binary.BigEndian.PutUint32(frame, uint32(len(payload)))
+	copy(frame[headerSize:], payload)
+
+	return frame, nil
+}
+
+// Read reads exactly one framed message from r and unmarshals its payload into v.
+//
+// Parameters:
+//   - r: The source of the message, usually a net.Conn.
+//   - v: A pointer to the value that receives the decoded message.
+//
+// Return:
+//   - io.EOF if the stream ended cleanly before a new message started.
+//   - ErrMessageTooLarge if the announced length exceeds MaxMessageSize.
+//   - A *json.SyntaxError or *json.UnmarshalTypeError if the payload is not valid for v.
+//   - Any other error returned by r.
+func (c *Codec) Read(r io.Reader, v interface{}) error {
+	var header [headerSize]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return err
+	}
+
+	size := binary.BigEndian.Uint32(header[:])
+	if size > c.MaxMessageSize {
+		return fmt.Errorf("%w: %d bytes", ErrMessageTooLarge, size)
+	}
+
+	payload := make([]byte, size)
+	if _, err := io.ReadFull(r, payload); err != nil {
+		if errors.Is(err, io.EOF) {
+			return io.ErrUnexpectedEOF
+		}
+		return err
+	}
+
+	return json.Unmarshal(payload, v)
+}
+
+// IsFormatError reports whether err was caused by a payload that is not valid JSON for the
+// target value, as opposed to a failure of the underlying stream.
+func IsFormatError(err error) bool {
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	return errors.As(err, &syntaxErr) || errors.As(err, &typeErr)
+}
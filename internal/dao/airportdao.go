@@ -50,10 +50,15 @@ func (dao *MemoryAirportDAO) New() {
 	}
 
 	for _, airport := range airports {
-		dao.data[airport.Id] = airport
+		dao.add(airport)
 	}
 }
 
+// add stores an airport under its current ID. The caller must hold the write lock.
+func (dao *MemoryAirportDAO) add(airport *models.Airport) {
+	dao.data[airport.Id] = airport
+}
+
 // FindAll retrieves all airports from the memory data store.
 //
 // The function iterates over the map of airports and appends each value to a new slice.
@@ -0,0 +1,468 @@
+package dao
+
+import (
+	"encoding/json"
+	"log"
+	"time"
+	"vendepass/internal/models"
+
+	"github.com/google/uuid"
+	bolt "go.etcd.io/bbolt"
+)
+
+var (
+	flightsBucket   = []byte("flights")
+	schedulesBucket = []byte("schedules")
+	clientsBucket   = []byte("clients")
+	bookingsBucket  = []byte("bookings")
+	airportsBucket  = []byte("airports")
+)
+
+// OpenBolt opens, or creates, the bbolt database at the given path and makes sure every bucket exists.
+//
+// Parameters:
+//   - path: The path of the database file.
+//
+// Return:
+//   - A pointer to the opened database.
+//   - An error if the file could not be opened or the buckets could not be created.
+func OpenBolt(path string) (*bolt.DB, error) {
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		return nil, err
+	}
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		for _, bucket := range [][]byte{flightsBucket, schedulesBucket, clientsBucket, bookingsBucket, airportsBucket} {
+			if _, err := tx.CreateBucketIfNotExists(bucket); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return db, nil
+}
+
+// boltPut stores the state returned by snapshot under id in the given bucket.
+// The snapshot is taken inside the write transaction, and bolt runs one write transaction at a time,
+// so when a record is stored twice at once, the state taken last is the one left in the database.
+// The snapshot must not write to the database.
+func boltPut(db *bolt.DB, bucket []byte, id uuid.UUID, snapshot func() ([]byte, error)) error {
+	return db.Update(func(tx *bolt.Tx) error {
+		b, err := snapshot()
+		if err != nil {
+			return err
+		}
+		return tx.Bucket(bucket).Put(id[:], b)
+	})
+}
+
+// boltDelete removes the record stored under id in the given bucket.
+func boltDelete(db *bolt.DB, bucket []byte, id uuid.UUID) error {
+	return db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(bucket).Delete(id[:])
+	})
+}
+
+// boltClear removes every record of the given bucket.
+func boltClear(db *bolt.DB, bucket []byte) error {
+	return db.Update(func(tx *bolt.Tx) error {
+		if err := tx.DeleteBucket(bucket); err != nil {
+			return err
+		}
+		_, err := tx.CreateBucket(bucket)
+		return err
+	})
+}
+
+// boltLoad calls fn with every record of the given bucket and reports whether the bucket had any record.
+func boltLoad(db *bolt.DB, bucket []byte, fn func([]byte) error) (bool, error) {
+	found := false
+	err := db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(bucket).ForEach(func(_, v []byte) error {
+			found = true
+			return fn(v)
+		})
+	})
+	return found, err
+}
+
+// BoltFlightDAO is a FlightDAO that keeps every flight in memory and writes each change through to a bbolt database.
+// Reads are served by the embedded MemoryFlightDAO, since flights carry their own locks and reservation queues.
+type BoltFlightDAO struct {
+	*MemoryFlightDAO
+	db *bolt.DB
+}
+
+// NewBoltFlightDAO creates a BoltFlightDAO backed by the given database. New must be called before using it.
+func NewBoltFlightDAO(db *bolt.DB) *BoltFlightDAO {
+	return &BoltFlightDAO{
+		MemoryFlightDAO: &MemoryFlightDAO{data: make(map[uuid.UUID]map[uuid.UUID][]*models.Flight), byId: make(map[uuid.UUID]*models.Flight)},
+		db:              db,
+	}
+}
+
+// New loads every flight from the database. If the database has no flights yet,
+// the flights of the stub JSON file are imported into it.
+func (dao *BoltFlightDAO) New() {
+	dao.MemoryFlightDAO.mu.Lock()
+	found, err := boltLoad(dao.db, flightsBucket, func(b []byte) error {
+		var f models.FlightJSON
+		if err := json.Unmarshal(b, &f); err != nil {
+			return err
+		}
+		dao.MemoryFlightDAO.add(models.NewFlight(f))
+		return nil
+	})
+	dao.MemoryFlightDAO.mu.Unlock()
+
+	if err != nil {
+		log.Fatal("Error loading flights:", err)
+	}
+	if found {
+		return
+	}
+
+	dao.MemoryFlightDAO.New()
+	for _, flight := range dao.MemoryFlightDAO.FindAll() {
+		if err := dao.put(flight); err != nil {
+			log.Fatal("Error importing flights:", err)
+		}
+	}
+}
+
+// put stores the current state of a flight, taken under the flight's lock inside the write transaction.
+func (dao *BoltFlightDAO) put(t *models.Flight) error {
+	return boltPut(dao.db, flightsBucket, t.Id, func() ([]byte, error) {
+		return json.Marshal(t.ToJSON())
+	})
+}
+
+// Insert adds a new flight and stores it in the database.
+func (dao *BoltFlightDAO) Insert(t *models.Flight) {
+	dao.MemoryFlightDAO.Insert(t)
+	if err := dao.put(t); err != nil {
+		log.Println("Error storing flight:", err)
+	}
+}
+
+// Update replaces an existing flight and stores its new state in the database.
+// The caller must not hold the flight's lock.
+func (dao *BoltFlightDAO) Update(t *models.Flight) error {
+	if err := dao.MemoryFlightDAO.Update(t); err != nil {
+		return err
+	}
+	return dao.put(t)
+}
+
+// Delete removes a flight from memory and from the database.
+func (dao *BoltFlightDAO) Delete(t *models.Flight) error {
+	if err := dao.MemoryFlightDAO.Delete(t); err != nil {
+		return err
+	}
+	return boltDelete(dao.db, flightsBucket, t.Id)
+}
+
+// DeleteAll removes every flight from memory and from the database.
+func (dao *BoltFlightDAO) DeleteAll() {
+	dao.MemoryFlightDAO.DeleteAll()
+	if err := boltClear(dao.db, flightsBucket); err != nil {
+		log.Println("Error deleting flights:", err)
+	}
+}
+
+// BoltScheduleDAO is a ScheduleDAO that keeps every schedule in memory and writes each change through to a bbolt database.
+type BoltScheduleDAO struct {
+	*MemoryScheduleDAO
+	db *bolt.DB
+}
+
+// NewBoltScheduleDAO creates a BoltScheduleDAO backed by the given database. New must be called before using it.
+func NewBoltScheduleDAO(db *bolt.DB) *BoltScheduleDAO {
+	return &BoltScheduleDAO{
+		MemoryScheduleDAO: &MemoryScheduleDAO{data: make(map[uuid.UUID]*models.Schedule)},
+		db:                db,
+	}
+}
+
+// New loads every schedule from the database. If the database has no schedules yet,
+// the schedules of the stub JSON file are imported into it.
+func (dao *BoltScheduleDAO) New() {
+	dao.MemoryScheduleDAO.mu.Lock()
+	found, err := boltLoad(dao.db, schedulesBucket, func(b []byte) error {
+		schedule := new(models.Schedule)
+		if err := json.Unmarshal(b, schedule); err != nil {
+			return err
+		}
+		dao.MemoryScheduleDAO.add(schedule)
+		return nil
+	})
+	dao.MemoryScheduleDAO.mu.Unlock()
+
+	if err != nil {
+		log.Fatal("Error loading schedules:", err)
+	}
+	if found {
+		return
+	}
+
+	dao.MemoryScheduleDAO.New()
+	for _, schedule := range dao.MemoryScheduleDAO.FindAll() {
+		if err := dao.put(schedule); err != nil {
+			log.Fatal("Error importing schedules:", err)
+		}
+	}
+}
+
+// put stores the current state of a schedule, taken inside the write transaction.
+func (dao *BoltScheduleDAO) put(t *models.Schedule) error {
+	return boltPut(dao.db, schedulesBucket, t.Id, func() ([]byte, error) {
+		return json.Marshal(t)
+	})
+}
+
+// Insert adds a new schedule and stores it in the database.
+func (dao *BoltScheduleDAO) Insert(t *models.Schedule) {
+	dao.MemoryScheduleDAO.Insert(t)
+	if err := dao.put(t); err != nil {
+		log.Println("Error storing schedule:", err)
+	}
+}
+
+// Delete removes a schedule from memory and from the database.
+func (dao *BoltScheduleDAO) Delete(t *models.Schedule) {
+	dao.MemoryScheduleDAO.Delete(t)
+	if err := boltDelete(dao.db, schedulesBucket, t.Id); err != nil {
+		log.Println("Error deleting schedule:", err)
+	}
+}
+
+// BoltClientDAO is a ClientDAO that keeps every client in memory and writes each change through to a bbolt database.
+type BoltClientDAO struct {
+	*MemoryClientDAO
+	db *bolt.DB
+}
+
+// NewBoltClientDAO creates a BoltClientDAO backed by the given database. New must be called before using it.
+func NewBoltClientDAO(db *bolt.DB) *BoltClientDAO {
+	return &BoltClientDAO{
+		MemoryClientDAO: &MemoryClientDAO{data: make(map[uuid.UUID]*models.Client)},
+		db:              db,
+	}
+}
+
+// New loads every client from the database. If the database has no clients yet,
+// the clients of the stub JSON file are imported into it.
+func (dao *BoltClientDAO) New() {
+	dao.MemoryClientDAO.mu.Lock()
+	found, err := boltLoad(dao.db, clientsBucket, func(b []byte) error {
+		client := new(models.Client)
+		if err := json.Unmarshal(b, client); err != nil {
+			return err
+		}
+		dao.MemoryClientDAO.add(client)
+		return nil
+	})
+	dao.MemoryClientDAO.mu.Unlock()
+
+	if err != nil {
+		log.Fatal("Error loading clients:", err)
+	}
+	if found {
+		return
+	}
+
+	dao.MemoryClientDAO.New()
+	for _, client := range dao.MemoryClientDAO.FindAll() {
+		if err := dao.put(client); err != nil {
+			log.Fatal("Error importing clients:", err)
+		}
+	}
+}
+
+// put stores the current state of a client, taken under the DAO's read lock inside the write transaction,
+// since clients are changed in place under the DAO's lock.
+func (dao *BoltClientDAO) put(t *models.Client) error {
+	return boltPut(dao.db, clientsBucket, t.Id, func() ([]byte, error) {
+		dao.MemoryClientDAO.mu.RLock()
+		defer dao.MemoryClientDAO.mu.RUnlock()
+		return json.Marshal(t)
+	})
+}
+
+// Insert adds a new client and stores it in the database.
+func (dao *BoltClientDAO) Insert(t *models.Client) error {
+	if err := dao.MemoryClientDAO.Insert(t); err != nil {
+		return err
+	}
+	return dao.put(t)
+}
+
+// Update replaces an existing client and stores its new state in the database.
+func (dao *BoltClientDAO) Update(t *models.Client) error {
+	if err := dao.MemoryClientDAO.Update(t); err != nil {
+		return err
+	}
+	return dao.put(t)
+}
+
+// Modify changes an existing client in place and stores its new state in the database.
+func (dao *BoltClientDAO) Modify(id uuid.UUID, change func(*models.Client) error) (*models.Client, error) {
+	client, err := dao.MemoryClientDAO.Modify(id, change)
+	if err != nil {
+		return nil, err
+	}
+	return client, dao.put(client)
+}
+
+// Delete removes a client from memory and from the database.
+func (dao *BoltClientDAO) Delete(t models.Client) {
+	dao.MemoryClientDAO.Delete(t)
+	if err := boltDelete(dao.db, clientsBucket, t.Id); err != nil {
+		log.Println("Error deleting client:", err)
+	}
+}
+
+// BoltBookingDAO is a BookingDAO that keeps every booking in memory and writes each change through to a bbolt database.
+type BoltBookingDAO struct {
+	*MemoryBookingDAO
+	db *bolt.DB
+}
+
+// NewBoltBookingDAO creates a BoltBookingDAO backed by the given database. New must be called before using it.
+func NewBoltBookingDAO(db *bolt.DB) *BoltBookingDAO {
+	return &BoltBookingDAO{
+		MemoryBookingDAO: &MemoryBookingDAO{data: make(map[uuid.UUID]*models.Booking)},
+		db:               db,
+	}
+}
+
+// New loads every booking from the database.
+func (dao *BoltBookingDAO) New() {
+	dao.MemoryBookingDAO.mu.Lock()
+	_, err := boltLoad(dao.db, bookingsBucket, func(b []byte) error {
+		booking := new(models.Booking)
+		if err := json.Unmarshal(b, booking); err != nil {
+			return err
+		}
+		dao.MemoryBookingDAO.add(booking)
+		return nil
+	})
+	dao.MemoryBookingDAO.mu.Unlock()
+
+	if err != nil {
+		log.Fatal("Error loading bookings:", err)
+	}
+}
+
+// put stores the current state of a booking, taken inside the write transaction.
+func (dao *BoltBookingDAO) put(t *models.Booking) error {
+	return boltPut(dao.db, bookingsBucket, t.Id, func() ([]byte, error) {
+		return json.Marshal(t)
+	})
+}
+
+// Insert adds a new booking and stores it in the database.
+func (dao *BoltBookingDAO) Insert(t *models.Booking) {
+	dao.MemoryBookingDAO.Insert(t)
+	if err := dao.put(t); err != nil {
+		log.Println("Error storing booking:", err)
+	}
+}
+
+// Update replaces an existing booking and stores its new state in the database.
+func (dao *BoltBookingDAO) Update(t *models.Booking) error {
+	if err := dao.MemoryBookingDAO.Update(t); err != nil {
+		return err
+	}
+	return dao.put(t)
+}
+
+// Delete removes a booking from memory and from the database.
+func (dao *BoltBookingDAO) Delete(t *models.Booking) {
+	dao.MemoryBookingDAO.Delete(t)
+	if err := boltDelete(dao.db, bookingsBucket, t.Id); err != nil {
+		log.Println("Error deleting booking:", err)
+	}
+}
+
+// BoltAirportDAO is an AirportDAO that keeps every airport in memory and writes each change through to a bbolt database.
+type BoltAirportDAO struct {
+	*MemoryAirportDAO
+	db *bolt.DB
+}
+
+// NewBoltAirportDAO creates a BoltAirportDAO backed by the given database. New must be called before using it.
+func NewBoltAirportDAO(db *bolt.DB) *BoltAirportDAO {
+	return &BoltAirportDAO{
+		MemoryAirportDAO: &MemoryAirportDAO{data: make(map[uuid.UUID]*models.Airport)},
+		db:               db,
+	}
+}
+
+// New loads every airport from the database. If the database has no airports yet,
+// the airports of the stub JSON file are imported into it.
+func (dao *BoltAirportDAO) New() {
+	dao.MemoryAirportDAO.mu.Lock()
+	found, err := boltLoad(dao.db, airportsBucket, func(b []byte) error {
+		airport := new(models.Airport)
+		if err := json.Unmarshal(b, airport); err != nil {
+			return err
+		}
+		dao.MemoryAirportDAO.add(airport)
+		return nil
+	})
+	dao.MemoryAirportDAO.mu.Unlock()
+
+	if err != nil {
+		log.Fatal("Error loading airports:", err)
+	}
+	if found {
+		return
+	}
+
+	dao.MemoryAirportDAO.New()
+	for _, airport := range dao.MemoryAirportDAO.FindAll() {
+		if err := dao.put(airport); err != nil {
+			log.Fatal("Error importing airports:", err)
+		}
+	}
+}
+
+// put stores the current state of an airport, taken under the airport's lock inside the write transaction.
+func (dao *BoltAirportDAO) put(t *models.Airport) error {
+	return boltPut(dao.db, airportsBucket, t.Id, func() ([]byte, error) {
+		t.Mu.RLock()
+		defer t.Mu.RUnlock()
+		return json.Marshal(t)
+	})
+}
+
+// Insert adds a new airport and stores it in the database.
+func (dao *BoltAirportDAO) Insert(t *models.Airport) {
+	dao.MemoryAirportDAO.Insert(t)
+	if err := dao.put(t); err != nil {
+		log.Println("Error storing airport:", err)
+	}
+}
+
+// Update replaces an existing airport and stores its new state in the database.
+func (dao *BoltAirportDAO) Update(t *models.Airport) error {
+	if err := dao.MemoryAirportDAO.Update(t); err != nil {
+		return err
+	}
+	return dao.put(t)
+}
+
+// Delete removes an airport from memory and from the database.
+func (dao *BoltAirportDAO) Delete(t *models.Airport) {
+	dao.MemoryAirportDAO.Delete(t)
+	if err := boltDelete(dao.db, airportsBucket, t.Id); err != nil {
+		log.Println("Error deleting airport:", err)
+	}
+}
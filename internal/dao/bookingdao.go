@@ -0,0 +1,171 @@
+package dao
+
+import (
+	"errors"
+	"sort"
+	"strings"
+	"sync"
+	"vendepass/internal/models"
+
+	"github.com/google/uuid"
+)
+
+// MemoryBookingDAO is a data access object (DAO) for managing the bookings of the clients in memory.
+// Bookings are also indexed by reference, which is unique among them.
+type MemoryBookingDAO struct {
+	data       map[uuid.UUID]*models.Booking
+	references map[string]uuid.UUID
+	mu         sync.RWMutex
+}
+
+// New initializes the MemoryBookingDAO. Bookings are only made by the clients, so there is no stub file to load.
+func (dao *MemoryBookingDAO) New() {}
+
+// add stores a booking under its current ID and indexes its reference. The caller must hold the write lock.
+func (dao *MemoryBookingDAO) add(booking *models.Booking) {
+	if dao.references == nil {
+		dao.references = make(map[string]uuid.UUID)
+	}
+
+	dao.data[booking.Id] = booking
+	dao.references[booking.Reference] = booking.Id
+}
+
+// remove deletes the booking with the given ID and its reference from the index. The caller must hold the write lock.
+func (dao *MemoryBookingDAO) remove(id uuid.UUID) {
+	if booking, exists := dao.data[id]; exists {
+		delete(dao.references, booking.Reference)
+		delete(dao.data, id)
+	}
+}
+
+// FindAll retrieves all bookings from the memory data store.
+//
+// Return:
+//   - A slice with every booking, empty if there are none.
+func (dao *MemoryBookingDAO) FindAll() []*models.Booking {
+	dao.mu.RLock()
+	defer dao.mu.RUnlock()
+	v := make([]*models.Booking, 0, len(dao.data))
+
+	for _, value := range dao.data {
+		v = append(v, value)
+	}
+
+	return v
+}
+
+// Insert adds a new booking to the memory data store, under the ID it already has: the ID of its order.
+// The booking is given a new reference, drawn again until it is not the reference of another booking.
+//
+// Parameters:
+//   - t: A pointer to the booking to be inserted.
+func (dao *MemoryBookingDAO) Insert(t *models.Booking) {
+	dao.mu.Lock()
+	defer dao.mu.Unlock()
+
+	if t.Id == uuid.Nil {
+		t.Id = uuid.New()
+	}
+
+	for {
+		t.Reference = models.NewReference()
+		if _, taken := dao.references[t.Reference]; !taken {
+			break
+		}
+	}
+	dao.add(t)
+}
+
+// Update replaces an existing booking in the memory data store.
+//
+// Parameters:
+//   - t: A pointer to the booking to be updated.
+//
+// Return:
+//   - An error with the message "booking not found" if there is no booking with its ID, or nil.
+func (dao *MemoryBookingDAO) Update(t *models.Booking) error {
+	dao.mu.Lock()
+	defer dao.mu.Unlock()
+
+	if _, exists := dao.data[t.Id]; !exists {
+		return errors.New("booking not found")
+	}
+	dao.remove(t.Id)
+	dao.add(t)
+	return nil
+}
+
+// Delete removes a booking from the memory data store.
+//
+// Parameters:
+//   - t: A pointer to the booking to be deleted.
+func (dao *MemoryBookingDAO) Delete(t *models.Booking) {
+	dao.mu.Lock()
+	defer dao.mu.Unlock()
+	dao.remove(t.Id)
+}
+
+// FindById retrieves a booking from the memory data store based on the provided UUID.
+//
+// Parameters:
+//   - id: The unique identifier of the booking, which is the ID of its order.
+//
+// Return:
+//   - *models.Booking: A pointer to the booking if found, or nil if not found.
+//   - error: An error with the message "booking not found" if there is no booking with the ID, or nil.
+func (dao *MemoryBookingDAO) FindById(id uuid.UUID) (*models.Booking, error) {
+	dao.mu.RLock()
+	defer dao.mu.RUnlock()
+	booking, exists := dao.data[id]
+
+	if !exists {
+		return nil, errors.New("booking not found")
+	}
+
+	return booking, nil
+}
+
+// FindByReference retrieves a booking from the memory data store by its reference, in any letter case.
+//
+// Parameters:
+//   - reference: The reference of the booking.
+//
+// Return:
+//   - *models.Booking: A pointer to the booking if found, or nil if not found.
+//   - error: An error with the message "booking not found" if there is no booking with the reference, or nil.
+func (dao *MemoryBookingDAO) FindByReference(reference string) (*models.Booking, error) {
+	dao.mu.RLock()
+	defer dao.mu.RUnlock()
+	id, exists := dao.references[strings.ToUpper(reference)]
+
+	if !exists {
+		return nil, errors.New("booking not found")
+	}
+
+	return dao.data[id], nil
+}
+
+// FindByClientId retrieves every booking of a client, from the oldest to the newest.
+//
+// Parameters:
+//   - clientId: The ID of the client.
+//
+// Return:
+//   - A slice with the client's bookings, empty if there are none.
+func (dao *MemoryBookingDAO) FindByClientId(clientId uuid.UUID) []*models.Booking {
+	dao.mu.RLock()
+	defer dao.mu.RUnlock()
+	v := make([]*models.Booking, 0)
+
+	for _, booking := range dao.data {
+		if booking.ClientId == clientId {
+			v = append(v, booking)
+		}
+	}
+
+	sort.Slice(v, func(i, j int) bool {
+		return v[i].CreatedAt.Before(v[j].CreatedAt)
+	})
+	return v
+}
@@ -0,0 +1,100 @@
+package dao
+
+import (
+	"errors"
+	"sync"
+	"vendepass/internal/models"
+
+	"github.com/google/uuid"
+)
+
+// MemoryCartDAO keeps the cart of each client, indexed by the client's ID.
+// Carts are only kept in memory, like the sessions, since the seats they hold are released on a restart.
+type MemoryCartDAO struct {
+	data map[uuid.UUID]*models.Cart
+	mu   sync.RWMutex
+}
+
+// New initializes the MemoryCartDAO by creating a new map to store carts.
+func (dao *MemoryCartDAO) New() {
+	dao.mu.Lock()
+	defer dao.mu.Unlock()
+
+	dao.data = make(map[uuid.UUID]*models.Cart)
+}
+
+// FindAll retrieves all carts from the memory data store.
+//
+// Returns:
+//   - A slice of pointers to Cart structs, representing all carts in the data store.
+func (dao *MemoryCartDAO) FindAll() []*models.Cart {
+	dao.mu.RLock()
+	defer dao.mu.RUnlock()
+
+	v := make([]*models.Cart, 0, len(dao.data))
+
+	for _, value := range dao.data {
+		v = append(v, value)
+	}
+
+	return v
+}
+
+// FindByClientId retrieves the cart of a client.
+//
+// Parameters:
+//   - id: A UUID representing the ID of the client.
+//
+// Returns:
+//   - A pointer to the client's cart, and nil as the error.
+//   - If the client has no cart, nil and an error with the message "not found".
+func (dao *MemoryCartDAO) FindByClientId(id uuid.UUID) (*models.Cart, error) {
+	dao.mu.RLock()
+	defer dao.mu.RUnlock()
+
+	cart, exists := dao.data[id]
+	if !exists {
+		return nil, errors.New("not found")
+	}
+
+	return cart, nil
+}
+
+// FindOrCreate retrieves the cart of a client, creating an empty one if the client has none.
+//
+// Parameters:
+//   - id: A UUID representing the ID of the client.
+//
+// Returns:
+//   - A pointer to the client's cart.
+func (dao *MemoryCartDAO) FindOrCreate(id uuid.UUID) *models.Cart {
+	dao.mu.Lock()
+	defer dao.mu.Unlock()
+
+	cart, exists := dao.data[id]
+	if !exists {
+		cart = &models.Cart{ClientID: id, Reservations: make(map[uuid.UUID]models.Reservation)}
+		dao.data[id] = cart
+	}
+
+	return cart
+}
+
+// Delete removes a cart from the memory data store.
+//
+// Parameters:
+//   - t: A pointer to the cart to be deleted. Its ClientID identifies it.
+func (dao *MemoryCartDAO) Delete(t *models.Cart) {
+	dao.mu.Lock()
+	defer dao.mu.Unlock()
+
+	delete(dao.data, t.ClientID)
+}
+
+// DeleteAll removes all carts from the memory data store.
+func (dao *MemoryCartDAO) DeleteAll() {
+	dao.mu.Lock()
+	defer dao.mu.Unlock()
+
+	dao.data = make(map[uuid.UUID]*models.Cart)
+}
@@ -12,11 +12,17 @@ import (
 	"github.com/google/uuid"
 )
 
+// ErrUsernameTaken is returned when a client would end up with the username of another client.
+var ErrUsernameTaken = errors.New("username already taken")
+
 // MemoryClientDAO is a data access object (DAO) for managing client data in memory.
 // It provides methods for inserting, updating, deleting, and retrieving clients.
+// Clients are also indexed by username, which is unique among them.
 type MemoryClientDAO struct {
-	data map[uuid.UUID]*models.Client
-	mu   sync.RWMutex
+	data      map[uuid.UUID]*models.Client
+	usernames map[string]uuid.UUID
+	indexedAs map[uuid.UUID]string // Username each client is indexed under
+	mu        sync.RWMutex
 }
 
 // New initializes the MemoryClientDAO by loading client data from a JSON file.
@@ -38,8 +44,44 @@ func (dao *MemoryClientDAO) New() {
 	json.Unmarshal(b, &clients)
 
 	for _, client := range clients {
-		dao.data[client.Id] = &client
+		dao.add(&client)
+	}
+}
+
+// add stores a client under its current ID and indexes its username. The caller must hold the write lock.
+//
+// The stored client may be the same pointer as client, already holding a new username,
+// so the username it was indexed under is tracked separately.
+func (dao *MemoryClientDAO) add(client *models.Client) {
+	if dao.usernames == nil {
+		dao.usernames = make(map[string]uuid.UUID)
+		dao.indexedAs = make(map[uuid.UUID]string)
+	}
+	if previous, exists := dao.indexedAs[client.Id]; exists {
+		delete(dao.usernames, previous)
 	}
+
+	dao.data[client.Id] = client
+	dao.usernames[client.Username] = client.Id
+	dao.indexedAs[client.Id] = client.Username
+}
+
+// remove deletes the client with the given ID and its username from the index. The caller must hold the write lock.
+func (dao *MemoryClientDAO) remove(id uuid.UUID) {
+	if _, exists := dao.data[id]; !exists {
+		return
+	}
+
+	delete(dao.usernames, dao.indexedAs[id])
+	delete(dao.indexedAs, id)
+	delete(dao.data, id)
+}
+
+// usernameTaken reports whether the username belongs to a client other than the one with the given ID.
+// The caller must hold the lock.
+func (dao *MemoryClientDAO) usernameTaken(username string, id uuid.UUID) bool {
+	owner, exists := dao.usernames[username]
+	return exists && owner != id
 }
 
 // FindAll retrieves all clients from the memory data store.
@@ -67,17 +109,29 @@ func (dao *MemoryClientDAO) FindAll() []*models.Client {
 //
 // The function generates a new UUID for the client and assigns it to the client's Id field.
 // Then, it inserts the client into the data map using the generated UUID as the key.
+// The check for a duplicated username and the insertion happen under the same lock,
+// so two clients can never be inserted with the same username.
 //
 // Parameters:
 //   - t: A pointer to the client model to be inserted. The client's Id field will be updated with a new UUID.
-func (dao *MemoryClientDAO) Insert(t *models.Client) {
+//
+// Return:
+//   - ErrUsernameTaken if another client already has the same username, nil otherwise.
+func (dao *MemoryClientDAO) Insert(t *models.Client) error {
 	dao.mu.Lock()
 	defer dao.mu.Unlock()
+
+	if dao.usernameTaken(t.Username, uuid.Nil) {
+		return ErrUsernameTaken
+	}
+
 	id := uuid.New()
 
 	t.Id = id
 
-	dao.data[id] = t
+	dao.add(t)
+
+	return nil
 }
 
 // Update updates an existing client in the memory data store.
@@ -91,20 +145,61 @@ func (dao *MemoryClientDAO) Insert(t *models.Client) {
 //
 // Return:
 //   - An error if the client was not found in the data map.
+//   - ErrUsernameTaken if the new username of the client belongs to another client.
 func (dao *MemoryClientDAO) Update(t *models.Client) error {
 	dao.mu.Lock()
 	defer dao.mu.Unlock()
-	lastClient, exists := dao.data[t.Id]
+	_, exists := dao.data[t.Id]
 
 	if !exists {
 		return errors.New("not found")
 	}
 
-	dao.data[t.Id] = lastClient
+	if dao.usernameTaken(t.Username, t.Id) {
+		return ErrUsernameTaken
+	}
+
+	dao.add(t)
 
 	return nil
 }
 
+// Modify changes the stored client with the given ID in place, under the write lock, so concurrent changes to
+// other fields of the same client, like its tickets, are never overwritten by a stale copy.
+// The change is made on a copy first, so a change that fails, or that would give the client the username
+// of another client, never reaches the stored client.
+//
+// Parameters:
+//   - id: The UUID of the client to be changed.
+//   - change: A function changing the fields of the client, returning an error to leave the client unchanged.
+//
+// Return:
+//   - A pointer to the stored client, with the change applied.
+//   - An error if the client was not found, the error returned by change, or ErrUsernameTaken.
+func (dao *MemoryClientDAO) Modify(id uuid.UUID, change func(*models.Client) error) (*models.Client, error) {
+	dao.mu.Lock()
+	defer dao.mu.Unlock()
+	client, exists := dao.data[id]
+
+	if !exists {
+		return nil, errors.New("not found")
+	}
+
+	updated := *client
+	if err := change(&updated); err != nil {
+		return nil, err
+	}
+
+	if dao.usernameTaken(updated.Username, id) {
+		return nil, ErrUsernameTaken
+	}
+
+	*client = updated
+	dao.add(client)
+
+	return client, nil
+}
+
 // Delete removes a client from the memory data store based on the provided client model.
 //
 // The function checks if a client with the given Id exists in the data map.
@@ -116,7 +211,7 @@ func (dao *MemoryClientDAO) Update(t *models.Client) error {
 func (dao *MemoryClientDAO) Delete(t models.Client) {
 	dao.mu.Lock()
 	defer dao.mu.Unlock()
-	delete(dao.data, t.Id)
+	dao.remove(t.Id)
 }
 
 // FindById retrieves a client from the memory data store based on the provided UUID.
@@ -142,3 +237,23 @@ func (dao *MemoryClientDAO) FindById(id uuid.UUID) (*models.Client, error) {
 
 	return client, nil
 }
+
+// FindByUsername retrieves a client from the memory data store based on its username.
+//
+// Parameters:
+//   - username: The username of the client to be retrieved.
+//
+// Return:
+//   - A pointer to the client if found, nil otherwise.
+//   - An error indicating that the client was not found, nil otherwise.
+func (dao *MemoryClientDAO) FindByUsername(username string) (*models.Client, error) {
+	dao.mu.RLock()
+	defer dao.mu.RUnlock()
+	id, exists := dao.usernames[username]
+
+	if !exists {
+		return nil, errors.New("not found")
+	}
+
+	return dao.data[id], nil
+}
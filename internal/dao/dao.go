@@ -1,23 +1,93 @@
 package dao
 
 import (
+	"fmt"
 	"sync"
 	"vendepass/internal/dao/interfaces"
+	"vendepass/internal/journal"
 	"vendepass/internal/models"
 
 	"github.com/google/uuid"
+	bolt "go.etcd.io/bbolt"
+)
+
+// Storage backends accepted by Configure.
+const (
+	// MemoryStorage keeps the data only in memory, loaded from the stub JSON files at startup.
+	MemoryStorage = "memory"
+	// BoltStorage keeps flights, schedules, clients, bookings and airports in a bbolt database file.
+	BoltStorage = "bolt"
+	// JournalStorage keeps the data in memory, appending every change to a journal that is
+	// replayed, on top of the latest snapshot, at startup.
+	JournalStorage = "journal"
 )
 
 var airportDao interfaces.AirportDAO
 var flightDao interfaces.FlightDAO
+var scheduleDao interfaces.ScheduleDAO
 var clientDao interfaces.ClientDAO
+var bookingDao interfaces.BookingDAO
 var sessionDao interfaces.SessionDAO
+var cartDao interfaces.CartDAO
+var notificationDao interfaces.NotificationDAO
+var quoteDao interfaces.QuoteDAO
+var waitlistDao interfaces.WaitlistDAO
+
+var storage = MemoryStorage
+var db *bolt.DB
+var journalLog *journal.Journal
+
+// Configure selects the storage backend used by the flight, schedule, client, booking and airport DAOs.
+// It must be called before the first call to any of the Get*DAO functions.
+// Sessions, carts, notifications, quotes and waitlists are always kept in memory.
+//
+// Parameters:
+//   - backend: MemoryStorage, BoltStorage or JournalStorage.
+//   - path: The path of the database file for BoltStorage, or of the journal directory for JournalStorage.
+//
+// Return:
+//   - An error if the backend is unknown or its storage could not be opened.
+func Configure(backend string, path string) error {
+	switch backend {
+	case MemoryStorage:
+	case BoltStorage:
+		var err error
+		db, err = OpenBolt(path)
+		if err != nil {
+			return err
+		}
+	case JournalStorage:
+		var err error
+		journalLog, err = journal.Open(path)
+		if err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("unknown storage backend: %s", backend)
+	}
+
+	storage = backend
+	return nil
+}
+
+// Close releases the storage opened by Configure.
+func Close() error {
+	if db != nil {
+		return db.Close()
+	}
+	if journalLog != nil {
+		return journalLog.Close()
+	}
+	return nil
+}
 
 // GetFlightDAO returns a singleton instance of FlightDAO.
 // If the instance does not exist, it creates a new one and initializes it.
 //
 // The FlightDAO interface provides methods for managing flight data.
-// This implementation uses a MemoryFlightDAO, which stores flight data in memory.
+// With the memory storage it uses a MemoryFlightDAO, which stores flight data in memory,
+// with the bolt storage a BoltFlightDAO, which also writes every change to the database,
+// and with the journal storage a JournalFlightDAO, which appends every change to the journal.
 //
 // Parameters:
 // None
@@ -25,16 +95,53 @@ var sessionDao interfaces.SessionDAO
 // Return:
 // flightDao (interfaces.FlightDAO) - A singleton instance of FlightDAO.
 func GetFlightDAO() interfaces.FlightDAO {
+	if flightDao == nil && storage == JournalStorage {
+		flightDao = NewJournalFlightDAO(journalLog)
+		flightDao.New()
+	}
+	if flightDao == nil && storage == BoltStorage {
+		flightDao = NewBoltFlightDAO(db)
+		flightDao.New()
+	}
 	if flightDao == nil {
-		flightDao = &MemoryFlightDAO{data: make(map[uuid.UUID]map[uuid.UUID]*models.Flight),
-			mu: sync.RWMutex{}}
+		flightDao = &MemoryFlightDAO{data: make(map[uuid.UUID]map[uuid.UUID][]*models.Flight),
+			byId: make(map[uuid.UUID]*models.Flight),
+			mu:   sync.RWMutex{}}
 		flightDao.New()
 	}
 
 	return flightDao
 }
 
+// GetScheduleDAO returns a singleton instance of ScheduleDAO, kept in the configured storage
+// like the flights it generates.
+func GetScheduleDAO() interfaces.ScheduleDAO {
+	if scheduleDao == nil && storage == JournalStorage {
+		scheduleDao = NewJournalScheduleDAO(journalLog)
+		scheduleDao.New()
+	}
+	if scheduleDao == nil && storage == BoltStorage {
+		scheduleDao = NewBoltScheduleDAO(db)
+		scheduleDao.New()
+	}
+	if scheduleDao == nil {
+		scheduleDao = &MemoryScheduleDAO{data: make(map[uuid.UUID]*models.Schedule),
+			mu: sync.RWMutex{}}
+		scheduleDao.New()
+	}
+
+	return scheduleDao
+}
+
 func GetClientDAO() interfaces.ClientDAO {
+	if clientDao == nil && storage == JournalStorage {
+		clientDao = NewJournalClientDAO(journalLog)
+		clientDao.New()
+	}
+	if clientDao == nil && storage == BoltStorage {
+		clientDao = NewBoltClientDAO(db)
+		clientDao.New()
+	}
 	if clientDao == nil {
 		clientDao = &MemoryClientDAO{data: make(map[uuid.UUID]*models.Client),
 			mu: sync.RWMutex{}}
@@ -44,6 +151,26 @@ func GetClientDAO() interfaces.ClientDAO {
 	return clientDao
 }
 
+// GetBookingDAO returns a singleton instance of BookingDAO, kept in the configured storage
+// like the clients whose tickets it groups.
+func GetBookingDAO() interfaces.BookingDAO {
+	if bookingDao == nil && storage == JournalStorage {
+		bookingDao = NewJournalBookingDAO(journalLog)
+		bookingDao.New()
+	}
+	if bookingDao == nil && storage == BoltStorage {
+		bookingDao = NewBoltBookingDAO(db)
+		bookingDao.New()
+	}
+	if bookingDao == nil {
+		bookingDao = &MemoryBookingDAO{data: make(map[uuid.UUID]*models.Booking),
+			mu: sync.RWMutex{}}
+		bookingDao.New()
+	}
+
+	return bookingDao
+}
+
 func GetSessionDAO() interfaces.SessionDAO {
 	if sessionDao == nil {
 		sessionDao = &MemorySessionDAO{
@@ -55,7 +182,59 @@ func GetSessionDAO() interfaces.SessionDAO {
 	return sessionDao
 }
 
+func GetCartDAO() interfaces.CartDAO {
+	if cartDao == nil {
+		cartDao = &MemoryCartDAO{
+			data: make(map[uuid.UUID]*models.Cart),
+			mu:   sync.RWMutex{}}
+		cartDao.New()
+	}
+
+	return cartDao
+}
+
+func GetNotificationDAO() interfaces.NotificationDAO {
+	if notificationDao == nil {
+		notificationDao = &MemoryNotificationDAO{
+			data: make(map[uuid.UUID][]*models.Notification),
+			mu:   sync.RWMutex{}}
+		notificationDao.New()
+	}
+
+	return notificationDao
+}
+
+func GetQuoteDAO() interfaces.QuoteDAO {
+	if quoteDao == nil {
+		quoteDao = &MemoryQuoteDAO{
+			data: make(map[uuid.UUID]*models.Quote),
+			mu:   sync.RWMutex{}}
+		quoteDao.New()
+	}
+
+	return quoteDao
+}
+
+func GetWaitlistDAO() interfaces.WaitlistDAO {
+	if waitlistDao == nil {
+		waitlistDao = &MemoryWaitlistDAO{
+			data: make(map[uuid.UUID]*models.WaitlistEntry),
+			mu:   sync.RWMutex{}}
+		waitlistDao.New()
+	}
+
+	return waitlistDao
+}
+
 func GetAirportDAO() interfaces.AirportDAO {
+	if airportDao == nil && storage == JournalStorage {
+		airportDao = NewJournalAirportDAO(journalLog)
+		airportDao.New()
+	}
+	if airportDao == nil && storage == BoltStorage {
+		airportDao = NewBoltAirportDAO(db)
+		airportDao.New()
+	}
 	if airportDao == nil {
 		airportDao = &MemoryAirportDAO{data: make(map[uuid.UUID]*models.Airport),
 			mu: sync.RWMutex{}}
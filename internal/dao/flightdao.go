@@ -1,12 +1,16 @@
 package dao
 
 import (
+	"container/heap"
 	"encoding/json"
 	"errors"
 	"log"
+	"math"
 	"os"
 	"path/filepath"
+	"sort"
 	"sync"
+	"time"
 	"vendepass/internal/models"
 
 	"github.com/google/uuid"
@@ -14,12 +18,24 @@ import (
 
 // MemoryFlightDAO is a data access object (DAO) for managing flight data in memory.
 // It provides methods for inserting, updating, deleting, and retrieving flights.
-// It also includes a breadth-first search algorithm for finding the shortest path between airports.
+// It also includes a breadth-first search algorithm for finding the route with the fewest flights between airports,
+// and an A* search for the route with the lowest cost.
+// Flights are indexed by their ID, and by their source and destination airports for routing.
+// Any number of flights may fly between the same pair of airports.
 type MemoryFlightDAO struct {
-	data map[uuid.UUID]map[uuid.UUID]*models.Flight
+	data map[uuid.UUID]map[uuid.UUID][]*models.Flight // flights by source and destination airport
+	byId map[uuid.UUID]*models.Flight
 	mu   sync.RWMutex
 }
 
+// Connection limits of the routes found by BreadthFirstSearch and ShortestPath between dated flights.
+const (
+	// MinConnection is the shortest time allowed between the arrival of a flight and the departure of the next one.
+	MinConnection = 30 * time.Minute
+	// MaxLayover is the longest time allowed between the arrival of a flight and the departure of the next one.
+	MaxLayover = 24 * time.Hour
+)
+
 // New initializes the MemoryFlightDAO by reading flight data from a JSON file and populating the internal data structure.
 // It also creates a new session queue for each flight.
 func (dao *MemoryFlightDAO) New() {
@@ -39,21 +55,52 @@ func (dao *MemoryFlightDAO) New() {
 	json.Unmarshal(b, &flights)
 
 	for _, f := range flights {
-		flight := models.Flight{
-			Id:              f.Id,
-			SourceAirportId: f.SourceAirportId,
-			DestAirportId:   f.DestAirportId,
-			Passengers:      f.Passengers,
-			Seats:           f.Seats,
-			Queue:           make(chan *models.Session, 10),
-		}
+		dao.add(models.NewFlight(f))
+	}
+}
 
-		if dao.data[flight.SourceAirportId] == nil {
-			dao.data[flight.SourceAirportId] = make(map[uuid.UUID]*models.Flight)
-		}
+// add stores a flight under its current ID, replacing the flight previously stored under it, if any.
+// The caller must hold the write lock.
+func (dao *MemoryFlightDAO) add(flight *models.Flight) {
+	if dao.byId == nil {
+		dao.byId = make(map[uuid.UUID]*models.Flight)
+	}
+	dao.remove(flight.Id)
 
-		dao.data[flight.SourceAirportId][flight.DestAirportId] = &flight
+	if dao.data[flight.SourceAirportId] == nil {
+		dao.data[flight.SourceAirportId] = make(map[uuid.UUID][]*models.Flight)
 	}
+
+	pair := dao.data[flight.SourceAirportId]
+	pair[flight.DestAirportId] = append(pair[flight.DestAirportId], flight)
+	dao.byId[flight.Id] = flight
+}
+
+// remove deletes the flight with the given ID from both indexes. The caller must hold the write lock.
+func (dao *MemoryFlightDAO) remove(id uuid.UUID) {
+	stored, exists := dao.byId[id]
+	if !exists {
+		return
+	}
+	delete(dao.byId, id)
+
+	pair := dao.data[stored.SourceAirportId]
+	flights := pair[stored.DestAirportId]
+	for i, flight := range flights {
+		if flight.Id == id {
+			pair[stored.DestAirportId] = append(flights[:i:i], flights[i+1:]...)
+			break
+		}
+	}
+	if len(pair[stored.DestAirportId]) == 0 {
+		delete(pair, stored.DestAirportId)
+	}
+}
+
+// reset removes every flight from both indexes. The caller must hold the write lock.
+func (dao *MemoryFlightDAO) reset() {
+	dao.data = make(map[uuid.UUID]map[uuid.UUID][]*models.Flight)
+	dao.byId = make(map[uuid.UUID]*models.Flight)
 }
 
 // FindAll retrieves all flights from the memory data structure.
@@ -65,20 +112,20 @@ func (dao *MemoryFlightDAO) New() {
 func (dao *MemoryFlightDAO) FindAll() []*models.Flight {
 	dao.mu.RLock()
 	defer dao.mu.RUnlock()
-	var v []*models.Flight
-	for _, array := range dao.data {
-		for _, flight := range array {
-			v = append(v, flight)
-		}
+	v := make([]*models.Flight, 0, len(dao.byId))
+	for _, flight := range dao.byId {
+		v = append(v, flight)
 	}
 
 	return v
 }
 
 // Insert adds a new flight to the memory data structure.
-// It generates a new UUID for the flight, sets the flight's ID, and creates a new session queue.
-// If the source airport ID does not exist in the data structure, a new map is created for that airport.
-// The flight is then added to the data structure using the source airport ID and destination airport ID as keys.
+// Unless the flight already has an ID, such as the ones of the flights generated by a schedule,
+// it generates a new UUID for the flight and sets the flight's ID. It then creates the flight's reservation queue.
+// If the flight has no capacity set, its current seats are taken as its capacity.
+// The flight is then indexed by its ID and added to the flights between its source and destination airports,
+// next to the ones already flying between them.
 //
 // Parameters:
 //   - t *models.Flight: A pointer to the flight to be inserted. The flight's ID, source airport ID, destination airport ID,
@@ -86,21 +133,22 @@ func (dao *MemoryFlightDAO) FindAll() []*models.Flight {
 func (dao *MemoryFlightDAO) Insert(t *models.Flight) {
 	dao.mu.Lock()
 	defer dao.mu.Unlock()
-	id := uuid.New()
 
-	t.Id = id
+	if t.Id == uuid.Nil {
+		t.Id = uuid.New()
+	}
 
-	if dao.data[t.SourceAirportId] == nil {
-		dao.data[t.SourceAirportId] = make(map[uuid.UUID]*models.Flight)
+	if t.Capacity == 0 {
+		t.Capacity = t.Seats + uint(len(t.Passengers))
 	}
 
-	dao.data[t.SourceAirportId][t.DestAirportId] = t
-	t.Queue = make(chan *models.Session)
+	dao.add(t)
+	t.InitQueue()
 }
 
 // Update updates an existing flight in the memory data structure.
-// It checks if the flight exists in the data structure based on the source airport ID and destination airport ID.
-// If the flight is found, it updates the flight's details in the data structure.
+// It looks the flight up by its ID.
+// If the flight is found, it replaces the stored flight with the provided one.
 // If the flight is not found, it returns an error.
 //
 // Parameters:
@@ -113,46 +161,35 @@ func (dao *MemoryFlightDAO) Insert(t *models.Flight) {
 func (dao *MemoryFlightDAO) Update(t *models.Flight) error {
 	dao.mu.Lock()
 	defer dao.mu.Unlock()
-	_, exists := dao.data[t.SourceAirportId][t.DestAirportId]
 
-	if !exists {
+	if _, exists := dao.byId[t.Id]; !exists {
 		return errors.New("not found")
 	}
 
-	dao.data[t.SourceAirportId][t.DestAirportId] = t
+	dao.add(t)
 
 	return nil
 }
 
 // Delete removes a flight from the memory data structure based on the provided flight object.
-// It deletes the flight from the map using the source airport ID and destination airport ID as keys.
-// After deletion, it checks if the flight still exists in the data structure.
-// If the flight still exists, it returns an error indicating that the deletion was unsuccessful.
-// If the flight is successfully deleted, it returns nil.
+// It removes the flight with the same ID from both indexes.
+// Deleting a flight that is not stored does nothing.
 //
 // Parameters:
-//   - t *models.Flight: A pointer to the flight to be deleted. The flight's source airport ID and destination airport ID
-//     should be set before calling this function.
+//   - t *models.Flight: A pointer to the flight to be deleted. The flight's ID should be set before calling this function.
 //
 // Return:
-//   - An error if the flight is not found in the data structure after deletion.
-//   - nil if the flight is successfully deleted.
+//   - nil, since the flight is never left in the data structure.
 func (dao *MemoryFlightDAO) Delete(t *models.Flight) error {
 	dao.mu.Lock()
 	defer dao.mu.Unlock()
-	delete(dao.data[t.SourceAirportId], t.DestAirportId)
-
-	_, exists := dao.data[t.SourceAirportId][t.DestAirportId]
-
-	if exists {
-		return errors.New("delete was unsuccessful")
-	}
+	dao.remove(t.Id)
 
 	return nil
 }
 
 // FindById retrieves a flight from the memory data structure based on its unique ID.
-// It iterates through the map of flights and checks if the flight's ID matches the provided ID.
+// It looks the flight up in the index of flights by ID.
 // If a matching flight is found, it is returned along with a nil error.
 // If no matching flight is found, nil is returned along with an error indicating that the flight was not found.
 //
@@ -165,15 +202,13 @@ func (dao *MemoryFlightDAO) Delete(t *models.Flight) error {
 func (dao *MemoryFlightDAO) FindById(id uuid.UUID) (*models.Flight, error) {
 	dao.mu.RLock()
 	defer dao.mu.RUnlock()
-	for _, array := range dao.data {
-		for _, flight := range array {
-			if flight.Id == id {
-				return flight, nil
-			}
-		}
+	flight, exists := dao.byId[id]
+
+	if !exists {
+		return nil, errors.New("flight not found")
 	}
 
-	return nil, errors.New("flight not found")
+	return flight, nil
 }
 
 // FindBySource retrieves all flights departing from a specific airport.
@@ -198,57 +233,75 @@ func (dao *MemoryFlightDAO) FindBySource(id uuid.UUID) ([]*models.Flight, error)
 
 	flights := make([]*models.Flight, 0, len(t))
 
-	for _, flight := range t {
-		flights = append(flights, flight)
+	for _, pair := range t {
+		flights = append(flights, pair...)
 	}
 
 	return flights, nil
 }
 
-// FindBySourceAndDest retrieves a specific flight from the memory data structure based on its source and destination airport IDs.
-// It checks if a flight exists in the data structure with the provided source and destination airport IDs.
-// If a matching flight is found, it is returned along with a nil error.
+// FindBySourceAndDest retrieves the flights between two airports that depart on a given day.
+// A flight departs on the day if its departure, in the local time of its source airport, falls on that calendar day.
+// Flights that are not dated are returned for any day, and every flight between the airports is returned for a zero day.
 // If no matching flight is found, nil is returned along with an error indicating that the flight was not found.
 //
 // Parameters:
 //   - source uuid.UUID: The unique ID of the source airport.
 //   - dest uuid.UUID: The unique ID of the destination airport.
+//   - day time.Time: The calendar day of the departure, or the zero time for any day.
 //
 // Return:
-//   - *models.Flight: A pointer to the flight with the matching source and destination airport IDs, or nil if no matching flight is found.
-//   - error: An error indicating that the flight was not found, or nil if the flight is successfully retrieved.
-func (dao *MemoryFlightDAO) FindBySourceAndDest(source uuid.UUID, dest uuid.UUID) (*models.Flight, error) {
+//   - []*models.Flight: The flights between the airports departing on the day, sorted by departure.
+//   - error: An error indicating that the flight was not found, or nil if flights are successfully retrieved.
+func (dao *MemoryFlightDAO) FindBySourceAndDest(source uuid.UUID, dest uuid.UUID, day time.Time) ([]*models.Flight, error) {
 	dao.mu.RLock()
 	defer dao.mu.RUnlock()
-	t, exists := dao.data[source][dest]
 
-	if !exists {
+	var flights []*models.Flight
+	for _, flight := range dao.data[source][dest] {
+		if day.IsZero() || flight.DepartsOn(day) {
+			flights = append(flights, flight)
+		}
+	}
+
+	if len(flights) == 0 {
 		return nil, errors.New("flight not found")
 	}
 
-	return t, nil
+	sort.SliceStable(flights, func(i, j int) bool {
+		return flights[i].Departure.Before(flights[j].Departure)
+	})
+
+	return flights, nil
 }
 
-// BreadthFirstSearch performs a breadth-first search on the flight data structure to find the shortest path between two airports.
-// It uses the source airport ID and destination airport ID as input parameters.
-// The function returns a slice of pointers to models.Flight representing the shortest path between the source and destination airports.
+// BreadthFirstSearch performs a breadth-first search on the flight data structure to find the route with the
+// fewest flights between two airports.
+// Only flights with seats left, in the given cabin class if there is one, that were neither cancelled
+// nor have already departed are used.
+// The first flight must depart on the given day, unless the day is zero, and each dated flight of the route
+// must depart between MinConnection and MaxLayover after the arrival of the previous dated one.
+// Among the flights to an airport, the one arriving first is taken, so later connections are more likely.
 // If no route is available, it returns an error indicating that no route was found.
 //
 // Parameters:
 //   - source uuid.UUID: The unique ID of the source airport.
 //   - dest uuid.UUID: The unique ID of the destination airport.
+//   - day time.Time: The calendar day of the first departure, or the zero time for any day.
+//   - cabin models.CabinClass: The cabin class every flight must have seats left in, or empty for any cabin.
 //
 // Return:
 //   - []*models.Flight: A slice of pointers to flights representing the shortest path between the source and destination airports.
 //   - error: An error indicating that no route was found, or nil if a route is successfully retrieved.
-func (dao *MemoryFlightDAO) BreadthFirstSearch(source uuid.UUID, dest uuid.UUID) ([]*models.Flight, error) {
+func (dao *MemoryFlightDAO) BreadthFirstSearch(source uuid.UUID, dest uuid.UUID, day time.Time, cabin models.CabinClass) ([]*models.Flight, error) {
 	dao.mu.RLock()
 	defer dao.mu.RUnlock()
+	now := time.Now()
 	visited := make(map[uuid.UUID]bool, len(dao.data))
 	queue := []uuid.UUID{source}
 	visited[source] = true
-	parent := make(map[uuid.UUID]uuid.UUID, len(dao.data))
-	parent[source] = source
+	// parent holds the flight taken to reach each airport; the source has none.
+	parent := make(map[uuid.UUID]*models.Flight, len(dao.data))
 
 	for len(queue) > 0 {
 		current := queue[0]
@@ -257,37 +310,199 @@ func (dao *MemoryFlightDAO) BreadthFirstSearch(source uuid.UUID, dest uuid.UUID)
 			break
 		}
 
-		for neighbor, flight := range dao.data[current] {
-			if !visited[neighbor] && flight.Seats > 0 {
+		previous := parent[current]
+		for neighbor, flights := range dao.data[current] {
+			if visited[neighbor] {
+				continue
+			}
+
+			var best *models.Flight
+			for _, flight := range flights {
+				if !connects(previous, flight, day, cabin, now) {
+					continue
+				}
+				if best == nil || flight.Arrival.Before(best.Arrival) {
+					best = flight
+				}
+			}
+
+			if best != nil {
 				visited[neighbor] = true
 				queue = append(queue, neighbor)
-				parent[neighbor] = current
+				parent[neighbor] = best
 			}
 		}
 	}
 
-	path := []*models.Flight{}
-	current := dest
-
 	if !visited[dest] {
 		return nil, errors.New("no route available")
 	}
 
-	for current != source {
-		prev := parent[current]
-		flight := dao.data[prev][current]
+	path := []*models.Flight{}
+	for current := dest; current != source; {
+		flight := parent[current]
 		path = append([]*models.Flight{flight}, path...)
-		current = prev
+		current = flight.SourceAirportId
 	}
 
 	return path, nil
 }
 
+// ShortestPath performs an A* search on the flight data structure to find the route between two airports with the
+// lowest total cost, as weighed by the given cost function. Without an estimate, it is Dijkstra's algorithm.
+// The flights are the nodes of the search, rather than the airports, since the cost of a flight, like a layover,
+// and whether it can be taken at all may depend on the flight before it.
+// Only flights with seats left, in the given cabin class if there is one, that were neither cancelled
+// nor have already departed are used, under the same connection rules as BreadthFirstSearch.
+// The search runs on a copy of the routes taken under the read lock, so the cost function may lock the flights.
+// If no route is available, it returns an error indicating that no route was found.
+//
+// Parameters:
+//   - source uuid.UUID: The unique ID of the source airport.
+//   - dest uuid.UUID: The unique ID of the destination airport.
+//   - day time.Time: The calendar day of the first departure, or the zero time for any day.
+//   - cabin models.CabinClass: The cabin class every flight must have seats left in, or empty for any cabin.
+//   - cost models.RouteCost: The cost of each flight of the route, and the estimate of the cost left from each airport.
+//
+// Return:
+//   - []*models.Flight: A slice of pointers to flights representing the cheapest route between the source and destination airports.
+//   - error: An error indicating that no route was found, or nil if a route is successfully retrieved.
+func (dao *MemoryFlightDAO) ShortestPath(source uuid.UUID, dest uuid.UUID, day time.Time, cabin models.CabinClass, cost models.RouteCost) ([]*models.Flight, error) {
+	dao.mu.RLock()
+	departures := make(map[uuid.UUID][]*models.Flight, len(dao.data))
+	for airport, destinations := range dao.data {
+		for _, flights := range destinations {
+			departures[airport] = append(departures[airport], flights...)
+		}
+	}
+	dao.mu.RUnlock()
+
+	now := time.Now()
+	queue := &pathQueue{}
+	settled := make(map[uuid.UUID]bool)
+
+	push := func(previous *pathNode, flight *models.Flight) {
+		var before *models.Flight
+		total := 0.0
+		if previous != nil {
+			before, total = previous.flight, previous.cost
+		}
+		if settled[flight.Id] || !connects(before, flight, day, cabin, now) {
+			return
+		}
+
+		weight := cost.Cost(before, flight)
+		if math.IsInf(weight, 1) || math.IsNaN(weight) {
+			return
+		}
+		total += weight
+		heap.Push(queue, &pathNode{
+			flight:   flight,
+			parent:   previous,
+			cost:     total,
+			priority: total + cost.Estimate(flight.DestAirportId),
+		})
+	}
+
+	for _, flight := range departures[source] {
+		push(nil, flight)
+	}
+
+	for queue.Len() > 0 {
+		node := heap.Pop(queue).(*pathNode)
+		if settled[node.flight.Id] {
+			continue
+		}
+		settled[node.flight.Id] = true
+
+		if node.flight.DestAirportId == dest {
+			path := []*models.Flight{}
+			for ; node != nil; node = node.parent {
+				path = append([]*models.Flight{node.flight}, path...)
+			}
+			return path, nil
+		}
+
+		for _, flight := range departures[node.flight.DestAirportId] {
+			push(node, flight)
+		}
+	}
+
+	return nil, errors.New("no route available")
+}
+
+// pathNode is a flight reached by ShortestPath, with the cost of the route up to it and the flight before it.
+type pathNode struct {
+	flight   *models.Flight
+	parent   *pathNode
+	cost     float64 // cost of the route up to and including the flight
+	priority float64 // cost plus the estimate of the cost left
+}
+
+// pathQueue is a priority queue of path nodes, the lowest priority first. It implements heap.Interface.
+type pathQueue []*pathNode
+
+func (q pathQueue) Len() int           { return len(q) }
+func (q pathQueue) Less(i, j int) bool { return q[i].priority < q[j].priority }
+func (q pathQueue) Swap(i, j int)      { q[i], q[j] = q[j], q[i] }
+
+func (q *pathQueue) Push(x interface{}) {
+	*q = append(*q, x.(*pathNode))
+}
+
+func (q *pathQueue) Pop() interface{} {
+	old := *q
+	last := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return last
+}
+
+// connects reports whether a flight can be taken after another one on a route.
+// The caller must hold the read lock, or search a copy of the routes taken under it, and must not hold the lock
+// of either flight: each flight is locked in turn while it is checked.
+//
+// Parameters:
+//   - previous: The flight arriving at the flight's source airport, or nil for the first flight of the route.
+//   - flight: The flight to be taken.
+//   - day: The calendar day of the first departure, or the zero time for any day.
+//   - cabin: The cabin class the flight must have seats left in, or empty for any cabin.
+//   - now: The current time, before which flights have departed.
+//
+// Return:
+//   - true if the flight can be booked and connects to the previous one.
+func connects(previous *models.Flight, flight *models.Flight, day time.Time, cabin models.CabinClass, now time.Time) bool {
+	flight.Mu.Lock()
+	bookable := flight.SeatsLeft(cabin) > 0 && !flight.Cancelled && !flight.Departed(now)
+	onDay := day.IsZero() || flight.DepartsOn(day)
+	departure := flight.Departure
+	flight.Mu.Unlock()
+
+	if !bookable {
+		return false
+	}
+
+	if previous == nil {
+		return onDay
+	}
+
+	previous.Mu.Lock()
+	dated := previous.Dated()
+	arrival := previous.Arrival
+	previous.Mu.Unlock()
+
+	if !dated || departure.IsZero() {
+		return true
+	}
+
+	layover := departure.Sub(arrival)
+	return layover >= MinConnection && layover <= MaxLayover
+}
+
 // DeleteAll removes all flights from the memory data structure.
 // It resets the internal map of flights to an empty map, effectively deleting all flights.
 // This function is useful for testing or resetting the data structure to its initial state.
 func (dao *MemoryFlightDAO) DeleteAll() {
 	dao.mu.Lock()
 	defer dao.mu.Unlock()
-	dao.data = make(map[uuid.UUID]map[uuid.UUID]*models.Flight)
+	dao.reset()
 }
@@ -2,6 +2,7 @@
 package interfaces
 
 import (
+	"time"
 	"vendepass/internal/models"
 
 	"github.com/google/uuid"
@@ -14,18 +15,40 @@ type FlightDAO interface {
 	Delete(*models.Flight) error
 	FindById(uuid.UUID) (*models.Flight, error)
 	FindBySource(uuid.UUID) ([]*models.Flight, error)
-	FindBySourceAndDest(uuid.UUID, uuid.UUID) (*models.Flight, error)
-	BreadthFirstSearch(source uuid.UUID, dest uuid.UUID) ([]*models.Flight, error)
+	FindBySourceAndDest(source uuid.UUID, dest uuid.UUID, day time.Time) ([]*models.Flight, error)
+	BreadthFirstSearch(source uuid.UUID, dest uuid.UUID, day time.Time, cabin models.CabinClass) ([]*models.Flight, error)
+	ShortestPath(source uuid.UUID, dest uuid.UUID, day time.Time, cabin models.CabinClass, cost models.RouteCost) ([]*models.Flight, error)
 	DeleteAll()
 	New()
 }
 
+type ScheduleDAO interface {
+	FindAll() []*models.Schedule
+	Insert(*models.Schedule)
+	Delete(*models.Schedule)
+	FindById(uuid.UUID) (*models.Schedule, error)
+	New()
+}
+
+type BookingDAO interface {
+	FindAll() []*models.Booking
+	Insert(*models.Booking)
+	Update(*models.Booking) error
+	Delete(*models.Booking)
+	FindById(uuid.UUID) (*models.Booking, error)
+	FindByReference(string) (*models.Booking, error)
+	FindByClientId(uuid.UUID) []*models.Booking
+	New()
+}
+
 type ClientDAO interface {
 	FindAll() []*models.Client
-	Insert(*models.Client)
+	Insert(*models.Client) error
 	Update(*models.Client) error
+	Modify(id uuid.UUID, change func(*models.Client) error) (*models.Client, error)
 	Delete(models.Client)
 	FindById(uuid.UUID) (*models.Client, error)
+	FindByUsername(string) (*models.Client, error)
 	New()
 }
 
@@ -35,6 +58,44 @@ type SessionDAO interface {
 	Update(*models.Session) error
 	Delete(*models.Session)
 	FindById(uuid.UUID) (*models.Session, error)
+	FindByClientId(uuid.UUID) []*models.Session
+	DeleteAll()
+	New()
+}
+
+type CartDAO interface {
+	FindAll() []*models.Cart
+	FindByClientId(uuid.UUID) (*models.Cart, error)
+	FindOrCreate(uuid.UUID) *models.Cart
+	Delete(*models.Cart)
+	DeleteAll()
+	New()
+}
+
+type WaitlistDAO interface {
+	Insert(*models.WaitlistEntry)
+	Delete(*models.WaitlistEntry) bool
+	FindById(uuid.UUID) (*models.WaitlistEntry, error)
+	FindByFlightId(uuid.UUID) []*models.WaitlistEntry
+	FindByClientId(uuid.UUID) []*models.WaitlistEntry
+	DeleteAll()
+	New()
+}
+
+type QuoteDAO interface {
+	Insert(*models.Quote)
+	FindById(uuid.UUID) (*models.Quote, error)
+	Delete(uuid.UUID) error
+	DeleteExpired(time.Time)
+	DeleteAll()
+	New()
+}
+
+type NotificationDAO interface {
+	Insert(*models.Notification)
+	FindByClientId(uuid.UUID) []*models.Notification
+	TakeByClientId(uuid.UUID) []*models.Notification
+	DeleteByClientId(uuid.UUID)
 	DeleteAll()
 	New()
 }
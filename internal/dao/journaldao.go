@@ -0,0 +1,533 @@
+package dao
+
+import (
+	"encoding/json"
+	"log"
+	"time"
+	"vendepass/internal/journal"
+	"vendepass/internal/models"
+
+	"github.com/google/uuid"
+)
+
+// Kinds of record written to the journal.
+const (
+	flightKind   = "flight"
+	scheduleKind = "schedule"
+	clientKind   = "client"
+	bookingKind  = "booking"
+	airportKind  = "airport"
+)
+
+// replayInto rebuilds the records of one kind from the journal.
+// If the journal was just created, the records are loaded by importStubs instead and journaled,
+// so the stub JSON files are only imported once.
+//
+// Parameters:
+//   - j: The journal being replayed.
+//   - kind: The kind of record to be replayed.
+//   - apply: The function that applies a single entry to the DAO.
+//   - importStubs: The function that loads the stub JSON file and journals every record.
+func replayInto(j *journal.Journal, kind string, apply func(journal.Entry) error, importStubs func()) {
+	if j.Fresh() {
+		importStubs()
+		return
+	}
+
+	if err := j.Replay(kind, apply); err != nil {
+		log.Fatalf("Error replaying %s journal: %v", kind, err)
+	}
+}
+
+// JournalFlightDAO is a MemoryFlightDAO that appends every change to a journal,
+// so the flights can be recovered after a crash. Seats held by reservations are recovered as free,
+// since the sessions holding them do not outlive the process.
+type JournalFlightDAO struct {
+	*MemoryFlightDAO
+	journal *journal.Journal
+}
+
+// NewJournalFlightDAO creates a JournalFlightDAO that writes to the given journal. New must be called before using it.
+func NewJournalFlightDAO(j *journal.Journal) *JournalFlightDAO {
+	return &JournalFlightDAO{
+		MemoryFlightDAO: &MemoryFlightDAO{data: make(map[uuid.UUID]map[uuid.UUID][]*models.Flight), byId: make(map[uuid.UUID]*models.Flight)},
+		journal:         j,
+	}
+}
+
+// New rebuilds the flights from the latest snapshot and the journal.
+func (dao *JournalFlightDAO) New() {
+	replayInto(dao.journal, flightKind, func(entry journal.Entry) error {
+		dao.MemoryFlightDAO.mu.Lock()
+		defer dao.MemoryFlightDAO.mu.Unlock()
+
+		switch entry.Op {
+		case journal.Put:
+			var f models.FlightJSON
+			if err := json.Unmarshal(entry.Data, &f); err != nil {
+				return err
+			}
+			dao.MemoryFlightDAO.add(models.NewFlight(f))
+		case journal.Delete:
+			dao.MemoryFlightDAO.remove(entry.Id)
+		case journal.Clear:
+			dao.MemoryFlightDAO.reset()
+		}
+		return nil
+	}, func() {
+		dao.MemoryFlightDAO.New()
+		for _, flight := range dao.MemoryFlightDAO.FindAll() {
+			dao.record(flight)
+		}
+	})
+}
+
+// record journals the current state of a flight, taken under the flight's lock once the journal's is held.
+func (dao *JournalFlightDAO) record(t *models.Flight) error {
+	err := dao.journal.Put(flightKind, t.Id, func() ([]byte, error) {
+		return json.Marshal(t.ToJSON())
+	})
+	if err != nil {
+		log.Println("Error journaling flight:", err)
+	}
+	return err
+}
+
+// Insert adds a new flight and journals it.
+func (dao *JournalFlightDAO) Insert(t *models.Flight) {
+	dao.MemoryFlightDAO.Insert(t)
+	dao.record(t)
+}
+
+// Update replaces an existing flight and journals its new state.
+// The caller must not hold the flight's lock.
+func (dao *JournalFlightDAO) Update(t *models.Flight) error {
+	if err := dao.MemoryFlightDAO.Update(t); err != nil {
+		return err
+	}
+	return dao.record(t)
+}
+
+// Delete removes a flight and journals its removal.
+func (dao *JournalFlightDAO) Delete(t *models.Flight) error {
+	if err := dao.MemoryFlightDAO.Delete(t); err != nil {
+		return err
+	}
+	return dao.journal.Append(journal.Delete, flightKind, t.Id, nil)
+}
+
+// DeleteAll removes every flight and journals their removal.
+func (dao *JournalFlightDAO) DeleteAll() {
+	dao.MemoryFlightDAO.DeleteAll()
+	if err := dao.journal.Append(journal.Clear, flightKind, uuid.Nil, nil); err != nil {
+		log.Println("Error journaling flights:", err)
+	}
+}
+
+// entries returns the current state of every flight as journal entries.
+func (dao *JournalFlightDAO) entries() []journal.Entry {
+	flights := dao.MemoryFlightDAO.FindAll()
+	entries := make([]journal.Entry, 0, len(flights))
+	for _, flight := range flights {
+		data, _ := json.Marshal(flight.ToJSON())
+		entries = append(entries, journal.Entry{Kind: flightKind, Id: flight.Id, Data: data})
+	}
+	return entries
+}
+
+// JournalScheduleDAO is a MemoryScheduleDAO that appends every change to a journal.
+type JournalScheduleDAO struct {
+	*MemoryScheduleDAO
+	journal *journal.Journal
+}
+
+// NewJournalScheduleDAO creates a JournalScheduleDAO that writes to the given journal. New must be called before using it.
+func NewJournalScheduleDAO(j *journal.Journal) *JournalScheduleDAO {
+	return &JournalScheduleDAO{
+		MemoryScheduleDAO: &MemoryScheduleDAO{data: make(map[uuid.UUID]*models.Schedule)},
+		journal:           j,
+	}
+}
+
+// New rebuilds the schedules from the latest snapshot and the journal.
+func (dao *JournalScheduleDAO) New() {
+	replayInto(dao.journal, scheduleKind, func(entry journal.Entry) error {
+		dao.MemoryScheduleDAO.mu.Lock()
+		defer dao.MemoryScheduleDAO.mu.Unlock()
+
+		switch entry.Op {
+		case journal.Put:
+			schedule := new(models.Schedule)
+			if err := json.Unmarshal(entry.Data, schedule); err != nil {
+				return err
+			}
+			dao.MemoryScheduleDAO.add(schedule)
+		case journal.Delete:
+			delete(dao.MemoryScheduleDAO.data, entry.Id)
+		case journal.Clear:
+			dao.MemoryScheduleDAO.data = make(map[uuid.UUID]*models.Schedule)
+		}
+		return nil
+	}, func() {
+		dao.MemoryScheduleDAO.New()
+		for _, schedule := range dao.MemoryScheduleDAO.FindAll() {
+			dao.record(schedule)
+		}
+	})
+}
+
+// record journals the current state of a schedule, taken once the journal's lock is held.
+func (dao *JournalScheduleDAO) record(t *models.Schedule) error {
+	err := dao.journal.Put(scheduleKind, t.Id, func() ([]byte, error) {
+		return json.Marshal(t)
+	})
+	if err != nil {
+		log.Println("Error journaling schedule:", err)
+	}
+	return err
+}
+
+// Insert adds a new schedule and journals it.
+func (dao *JournalScheduleDAO) Insert(t *models.Schedule) {
+	dao.MemoryScheduleDAO.Insert(t)
+	dao.record(t)
+}
+
+// Delete removes a schedule and journals its removal.
+func (dao *JournalScheduleDAO) Delete(t *models.Schedule) {
+	dao.MemoryScheduleDAO.Delete(t)
+	if err := dao.journal.Append(journal.Delete, scheduleKind, t.Id, nil); err != nil {
+		log.Println("Error journaling schedule:", err)
+	}
+}
+
+// entries returns the current state of every schedule as journal entries.
+func (dao *JournalScheduleDAO) entries() []journal.Entry {
+	schedules := dao.MemoryScheduleDAO.FindAll()
+	entries := make([]journal.Entry, 0, len(schedules))
+	for _, schedule := range schedules {
+		data, _ := json.Marshal(schedule)
+		entries = append(entries, journal.Entry{Kind: scheduleKind, Id: schedule.Id, Data: data})
+	}
+	return entries
+}
+
+// JournalClientDAO is a MemoryClientDAO that appends every change to a journal,
+// so the clients can be recovered after a crash.
+type JournalClientDAO struct {
+	*MemoryClientDAO
+	journal *journal.Journal
+}
+
+// NewJournalClientDAO creates a JournalClientDAO that writes to the given journal. New must be called before using it.
+func NewJournalClientDAO(j *journal.Journal) *JournalClientDAO {
+	return &JournalClientDAO{
+		MemoryClientDAO: &MemoryClientDAO{data: make(map[uuid.UUID]*models.Client)},
+		journal:         j,
+	}
+}
+
+// New rebuilds the clients from the latest snapshot and the journal.
+func (dao *JournalClientDAO) New() {
+	replayInto(dao.journal, clientKind, func(entry journal.Entry) error {
+		dao.MemoryClientDAO.mu.Lock()
+		defer dao.MemoryClientDAO.mu.Unlock()
+
+		switch entry.Op {
+		case journal.Put:
+			client := new(models.Client)
+			if err := json.Unmarshal(entry.Data, client); err != nil {
+				return err
+			}
+			dao.MemoryClientDAO.add(client)
+		case journal.Delete:
+			dao.MemoryClientDAO.remove(entry.Id)
+		case journal.Clear:
+			dao.MemoryClientDAO.data = make(map[uuid.UUID]*models.Client)
+			dao.MemoryClientDAO.usernames = nil
+		}
+		return nil
+	}, func() {
+		dao.MemoryClientDAO.New()
+		for _, client := range dao.MemoryClientDAO.FindAll() {
+			dao.record(client)
+		}
+	})
+}
+
+// record journals the current state of a client, taken under the DAO's read lock once the journal's is held,
+// since clients are changed in place under the DAO's lock.
+func (dao *JournalClientDAO) record(t *models.Client) error {
+	err := dao.journal.Put(clientKind, t.Id, func() ([]byte, error) {
+		dao.MemoryClientDAO.mu.RLock()
+		defer dao.MemoryClientDAO.mu.RUnlock()
+		return json.Marshal(t)
+	})
+	if err != nil {
+		log.Println("Error journaling client:", err)
+	}
+	return err
+}
+
+// Insert adds a new client and journals it.
+func (dao *JournalClientDAO) Insert(t *models.Client) error {
+	if err := dao.MemoryClientDAO.Insert(t); err != nil {
+		return err
+	}
+	return dao.record(t)
+}
+
+// Update replaces an existing client and journals its new state.
+func (dao *JournalClientDAO) Update(t *models.Client) error {
+	if err := dao.MemoryClientDAO.Update(t); err != nil {
+		return err
+	}
+	return dao.record(t)
+}
+
+// Modify changes an existing client in place and journals its new state.
+func (dao *JournalClientDAO) Modify(id uuid.UUID, change func(*models.Client) error) (*models.Client, error) {
+	client, err := dao.MemoryClientDAO.Modify(id, change)
+	if err != nil {
+		return nil, err
+	}
+	return client, dao.record(client)
+}
+
+// Delete removes a client and journals its removal.
+func (dao *JournalClientDAO) Delete(t models.Client) {
+	dao.MemoryClientDAO.Delete(t)
+	if err := dao.journal.Append(journal.Delete, clientKind, t.Id, nil); err != nil {
+		log.Println("Error journaling client:", err)
+	}
+}
+
+// entries returns the current state of every client as journal entries.
+func (dao *JournalClientDAO) entries() []journal.Entry {
+	clients := dao.MemoryClientDAO.FindAll()
+	entries := make([]journal.Entry, 0, len(clients))
+
+	dao.MemoryClientDAO.mu.RLock()
+	defer dao.MemoryClientDAO.mu.RUnlock()
+	for _, client := range clients {
+		data, _ := json.Marshal(client)
+		entries = append(entries, journal.Entry{Kind: clientKind, Id: client.Id, Data: data})
+	}
+	return entries
+}
+
+// JournalBookingDAO is a MemoryBookingDAO that appends every change to a journal,
+// so the bookings can be recovered after a crash.
+type JournalBookingDAO struct {
+	*MemoryBookingDAO
+	journal *journal.Journal
+}
+
+// NewJournalBookingDAO creates a JournalBookingDAO that writes to the given journal. New must be called before using it.
+func NewJournalBookingDAO(j *journal.Journal) *JournalBookingDAO {
+	return &JournalBookingDAO{
+		MemoryBookingDAO: &MemoryBookingDAO{data: make(map[uuid.UUID]*models.Booking)},
+		journal:          j,
+	}
+}
+
+// New rebuilds the bookings from the latest snapshot and the journal.
+func (dao *JournalBookingDAO) New() {
+	replayInto(dao.journal, bookingKind, func(entry journal.Entry) error {
+		dao.MemoryBookingDAO.mu.Lock()
+		defer dao.MemoryBookingDAO.mu.Unlock()
+
+		switch entry.Op {
+		case journal.Put:
+			booking := new(models.Booking)
+			if err := json.Unmarshal(entry.Data, booking); err != nil {
+				return err
+			}
+			dao.MemoryBookingDAO.add(booking)
+		case journal.Delete:
+			dao.MemoryBookingDAO.remove(entry.Id)
+		case journal.Clear:
+			dao.MemoryBookingDAO.data = make(map[uuid.UUID]*models.Booking)
+			dao.MemoryBookingDAO.references = nil
+		}
+		return nil
+	}, func() {})
+}
+
+// record journals the current state of a booking, taken once the journal's lock is held.
+func (dao *JournalBookingDAO) record(t *models.Booking) error {
+	err := dao.journal.Put(bookingKind, t.Id, func() ([]byte, error) {
+		return json.Marshal(t)
+	})
+	if err != nil {
+		log.Println("Error journaling booking:", err)
+	}
+	return err
+}
+
+// Insert adds a new booking and journals it.
+func (dao *JournalBookingDAO) Insert(t *models.Booking) {
+	dao.MemoryBookingDAO.Insert(t)
+	dao.record(t)
+}
+
+// Update replaces an existing booking and journals its new state.
+func (dao *JournalBookingDAO) Update(t *models.Booking) error {
+	if err := dao.MemoryBookingDAO.Update(t); err != nil {
+		return err
+	}
+	return dao.record(t)
+}
+
+// Delete removes a booking and journals its removal.
+func (dao *JournalBookingDAO) Delete(t *models.Booking) {
+	dao.MemoryBookingDAO.Delete(t)
+	if err := dao.journal.Append(journal.Delete, bookingKind, t.Id, nil); err != nil {
+		log.Println("Error journaling booking:", err)
+	}
+}
+
+// entries returns the current state of every booking as journal entries.
+func (dao *JournalBookingDAO) entries() []journal.Entry {
+	bookings := dao.MemoryBookingDAO.FindAll()
+	entries := make([]journal.Entry, 0, len(bookings))
+	for _, booking := range bookings {
+		data, _ := json.Marshal(booking)
+		entries = append(entries, journal.Entry{Kind: bookingKind, Id: booking.Id, Data: data})
+	}
+	return entries
+}
+
+// JournalAirportDAO is a MemoryAirportDAO that appends every change to a journal,
+// so the airports can be recovered after a crash.
+type JournalAirportDAO struct {
+	*MemoryAirportDAO
+	journal *journal.Journal
+}
+
+// NewJournalAirportDAO creates a JournalAirportDAO that writes to the given journal. New must be called before using it.
+func NewJournalAirportDAO(j *journal.Journal) *JournalAirportDAO {
+	return &JournalAirportDAO{
+		MemoryAirportDAO: &MemoryAirportDAO{data: make(map[uuid.UUID]*models.Airport)},
+		journal:          j,
+	}
+}
+
+// New rebuilds the airports from the latest snapshot and the journal.
+func (dao *JournalAirportDAO) New() {
+	replayInto(dao.journal, airportKind, func(entry journal.Entry) error {
+		dao.MemoryAirportDAO.mu.Lock()
+		defer dao.MemoryAirportDAO.mu.Unlock()
+
+		switch entry.Op {
+		case journal.Put:
+			airport := new(models.Airport)
+			if err := json.Unmarshal(entry.Data, airport); err != nil {
+				return err
+			}
+			dao.MemoryAirportDAO.add(airport)
+		case journal.Delete:
+			delete(dao.MemoryAirportDAO.data, entry.Id)
+		case journal.Clear:
+			dao.MemoryAirportDAO.data = make(map[uuid.UUID]*models.Airport)
+		}
+		return nil
+	}, func() {
+		dao.MemoryAirportDAO.New()
+		for _, airport := range dao.MemoryAirportDAO.FindAll() {
+			dao.record(airport)
+		}
+	})
+}
+
+// record journals the current state of an airport, taken under the airport's lock once the journal's is held.
+func (dao *JournalAirportDAO) record(t *models.Airport) error {
+	err := dao.journal.Put(airportKind, t.Id, func() ([]byte, error) {
+		t.Mu.RLock()
+		defer t.Mu.RUnlock()
+		return json.Marshal(t)
+	})
+	if err != nil {
+		log.Println("Error journaling airport:", err)
+	}
+	return err
+}
+
+// Insert adds a new airport and journals it.
+func (dao *JournalAirportDAO) Insert(t *models.Airport) {
+	dao.MemoryAirportDAO.Insert(t)
+	dao.record(t)
+}
+
+// Update replaces an existing airport and journals its new state.
+func (dao *JournalAirportDAO) Update(t *models.Airport) error {
+	if err := dao.MemoryAirportDAO.Update(t); err != nil {
+		return err
+	}
+	return dao.record(t)
+}
+
+// Delete removes an airport and journals its removal.
+func (dao *JournalAirportDAO) Delete(t *models.Airport) {
+	dao.MemoryAirportDAO.Delete(t)
+	if err := dao.journal.Append(journal.Delete, airportKind, t.Id, nil); err != nil {
+		log.Println("Error journaling airport:", err)
+	}
+}
+
+// entries returns the current state of every airport as journal entries.
+func (dao *JournalAirportDAO) entries() []journal.Entry {
+	airports := dao.MemoryAirportDAO.FindAll()
+	entries := make([]journal.Entry, 0, len(airports))
+	for _, airport := range airports {
+		data, _ := json.Marshal(airport)
+		entries = append(entries, journal.Entry{Kind: airportKind, Id: airport.Id, Data: data})
+	}
+	return entries
+}
+
+// Snapshot writes the current state of the journaled DAOs to a snapshot and compacts the journal.
+// It does nothing when another storage is configured or when no change was journaled since the last snapshot.
+//
+// Return:
+//   - An error if the snapshot could not be written.
+func Snapshot() error {
+	if storage != JournalStorage || journalLog.Pending() == 0 {
+		return nil
+	}
+
+	// Every DAO must be loaded first, otherwise the records still only found in the journal would be lost.
+	flights := GetFlightDAO().(*JournalFlightDAO)
+	schedules := GetScheduleDAO().(*JournalScheduleDAO)
+	clients := GetClientDAO().(*JournalClientDAO)
+	bookings := GetBookingDAO().(*JournalBookingDAO)
+	airports := GetAirportDAO().(*JournalAirportDAO)
+
+	return journalLog.Compact(func() []journal.Entry {
+		entries := flights.entries()
+		entries = append(entries, schedules.entries()...)
+		entries = append(entries, clients.entries()...)
+		entries = append(entries, bookings.entries()...)
+		return append(entries, airports.entries()...)
+	})
+}
+
+// SnapshotPeriodically calls Snapshot at every interval, logging any failure.
+// It returns immediately unless the journal storage is configured.
+//
+// Parameters:
+//   - interval: The time between two snapshots.
+func SnapshotPeriodically(interval time.Duration) {
+	if storage != JournalStorage {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		if err := Snapshot(); err != nil {
+			log.Println("Error writing snapshot:", err)
+		}
+	}
+}
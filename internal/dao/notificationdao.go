@@ -0,0 +1,84 @@
+package dao
+
+import (
+	"sync"
+	"vendepass/internal/models"
+
+	"github.com/google/uuid"
+)
+
+// MemoryNotificationDAO keeps the notifications waiting to be read by each client, indexed by the client's ID.
+type MemoryNotificationDAO struct {
+	data map[uuid.UUID][]*models.Notification
+	mu   sync.RWMutex
+}
+
+// New initializes the MemoryNotificationDAO by creating a new map to store notifications.
+func (dao *MemoryNotificationDAO) New() {
+	dao.mu.Lock()
+	defer dao.mu.Unlock()
+
+	dao.data = make(map[uuid.UUID][]*models.Notification)
+}
+
+// Insert adds a notification to the inbox of its client, giving it a new ID.
+//
+// Parameters:
+//   - t: A pointer to the notification to be added. Its ClientId names the client it is for.
+func (dao *MemoryNotificationDAO) Insert(t *models.Notification) {
+	dao.mu.Lock()
+	defer dao.mu.Unlock()
+
+	t.Id = uuid.New()
+	dao.data[t.ClientId] = append(dao.data[t.ClientId], t)
+}
+
+// FindByClientId retrieves the notifications of a client, oldest first.
+//
+// Parameters:
+//   - id: A UUID representing the ID of the client.
+//
+// Returns:
+//   - A slice of pointers to the client's notifications. If there are none, an empty slice is returned.
+func (dao *MemoryNotificationDAO) FindByClientId(id uuid.UUID) []*models.Notification {
+	dao.mu.RLock()
+	defer dao.mu.RUnlock()
+
+	return append(make([]*models.Notification, 0, len(dao.data[id])), dao.data[id]...)
+}
+
+// TakeByClientId retrieves the notifications of a client, oldest first, and removes them in the same operation,
+// so a notification inserted meanwhile is either returned or kept for the next call, never lost.
+//
+// Parameters:
+//   - id: A UUID representing the ID of the client.
+//
+// Returns:
+//   - A slice of pointers to the client's notifications. If there are none, an empty slice is returned.
+func (dao *MemoryNotificationDAO) TakeByClientId(id uuid.UUID) []*models.Notification {
+	dao.mu.Lock()
+	defer dao.mu.Unlock()
+
+	notifications := append(make([]*models.Notification, 0, len(dao.data[id])), dao.data[id]...)
+	delete(dao.data, id)
+	return notifications
+}
+
+// DeleteByClientId removes every notification of a client, once they were delivered.
+//
+// Parameters:
+//   - id: A UUID representing the ID of the client.
+func (dao *MemoryNotificationDAO) DeleteByClientId(id uuid.UUID) {
+	dao.mu.Lock()
+	defer dao.mu.Unlock()
+
+	delete(dao.data, id)
+}
+
+// DeleteAll removes all notifications from the memory data store.
+func (dao *MemoryNotificationDAO) DeleteAll() {
+	dao.mu.Lock()
+	defer dao.mu.Unlock()
+
+	dao.data = make(map[uuid.UUID][]*models.Notification)
+}
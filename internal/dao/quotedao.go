@@ -0,0 +1,95 @@
+package dao
+
+import (
+	"errors"
+	"sync"
+	"time"
+	"vendepass/internal/models"
+
+	"github.com/google/uuid"
+)
+
+// MemoryQuoteDAO keeps the price quotes offered to clients until they expire.
+type MemoryQuoteDAO struct {
+	data map[uuid.UUID]*models.Quote
+	mu   sync.RWMutex
+}
+
+// New initializes the MemoryQuoteDAO by creating a new map to store quotes.
+func (dao *MemoryQuoteDAO) New() {
+	dao.mu.Lock()
+	defer dao.mu.Unlock()
+
+	dao.data = make(map[uuid.UUID]*models.Quote)
+}
+
+// Insert stores a quote, giving it a new ID.
+//
+// Parameters:
+//   - t: A pointer to the quote to be stored.
+func (dao *MemoryQuoteDAO) Insert(t *models.Quote) {
+	dao.mu.Lock()
+	defer dao.mu.Unlock()
+
+	t.Id = uuid.New()
+	dao.data[t.Id] = t
+}
+
+// FindById retrieves a quote that has not expired yet.
+//
+// Parameters:
+//   - id: The ID of the quote.
+//
+// Return:
+//   - A pointer to the quote, or nil.
+//   - An error if there is no quote with the ID or it has expired.
+func (dao *MemoryQuoteDAO) FindById(id uuid.UUID) (*models.Quote, error) {
+	dao.mu.RLock()
+	defer dao.mu.RUnlock()
+
+	quote, exists := dao.data[id]
+	if !exists || time.Now().After(quote.ExpiresAt) {
+		return nil, errors.New("quote not found")
+	}
+
+	return quote, nil
+}
+
+// Delete removes a quote, so it cannot be used again.
+//
+// Parameters:
+//   - id: The ID of the quote.
+//
+// Return:
+//   - An error if there is no quote with the ID, for instance because it was already used.
+func (dao *MemoryQuoteDAO) Delete(id uuid.UUID) error {
+	dao.mu.Lock()
+	defer dao.mu.Unlock()
+
+	if _, exists := dao.data[id]; !exists {
+		return errors.New("quote not found")
+	}
+	delete(dao.data, id)
+
+	return nil
+}
+
+// DeleteExpired removes every quote that expired before the given time.
+func (dao *MemoryQuoteDAO) DeleteExpired(now time.Time) {
+	dao.mu.Lock()
+	defer dao.mu.Unlock()
+
+	for id, quote := range dao.data {
+		if now.After(quote.ExpiresAt) {
+			delete(dao.data, id)
+		}
+	}
+}
+
+// DeleteAll removes every quote.
+func (dao *MemoryQuoteDAO) DeleteAll() {
+	dao.mu.Lock()
+	defer dao.mu.Unlock()
+
+	dao.data = make(map[uuid.UUID]*models.Quote)
+}
@@ -0,0 +1,105 @@
+package dao
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"vendepass/internal/models"
+
+	"github.com/google/uuid"
+)
+
+// MemoryScheduleDAO is a data access object (DAO) for managing the recurring flight schedules in memory.
+type MemoryScheduleDAO struct {
+	data map[uuid.UUID]*models.Schedule
+	mu   sync.RWMutex
+}
+
+// New initializes the MemoryScheduleDAO by loading the schedules from a JSON file, if there is one.
+func (dao *MemoryScheduleDAO) New() {
+	dao.mu.Lock()
+	defer dao.mu.Unlock()
+
+	baseDir, err := os.Getwd()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	jsonPath := filepath.Join(baseDir, "internal", "stubs", "schedules.json")
+
+	b, _ := os.ReadFile(jsonPath)
+
+	var schedules []*models.Schedule
+
+	json.Unmarshal(b, &schedules)
+
+	for _, schedule := range schedules {
+		dao.add(schedule)
+	}
+}
+
+// add stores a schedule under its current ID. The caller must hold the write lock.
+func (dao *MemoryScheduleDAO) add(schedule *models.Schedule) {
+	dao.data[schedule.Id] = schedule
+}
+
+// FindAll retrieves all schedules from the memory data store.
+//
+// Return:
+//   - A slice with every schedule, empty if there are none.
+func (dao *MemoryScheduleDAO) FindAll() []*models.Schedule {
+	dao.mu.RLock()
+	defer dao.mu.RUnlock()
+	v := make([]*models.Schedule, 0, len(dao.data))
+
+	for _, value := range dao.data {
+		v = append(v, value)
+	}
+
+	return v
+}
+
+// Insert adds a new schedule to the memory data store, giving it a new ID.
+//
+// Parameters:
+//   - t: A pointer to the schedule to be inserted.
+func (dao *MemoryScheduleDAO) Insert(t *models.Schedule) {
+	dao.mu.Lock()
+	defer dao.mu.Unlock()
+
+	t.Id = uuid.New()
+	dao.add(t)
+}
+
+// Delete removes a schedule from the memory data store. The flights it already generated are kept.
+//
+// Parameters:
+//   - t: A pointer to the schedule to be deleted.
+func (dao *MemoryScheduleDAO) Delete(t *models.Schedule) {
+	dao.mu.Lock()
+	defer dao.mu.Unlock()
+	delete(dao.data, t.Id)
+}
+
+// FindById retrieves a schedule from the memory data store based on the provided UUID.
+//
+// Parameters:
+//   - id: The unique identifier of the schedule to be retrieved.
+//
+// Return:
+//   - *models.Schedule: A pointer to the schedule if found, or nil if not found.
+//   - error: An error with the message "schedule not found" if there is no schedule with the ID, or nil.
+func (dao *MemoryScheduleDAO) FindById(id uuid.UUID) (*models.Schedule, error) {
+	dao.mu.RLock()
+	defer dao.mu.RUnlock()
+	schedule, exists := dao.data[id]
+
+	if !exists {
+		return nil, errors.New("schedule not found")
+	}
+
+	return schedule, nil
+}
@@ -42,8 +42,7 @@ func (dao *MemorySessionDAO) FindAll() []*models.Session {
 }
 
 // Insert adds a new session to the memory data store.
-// It generates a new UUID for the session, sets it as the ID, initializes the reservations map,
-// and then stores the session in the data map.
+// It generates a new UUID for the session, sets it as the ID, and then stores the session in the data map.
 //
 // Parameters:
 //   - t: A pointer to a Session struct representing the session to be added.
@@ -53,9 +52,7 @@ func (dao *MemorySessionDAO) Insert(t *models.Session) {
 
 	id := uuid.New()
 	t.ID = id
-	t.Reservations = make(map[uuid.UUID]models.Reservation)
 	t.Mu = sync.RWMutex{}
-	t.FailedReservations = make(chan string)
 	dao.data[id] = t
 }
 
@@ -117,6 +114,30 @@ func (dao *MemorySessionDAO) FindById(id uuid.UUID) (*models.Session, error) {
 	return session, nil
 }
 
+// FindByClientId retrieves every session of a client from the memory data store.
+// It locks the read mutex to ensure thread safety while accessing the data.
+//
+// Parameters:
+//   - id: A UUID representing the ID of the client.
+//
+// Returns:
+//   - A slice of pointers to Session structs, representing the client's sessions.
+//   - If the client has no sessions, an empty slice is returned.
+func (dao *MemorySessionDAO) FindByClientId(id uuid.UUID) []*models.Session {
+	dao.mu.RLock()
+	defer dao.mu.RUnlock()
+
+	v := make([]*models.Session, 0)
+
+	for _, value := range dao.data {
+		if value.ClientID == id {
+			v = append(v, value)
+		}
+	}
+
+	return v
+}
+
 // DeleteAll removes all sessions from the memory data store.
 // It locks the mutex to ensure thread safety while accessing the data.
 // After deleting all sessions, it initializes the data map with a new empty map.
@@ -0,0 +1,121 @@
+package dao
+
+import (
+	"errors"
+	"sort"
+	"sync"
+	"vendepass/internal/models"
+
+	"github.com/google/uuid"
+)
+
+// MemoryWaitlistDAO keeps the clients waiting for seats on full flights, indexed by the entry's ID.
+type MemoryWaitlistDAO struct {
+	data map[uuid.UUID]*models.WaitlistEntry
+	mu   sync.RWMutex
+}
+
+// New initializes the MemoryWaitlistDAO by creating a new map to store the entries.
+func (dao *MemoryWaitlistDAO) New() {
+	dao.mu.Lock()
+	defer dao.mu.Unlock()
+
+	dao.data = make(map[uuid.UUID]*models.WaitlistEntry)
+}
+
+// Insert adds an entry to the waitlist of its flight, giving it a new ID.
+//
+// Parameters:
+//   - t: A pointer to the entry to be added.
+func (dao *MemoryWaitlistDAO) Insert(t *models.WaitlistEntry) {
+	dao.mu.Lock()
+	defer dao.mu.Unlock()
+
+	t.Id = uuid.New()
+	dao.data[t.Id] = t
+}
+
+// Delete removes an entry from the waitlist.
+//
+// Parameters:
+//   - t: A pointer to the entry to be removed.
+//
+// Return:
+//   - true if the entry was still on the waitlist, false if it had already been removed.
+func (dao *MemoryWaitlistDAO) Delete(t *models.WaitlistEntry) bool {
+	dao.mu.Lock()
+	defer dao.mu.Unlock()
+
+	if _, exists := dao.data[t.Id]; !exists {
+		return false
+	}
+	delete(dao.data, t.Id)
+	return true
+}
+
+// FindById retrieves an entry by its ID.
+//
+// Parameters:
+//   - id: The ID of the entry.
+//
+// Return:
+//   - A pointer to the entry, or nil.
+//   - An error if there is no entry with the ID.
+func (dao *MemoryWaitlistDAO) FindById(id uuid.UUID) (*models.WaitlistEntry, error) {
+	dao.mu.RLock()
+	defer dao.mu.RUnlock()
+
+	entry, exists := dao.data[id]
+	if !exists {
+		return nil, errors.New("waitlist entry not found")
+	}
+	return entry, nil
+}
+
+// FindByFlightId retrieves the waitlist of a flight, in the order the entries joined it.
+//
+// Parameters:
+//   - id: The ID of the flight.
+//
+// Return:
+//   - A slice of pointers to the entries of the flight. If there are none, an empty slice is returned.
+func (dao *MemoryWaitlistDAO) FindByFlightId(id uuid.UUID) []*models.WaitlistEntry {
+	return dao.find(func(entry *models.WaitlistEntry) bool { return entry.FlightId == id })
+}
+
+// FindByClientId retrieves the entries of a client, in the order they joined their waitlists.
+//
+// Parameters:
+//   - id: The ID of the client.
+//
+// Return:
+//   - A slice of pointers to the entries of the client. If there are none, an empty slice is returned.
+func (dao *MemoryWaitlistDAO) FindByClientId(id uuid.UUID) []*models.WaitlistEntry {
+	return dao.find(func(entry *models.WaitlistEntry) bool { return entry.ClientId == id })
+}
+
+// find returns the entries matching a filter, oldest first.
+func (dao *MemoryWaitlistDAO) find(match func(*models.WaitlistEntry) bool) []*models.WaitlistEntry {
+	dao.mu.RLock()
+	defer dao.mu.RUnlock()
+
+	entries := []*models.WaitlistEntry{}
+	for _, entry := range dao.data {
+		if match(entry) {
+			entries = append(entries, entry)
+		}
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].CreatedAt.Before(entries[j].CreatedAt)
+	})
+	return entries
+}
+
+// DeleteAll removes every entry.
+func (dao *MemoryWaitlistDAO) DeleteAll() {
+	dao.mu.Lock()
+	defer dao.mu.Unlock()
+
+	dao.data = make(map[uuid.UUID]*models.WaitlistEntry)
+}
@@ -0,0 +1,348 @@
+// Package journal implements an append-only log of record changes with periodic snapshots.
+//
+// Every change is appended to the journal file as one JSON line and synced to disk before Append returns.
+// A snapshot stores the full state up to a sequence number; after it is written the journal is truncated,
+// so the log never grows past the changes made since the last snapshot. Recovering the state means
+// replaying the snapshot and then every journal entry with a higher sequence number.
+package journal
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/google/uuid"
+)
+
+// Operations recorded by an Entry.
+const (
+	// Put stores the record in Data under Id, replacing any previous version.
+	Put = "put"
+	// Delete removes the record stored under Id.
+	Delete = "delete"
+	// Clear removes every record of the kind.
+	Clear = "clear"
+)
+
+const (
+	journalFile  = "journal.log"
+	snapshotFile = "snapshot.json"
+)
+
+// Entry is a single change to a record. Entries hold the whole new state of the record,
+// so replaying the same entry more than once leaves the state unchanged.
+type Entry struct {
+	Seq  uint64
+	Op   string
+	Kind string
+	Id   uuid.UUID
+	Data json.RawMessage `json:",omitempty"`
+}
+
+// snapshot is the stored form of a snapshot: the state as a list of Put entries
+// and the sequence number of the last change it includes.
+type snapshot struct {
+	Seq     uint64
+	Entries []Entry
+}
+
+// Journal is an append-only log stored in a directory, together with its latest snapshot.
+type Journal struct {
+	dir     string
+	mu      sync.Mutex
+	file    *os.File
+	seq     uint64
+	pending int
+	fresh   bool
+}
+
+// Open opens, or creates, the journal stored in dir.
+//
+// Parameters:
+//   - dir: The directory holding the journal and snapshot files. It is created if missing.
+//
+// Return:
+//   - A pointer to the opened journal.
+//   - An error if the directory or the files could not be opened or the existing entries could not be read.
+func Open(dir string) (*Journal, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+
+	j := &Journal{dir: dir}
+
+	last, snapshotFound, err := j.readSnapshot()
+	if err != nil {
+		return nil, err
+	}
+	j.seq = last.Seq
+
+	entries, valid, err := j.readJournal()
+	if err != nil {
+		return nil, err
+	}
+	for _, entry := range entries {
+		if entry.Seq > j.seq {
+			j.seq = entry.Seq
+			j.pending++
+		}
+	}
+
+	j.fresh = !snapshotFound && len(entries) == 0
+
+	j.file, err = os.OpenFile(filepath.Join(dir, journalFile), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, err
+	}
+
+	// Drop a torn last line, so new entries do not end up glued to it.
+	if err := j.file.Truncate(valid); err != nil {
+		j.file.Close()
+		return nil, err
+	}
+
+	return j, nil
+}
+
+// Fresh reports whether the journal had neither a snapshot nor any entry when it was opened.
+func (j *Journal) Fresh() bool {
+	return j.fresh
+}
+
+// Pending returns the number of entries appended since the last snapshot.
+func (j *Journal) Pending() int {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.pending
+}
+
+// Append records a change and syncs it to disk.
+//
+// Parameters:
+//   - op: Put, Delete or Clear.
+//   - kind: The kind of record, such as "flight".
+//   - id: The ID of the record. Ignored by Clear.
+//   - v: The new state of the record, marshalled into JSON. Only used by Put.
+//
+// Return:
+//   - An error if the record could not be marshalled or written.
+func (j *Journal) Append(op string, kind string, id uuid.UUID, v interface{}) error {
+	return j.write(Entry{Op: op, Kind: kind, Id: id}, func() ([]byte, error) {
+		return json.Marshal(v)
+	})
+}
+
+// Put records the new state of a record and syncs it to disk. The state is taken by snapshot while the journal's
+// lock is held, so when a record changes twice at once, the entry appended last holds the state taken last,
+// and replaying the journal never restores an older state of the record.
+//
+// Parameters:
+//   - kind: The kind of record, such as "flight".
+//   - id: The ID of the record.
+//   - snapshot: A function returning the current state of the record, marshalled into JSON.
+//     It must not append to the journal.
+//
+// Return:
+//   - An error if the snapshot failed or the entry could not be written.
+func (j *Journal) Put(kind string, id uuid.UUID, snapshot func() ([]byte, error)) error {
+	return j.write(Entry{Op: Put, Kind: kind, Id: id}, snapshot)
+}
+
+// write appends an entry to the journal and syncs it to disk, taking the state of a Put entry from snapshot
+// under the journal's lock.
+func (j *Journal) write(entry Entry, snapshot func() ([]byte, error)) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if entry.Op == Put {
+		data, err := snapshot()
+		if err != nil {
+			return err
+		}
+		entry.Data = data
+	}
+
+	entry.Seq = j.seq + 1
+
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	if _, err := j.file.Write(append(line, '\n')); err != nil {
+		return err
+	}
+	if err := j.file.Sync(); err != nil {
+		return err
+	}
+
+	j.seq = entry.Seq
+	j.pending++
+	return nil
+}
+
+// Replay calls fn, in order, with every entry of the given kind found in the snapshot and in the journal.
+//
+// Parameters:
+//   - kind: The kind of record to be replayed.
+//   - fn: The function that applies an entry to the state being rebuilt.
+//
+// Return:
+//   - An error if the files could not be read or fn failed.
+func (j *Journal) Replay(kind string, fn func(Entry) error) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	last, _, err := j.readSnapshot()
+	if err != nil {
+		return err
+	}
+
+	entries, _, err := j.readJournal()
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range last.Entries {
+		if entry.Kind != kind {
+			continue
+		}
+		if err := fn(entry); err != nil {
+			return err
+		}
+	}
+
+	for _, entry := range entries {
+		if entry.Kind != kind || entry.Seq <= last.Seq {
+			continue
+		}
+		if err := fn(entry); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Compact writes a snapshot of the current state and truncates the journal.
+// No entry can be appended while the snapshot is taken, so collect sees every change already journaled.
+//
+// Parameters:
+//   - collect: A function returning the whole current state as Put entries.
+//
+// Return:
+//   - An error if the snapshot could not be written or the journal could not be truncated.
+func (j *Journal) Compact(collect func() []Entry) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	state := snapshot{Seq: j.seq, Entries: collect()}
+	for i := range state.Entries {
+		state.Entries[i].Op = Put
+		state.Entries[i].Seq = j.seq
+	}
+
+	data, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+
+	tmpPath := filepath.Join(j.dir, snapshotFile+".tmp")
+	tmp, err := os.Create(tmpPath)
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	// Once the snapshot is in place the journal entries are redundant, since replaying
+	// skips every entry whose sequence number the snapshot already covers.
+	if err := os.Rename(tmpPath, filepath.Join(j.dir, snapshotFile)); err != nil {
+		return err
+	}
+
+	if err := j.file.Truncate(0); err != nil {
+		return err
+	}
+	if err := j.file.Sync(); err != nil {
+		return err
+	}
+
+	j.pending = 0
+	return nil
+}
+
+// Close closes the journal file.
+func (j *Journal) Close() error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.file.Close()
+}
+
+// readSnapshot reads the latest snapshot and reports whether one exists.
+func (j *Journal) readSnapshot() (snapshot, bool, error) {
+	var last snapshot
+
+	data, err := os.ReadFile(filepath.Join(j.dir, snapshotFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return last, false, nil
+	}
+	if err != nil {
+		return last, false, err
+	}
+
+	if err := json.Unmarshal(data, &last); err != nil {
+		return last, false, err
+	}
+
+	return last, true, nil
+}
+
+// readJournal reads every entry of the journal and returns them together with the length of the
+// valid part of the file. A malformed last line is the result of a crash in the middle of an append,
+// whose change was never acknowledged, so it is left out.
+func (j *Journal) readJournal() ([]Entry, int64, error) {
+	data, err := os.ReadFile(filepath.Join(j.dir, journalFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, 0, nil
+	}
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var entries []Entry
+	var valid int64
+
+	for len(data) > 0 {
+		end := bytes.IndexByte(data, '\n')
+		if end < 0 {
+			break
+		}
+
+		var entry Entry
+		if err := json.Unmarshal(data[:end], &entry); err != nil {
+			if bytes.IndexByte(data[end+1:], '\n') < 0 {
+				break
+			}
+			return nil, 0, err
+		}
+
+		entries = append(entries, entry)
+		valid += int64(end + 1)
+		data = data[end+1:]
+	}
+
+	return entries, valid, nil
+}
@@ -0,0 +1,115 @@
+package models
+
+import (
+	"crypto/rand"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// BookingStatus is the state of a booking, following the state of its tickets.
+type BookingStatus string
+
+const (
+	BookingConfirmed          BookingStatus = "confirmed"           // every ticket is valid
+	BookingPartiallyCancelled BookingStatus = "partially-cancelled" // some tickets were cancelled
+	BookingCancelled          BookingStatus = "cancelled"           // every ticket was cancelled
+)
+
+// ReferenceLength is the number of characters of a booking reference.
+const ReferenceLength = 6
+
+// referenceAlphabet leaves out 0, O, 1 and I, which are easily mistaken for each other when read aloud.
+// Its 32 characters divide 256, so every character is equally likely to be drawn from a random byte.
+const referenceAlphabet = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+
+// Booking groups the tickets bought together in an order, e.g. the legs of a route, into an itinerary.
+// Its ID is the order ID shared by its tickets, and its reference is the short locator shown to the client,
+// unique among the bookings and given when the booking is stored.
+// Cancelled tickets are kept in the booking, with their IDs listed in Cancelled.
+type Booking struct {
+	Id        uuid.UUID
+	Reference string
+	ClientId  uuid.UUID
+	Tickets   []*Ticket
+	Cancelled []uuid.UUID
+	Status    BookingStatus
+	CreatedAt time.Time
+	TotalPaid []Price
+}
+
+// NewBooking creates a confirmed booking for the tickets of an order, totalling the prices paid for them.
+//
+// Parameters:
+//   - orderId: The order ID shared by the tickets, used as the booking ID.
+//   - clientId: The ID of the client who bought the tickets.
+//   - tickets: The tickets of the order.
+//
+// Return:
+//   - A pointer to the new booking.
+func NewBooking(orderId uuid.UUID, clientId uuid.UUID, tickets []*Ticket) *Booking {
+	prices := make([]Price, 0, len(tickets))
+	for _, ticket := range tickets {
+		prices = append(prices, ticket.Price)
+	}
+
+	return &Booking{
+		Id:        orderId,
+		ClientId:  clientId,
+		Tickets:   tickets,
+		Cancelled: []uuid.UUID{},
+		Status:    BookingConfirmed,
+		CreatedAt: time.Now(),
+		TotalPaid: Total(prices),
+	}
+}
+
+// TicketCancelled reports whether a ticket of the booking was cancelled.
+func (b *Booking) TicketCancelled(ticketId uuid.UUID) bool {
+	for _, id := range b.Cancelled {
+		if id == ticketId {
+			return true
+		}
+	}
+	return false
+}
+
+// CancelTicket records the cancellation of one of the booking's tickets and updates its status.
+//
+// Parameters:
+//   - ticketId: The ID of the cancelled ticket.
+//
+// Return:
+//   - false if the ticket is not in the booking or was already cancelled.
+func (b *Booking) CancelTicket(ticketId uuid.UUID) bool {
+	found := false
+	for _, ticket := range b.Tickets {
+		found = found || ticket.Id == ticketId
+	}
+	if !found || b.TicketCancelled(ticketId) {
+		return false
+	}
+
+	b.Cancelled = append(b.Cancelled, ticketId)
+	if len(b.Cancelled) == len(b.Tickets) {
+		b.Status = BookingCancelled
+	} else {
+		b.Status = BookingPartiallyCancelled
+	}
+	return true
+}
+
+// NewReference draws a random booking reference. It may collide with the reference of another booking,
+// so the DAO storing the booking must check it is unique.
+//
+// Return:
+//   - A string of ReferenceLength uppercase letters and digits.
+func NewReference() string {
+	b := make([]byte, ReferenceLength)
+	rand.Read(b)
+
+	for i := range b {
+		b[i] = referenceAlphabet[int(b[i])%len(referenceAlphabet)]
+	}
+	return string(b)
+}
@@ -0,0 +1,78 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	// ErrCabinNotSold is returned when a flight does not sell the requested cabin class.
+	ErrCabinNotSold = errors.New("cabin not sold on this flight")
+	// ErrSeatNotInCabin is returned when the chosen seat is not in the cabin class being reserved.
+	ErrSeatNotInCabin = errors.New("seat is not in the reserved cabin")
+)
+
+// Cabin is the inventory of a cabin class of a flight: the seats it sells, the ones left, and their fare.
+type Cabin struct {
+	Class    CabinClass
+	Seats    uint // seats left
+	Capacity uint
+	Fare     Fare
+
+	overbooked uint // seats held or sold beyond the capacity
+}
+
+// NewCabins checks the cabins of a new flight or schedule and fills their seats in.
+// Every cabin starts with all of its seats left. On aircraft with a seat map, each cabin must be on the map
+// and sells every seat the map has in it unless fewer seats are asked for.
+//
+// Parameters:
+//   - cabins: The cabins asked for, with the seats and fare of each one.
+//   - seatMap: The seat map of the aircraft, or nil.
+//
+// Return:
+//   - The cabins, ready to be sold.
+//   - The number of seats of all the cabins.
+//   - An error describing the first invalid cabin, or nil.
+func NewCabins(cabins []Cabin, seatMap *SeatMap) ([]Cabin, uint, error) {
+	built := make([]Cabin, 0, len(cabins))
+	seen := make(map[CabinClass]bool, len(cabins))
+	total := uint(0)
+
+	for _, cabin := range cabins {
+		if !cabin.Class.Valid() {
+			return nil, 0, fmt.Errorf("invalid cabin: %s", cabin.Class)
+		}
+		if seen[cabin.Class] {
+			return nil, 0, fmt.Errorf("the %s cabin is listed twice", cabin.Class)
+		}
+		seen[cabin.Class] = true
+
+		seats := cabin.Seats
+		if seatMap != nil {
+			onMap := seatMap.CabinSeats(cabin.Class)
+			if onMap == 0 {
+				return nil, 0, fmt.Errorf("the seat map has no %s cabin", cabin.Class)
+			}
+			if seats == 0 {
+				seats = onMap
+			}
+			if seats > onMap {
+				return nil, 0, fmt.Errorf("the %s cabin cannot sell more seats than its seat map has", cabin.Class)
+			}
+		}
+		if seats == 0 {
+			return nil, 0, fmt.Errorf("the %s cabin needs at least one seat", cabin.Class)
+		}
+
+		fare := cabin.Fare.WithDefaults()
+		if err := fare.Validate(); err != nil {
+			return nil, 0, fmt.Errorf("%s cabin: %w", cabin.Class, err)
+		}
+
+		built = append(built, Cabin{Class: cabin.Class, Seats: seats, Capacity: seats, Fare: fare})
+		total += seats
+	}
+
+	return built, total, nil
+}
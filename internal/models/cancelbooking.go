@@ -0,0 +1,9 @@
+package models
+
+import "github.com/google/uuid"
+
+// CancelBookingRequest names the booking to be cancelled by its ID or, when the ID is not given, by its reference.
+type CancelBookingRequest struct {
+	BookingId uuid.UUID
+	Reference string
+}
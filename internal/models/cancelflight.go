@@ -0,0 +1,8 @@
+package models
+
+import "github.com/google/uuid"
+
+type CancelFlightRequest struct {
+	FlightId uuid.UUID
+	Reason   string
+}
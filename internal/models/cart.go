@@ -0,0 +1,15 @@
+package models
+
+import (
+	"sync"
+
+	"github.com/google/uuid"
+)
+
+// Cart holds the reservations of a client. It belongs to the client rather than to a session,
+// so every session of the client sees and buys the same reservations.
+type Cart struct {
+	ClientID     uuid.UUID
+	Reservations map[uuid.UUID]Reservation
+	Mu           sync.RWMutex
+}
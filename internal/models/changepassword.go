@@ -0,0 +1,6 @@
+package models
+
+type ChangePasswordRequest struct {
+	CurrentPassword string
+	NewPassword     string
+}
@@ -1,9 +1,51 @@
 package models
 
+import (
+	"math"
+	"time"
+	_ "time/tzdata" // the server image has no zoneinfo of its own
+)
+
 type City struct {
 	Name      string  `json:"Name"`
 	State     string  `json:"State"`
 	Country   string  `json:"Country"`
 	Latitude  float32 `json:"Latitude"`
 	Longitude float32 `json:"Longitude"`
+	TimeZone  string  `json:"TimeZone,omitempty"`
+}
+
+// Location returns the time zone of the city, given by its IANA name.
+// Cities without a time zone, or with an unknown one, are in UTC.
+func (c City) Location() *time.Location {
+	if c.TimeZone == "" {
+		return time.UTC
+	}
+
+	location, err := time.LoadLocation(c.TimeZone)
+	if err != nil {
+		return time.UTC
+	}
+	return location
+}
+
+// EarthRadius is the mean radius of the Earth, in kilometers.
+const EarthRadius = 6371.0
+
+// DistanceTo returns the great-circle distance between the city and another one, in kilometers,
+// computed from their coordinates with the haversine formula.
+//
+// Parameters:
+//   - other: The city the distance is measured to.
+//
+// Return:
+//   - The distance between the two cities, in kilometers.
+func (c City) DistanceTo(other City) float64 {
+	lat1 := float64(c.Latitude) * math.Pi / 180
+	lat2 := float64(other.Latitude) * math.Pi / 180
+	dLat := lat2 - lat1
+	dLon := float64(other.Longitude-c.Longitude) * math.Pi / 180
+
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) + math.Cos(lat1)*math.Cos(lat2)*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return 2 * EarthRadius * math.Asin(math.Min(1, math.Sqrt(h)))
 }
@@ -9,5 +9,14 @@ type Client struct {
 	Name           string    `json:"Name"`
 	Username       string    `json:"Username"`
 	Password       string    `json:"Password"`
+	Role           Role      `json:"Role,omitempty"`
 	Client_flights []*Ticket `json:"Client_flights"`
 }
+
+// GetRole returns the role of the client. Clients stored before roles existed are customers.
+func (c *Client) GetRole() Role {
+	if c.Role == "" {
+		return RoleCustomer
+	}
+	return c.Role
+}
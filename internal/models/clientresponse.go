@@ -0,0 +1,25 @@
+package models
+
+import (
+	"github.com/google/uuid"
+)
+
+// ClientResponse is the view of a Client sent back to the users. It never carries the password.
+type ClientResponse struct {
+	Id             uuid.UUID `json:"Id"`
+	Name           string    `json:"Name"`
+	Username       string    `json:"Username"`
+	Role           Role      `json:"Role"`
+	Client_flights []*Ticket `json:"Client_flights"`
+}
+
+// NewClientResponse builds the view of the given client sent back to the users.
+func NewClientResponse(c *Client) ClientResponse {
+	return ClientResponse{
+		Id:             c.Id,
+		Name:           c.Name,
+		Username:       c.Username,
+		Role:           c.GetRole(),
+		Client_flights: c.Client_flights,
+	}
+}
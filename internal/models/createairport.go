@@ -0,0 +1,6 @@
+package models
+
+type CreateAirportRequest struct {
+	Name string
+	City City
+}
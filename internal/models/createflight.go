@@ -0,0 +1,13 @@
+package models
+
+import "github.com/google/uuid"
+
+type CreateFlightRequest struct {
+	SourceAirportId uuid.UUID
+	DestAirportId   uuid.UUID
+	Seats           uint
+	Fare            Fare
+	SeatMap         []CabinLayout // cabins of the aircraft; the flight has no seat map if empty
+	Cabins          []Cabin       // cabin classes sold, each with its seats and fare, in place of Seats and Fare
+	Overbooking     uint          // seats that can be sold beyond the capacity once the flight, or a cabin, is full
+}
@@ -0,0 +1,23 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type CreateScheduleRequest struct {
+	FlightNumber    string
+	SourceAirportId uuid.UUID
+	DestAirportId   uuid.UUID
+	DepartureTime   string
+	DurationMinutes uint
+	Weekdays        []time.Weekday
+	StartDate       string
+	EndDate         string
+	Seats           uint
+	Fare            Fare
+	SeatMap         []CabinLayout
+	Cabins          []Cabin
+	Overbooking     uint
+}
@@ -0,0 +1,11 @@
+package models
+
+import "github.com/google/uuid"
+
+// DecommissionAirportRequest removes an airport. An airport still served by active flights is only
+// removed when CancelFlights is set, in which case those flights are cancelled first.
+type DecommissionAirportRequest struct {
+	AirportId     uuid.UUID
+	CancelFlights bool
+	Reason        string
+}
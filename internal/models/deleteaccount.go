@@ -0,0 +1,5 @@
+package models
+
+type DeleteAccountRequest struct {
+	Password string
+}
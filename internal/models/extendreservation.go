@@ -0,0 +1,7 @@
+package models
+
+import "github.com/google/uuid"
+
+type ExtendReservationRequest struct {
+	ReservationId uuid.UUID
+}
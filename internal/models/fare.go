@@ -0,0 +1,116 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+)
+
+const (
+	// DefaultFareClass is the only fare class sold on flights whose fare lists no classes.
+	DefaultFareClass = "standard"
+	// DefaultCurrency is the currency of fares that do not name one.
+	DefaultCurrency = "BRL"
+)
+
+// ErrFareClassNotSold is returned when a flight does not sell the requested fare class.
+var ErrFareClassNotSold = errors.New("fare class not sold on this flight")
+
+// Price is an amount of money in the minor unit of its currency, such as cents of BRL.
+type Price struct {
+	Amount   int64
+	Currency string
+}
+
+// String formats the price with two decimal places, as "BRL 450.00".
+func (p Price) String() string {
+	return fmt.Sprintf("%s %d.%02d", p.Currency, p.Amount/100, p.Amount%100)
+}
+
+// FareClass is a class of fare sold on a flight, priced as a markup over the flight's base price.
+type FareClass struct {
+	Code   string
+	Markup uint // percent added to the base price
+}
+
+// Fare is the pricing of a flight: a base price, in the minor unit of its currency, and the fare classes sold.
+type Fare struct {
+	BasePrice int64
+	Currency  string
+	Classes   []FareClass
+}
+
+// WithDefaults returns the fare in DefaultCurrency if it does not name a currency.
+func (f Fare) WithDefaults() Fare {
+	if f.Currency == "" {
+		f.Currency = DefaultCurrency
+	}
+	return f
+}
+
+// Validate checks that the fare can be sold.
+func (f Fare) Validate() error {
+	if f.BasePrice < 0 {
+		return errors.New("the base price cannot be negative")
+	}
+	if len(f.Currency) != 3 {
+		return errors.New("the currency must be an ISO 4217 code")
+	}
+
+	seen := make(map[string]bool, len(f.Classes))
+	for _, class := range f.Classes {
+		if class.Code == "" || seen[class.Code] {
+			return errors.New("fare classes need distinct codes")
+		}
+		seen[class.Code] = true
+	}
+	return nil
+}
+
+// Quote returns the price of a fare class. An empty code quotes the first class sold.
+//
+// Parameters:
+//   - code: The code of the fare class.
+//
+// Return:
+//   - The price of the class, and the code of the class quoted.
+//   - ErrFareClassNotSold if the flight does not sell the class.
+func (f Fare) Quote(code string) (Price, string, error) {
+	classes := f.Classes
+	if len(classes) == 0 {
+		classes = []FareClass{{Code: DefaultFareClass}}
+	}
+	if code == "" {
+		code = classes[0].Code
+	}
+
+	for _, class := range classes {
+		if class.Code == code {
+			amount := f.BasePrice + f.BasePrice*int64(class.Markup)/100
+			return Price{Amount: amount, Currency: f.Currency}, code, nil
+		}
+	}
+	return Price{}, "", ErrFareClassNotSold
+}
+
+// Total adds prices up by currency.
+//
+// Parameters:
+//   - prices: The prices to be added.
+//
+// Return:
+//   - One price per currency, in the order the currencies first appear.
+func Total(prices []Price) []Price {
+	totals := []Price{}
+	index := make(map[string]int)
+
+	for _, price := range prices {
+		i, exists := index[price.Currency]
+		if !exists {
+			i = len(totals)
+			index[price.Currency] = i
+			totals = append(totals, Price{Currency: price.Currency})
+		}
+		totals[i].Amount += price.Amount
+	}
+	return totals
+}
@@ -0,0 +1,6 @@
+package models
+
+type FindBookingRequest struct {
+	LastName  string
+	Reference string
+}
@@ -9,72 +9,600 @@ import (
 	"github.com/google/uuid"
 )
 
+// FlightJSON is the stored form of a Flight.
+// Seats does not count the seats held by reservations, since reservations live in the
+// clients' carts and do not survive a restart of the server.
 type FlightJSON struct {
 	Id              uuid.UUID
+	Number          string
+	ScheduleId      uuid.UUID
 	SourceAirportId uuid.UUID
 	DestAirportId   uuid.UUID
+	Departure       time.Time
+	Arrival         time.Time
 	Passengers      []*Ticket
 	Seats           uint
+	Capacity        uint
+	Fare            Fare
+	Cabins          []Cabin
+	SeatMap         *SeatMap
+	Overbooking     uint
+	Cancelled       bool
 }
 
+// ErrFlightCancelled is returned for reservations of a flight that was cancelled.
+var ErrFlightCancelled = errors.New("flight cancelled")
+
+// Flight is a single flight between two airports. Flights generated by a Schedule are dated:
+// they have a number and depart and arrive at given times, in the time zones of their airports.
+// Flights without a departure time are not bound to a date.
+// Flights with a seat map let clients choose their seats; Seats still counts every seat left,
+// since a reservation does not have to choose one.
+// Flights with cabins sell each cabin class out of its own seats and fare, and their Seats and Capacity
+// add the cabins up. Flights without cabins sell every seat in economy, out of their own Seats and Fare.
+// Once a cabin, or a flight without cabins, is full, up to Overbooking more seats can be sold across the flight;
+// they count as overbooked, not against Seats, and the first seats freed go to them.
 type Flight struct {
 	Id              uuid.UUID
+	Number          string
+	ScheduleId      uuid.UUID
 	SourceAirportId uuid.UUID
 	DestAirportId   uuid.UUID
+	Departure       time.Time
+	Arrival         time.Time
 	Passengers      []*Ticket
 	Seats           uint
-	Queue           chan *Session // Canal de fila para reservas
+	Capacity        uint
+	Fare            Fare
+	Cabins          []Cabin
+	SeatMap         *SeatMap
+	Overbooking     uint // seats that can be sold beyond the capacity
+	Cancelled       bool
+	Queue           chan *ReservationRequest // Canal de fila para reservas
 	Mu              sync.Mutex
+
+	stopped chan struct{} // fechado quando o voo é cancelado, encerrando a fila
+	running bool
+	taken   map[string]uuid.UUID // assentos ocupados por reservas ou bilhetes, com o ID do bilhete
+
+	overbooked uint // assentos reservados ou vendidos além da capacidade
+}
+
+// NewFlight builds a Flight from its stored form and creates its reservation queue.
+// Records written before the capacity was stored get it from the seats left plus the tickets sold.
+func NewFlight(f FlightJSON) *Flight {
+	capacity := f.Capacity
+	if capacity == 0 {
+		capacity = f.Seats + uint(len(f.Passengers))
+	}
+
+	flight := &Flight{
+		Id:              f.Id,
+		Number:          f.Number,
+		ScheduleId:      f.ScheduleId,
+		SourceAirportId: f.SourceAirportId,
+		DestAirportId:   f.DestAirportId,
+		Departure:       f.Departure,
+		Arrival:         f.Arrival,
+		Passengers:      f.Passengers,
+		Seats:           f.Seats,
+		Capacity:        capacity,
+		Fare:            f.Fare.WithDefaults(),
+		Cabins:          append([]Cabin(nil), f.Cabins...),
+		SeatMap:         f.SeatMap,
+		Overbooking:     f.Overbooking,
+		Cancelled:       f.Cancelled,
+	}
+	flight.InitQueue()
+
+	for _, ticket := range f.Passengers {
+		if ticket.Seat != "" {
+			flight.takeSeat(ticket.Seat, ticket.Id)
+		}
+	}
+
+	if len(flight.Cabins) == 0 && uint(len(f.Passengers)) > capacity {
+		flight.overbooked = uint(len(f.Passengers)) - capacity
+	}
+	for i := range flight.Cabins {
+		cabin := &flight.Cabins[i]
+		sold := uint(0)
+		for _, ticket := range f.Passengers {
+			if ticket.Cabin == cabin.Class {
+				sold++
+			}
+		}
+		if sold > cabin.Capacity {
+			cabin.overbooked = sold - cabin.Capacity
+			flight.overbooked += cabin.overbooked
+		}
+	}
+
+	return flight
+}
+
+// InitQueue creates the reservation queue of the flight. It must be called once, before the flight is shared.
+// The queue of a cancelled flight starts stopped.
+func (f *Flight) InitQueue() {
+	f.Queue = make(chan *ReservationRequest, 10)
+	f.stopped = make(chan struct{})
+	if f.Cancelled {
+		close(f.stopped)
+	}
+}
+
+// ToJSON returns the stored form of the flight. The caller must not hold the flight's lock.
+func (f *Flight) ToJSON() FlightJSON {
+	f.Mu.Lock()
+	defer f.Mu.Unlock()
+
+	var seats uint
+	if f.Capacity > uint(len(f.Passengers)) {
+		seats = f.Capacity - uint(len(f.Passengers))
+	}
+
+	var cabins []Cabin
+	for _, cabin := range f.Cabins {
+		sold := uint(0)
+		for _, ticket := range f.Passengers {
+			if ticket.Cabin == cabin.Class {
+				sold++
+			}
+		}
+		cabin.Seats = 0
+		if cabin.Capacity > sold {
+			cabin.Seats = cabin.Capacity - sold
+		}
+		cabins = append(cabins, cabin)
+	}
+
+	return FlightJSON{
+		Id:              f.Id,
+		Number:          f.Number,
+		ScheduleId:      f.ScheduleId,
+		SourceAirportId: f.SourceAirportId,
+		DestAirportId:   f.DestAirportId,
+		Departure:       f.Departure,
+		Arrival:         f.Arrival,
+		Passengers:      append([]*Ticket(nil), f.Passengers...),
+		Seats:           seats,
+		Capacity:        f.Capacity,
+		Fare:            f.Fare,
+		Cabins:          cabins,
+		SeatMap:         f.SeatMap,
+		Overbooking:     f.Overbooking,
+		Cancelled:       f.Cancelled,
+	}
+}
+
+// Dated reports whether the flight departs at a given time, rather than being open-dated.
+func (f *Flight) Dated() bool {
+	return !f.Departure.IsZero()
+}
+
+// DepartsOn reports whether the flight departs on a calendar day, in the local time of its source airport.
+// Flights that are not dated depart on any day.
+func (f *Flight) DepartsOn(day time.Time) bool {
+	if !f.Dated() {
+		return true
+	}
+	return f.Departure.Format(DateLayout) == day.Format(DateLayout)
+}
+
+// Departed reports whether a dated flight has already departed at the given time.
+func (f *Flight) Departed(now time.Time) bool {
+	return f.Dated() && !f.Departure.After(now)
+}
+
+// Cabin returns the inventory of a cabin class of the flight. An empty class is the first cabin the flight sells.
+// The caller must hold the flight's lock.
+//
+// Parameters:
+//   - class: The cabin class, or empty for the first cabin.
+//
+// Return:
+//   - A pointer to the cabin, or nil for flights without cabins, which sell every seat in economy.
+//   - ErrCabinNotSold if the flight does not sell the class.
+func (f *Flight) Cabin(class CabinClass) (*Cabin, error) {
+	if len(f.Cabins) == 0 {
+		if class != "" && class != CabinEconomy {
+			return nil, ErrCabinNotSold
+		}
+		return nil, nil
+	}
+
+	if class == "" {
+		return &f.Cabins[0], nil
+	}
+	for i := range f.Cabins {
+		if f.Cabins[i].Class == class {
+			return &f.Cabins[i], nil
+		}
+	}
+	return nil, ErrCabinNotSold
+}
+
+// SeatsLeft returns the seats left in a cabin class, or in any cabin if the class is empty.
+// The caller must hold the flight's lock.
+func (f *Flight) SeatsLeft(class CabinClass) uint {
+	if class == "" {
+		return f.Seats
+	}
+
+	cabin, err := f.Cabin(class)
+	if err != nil {
+		return 0
+	}
+	if cabin == nil {
+		return f.Seats
+	}
+	return cabin.Seats
+}
+
+// Bookable reports whether a number of seats can be reserved in a cabin class, counting the seats left in the cabin
+// and those that can still be overbooked. The caller must hold the flight's lock.
+//
+// Parameters:
+//   - class: The cabin class, or empty for the first cabin the flight sells.
+//   - count: The number of seats.
+func (f *Flight) Bookable(class CabinClass, count uint) bool {
+	cabin, err := f.Cabin(class)
+	if err != nil {
+		return false
+	}
+
+	left := f.Seats
+	if cabin != nil {
+		left = cabin.Seats
+	}
+	return left+f.overbookingLeft() >= count
+}
+
+// Overbooked returns the number of seats held or sold beyond the capacity. The caller must hold the flight's lock.
+func (f *Flight) Overbooked() uint {
+	return f.overbooked
+}
+
+// overbookingLeft returns the number of seats that can still be sold beyond the capacity. The caller must hold the flight's lock.
+func (f *Flight) overbookingLeft() uint {
+	if f.overbooked >= f.Overbooking {
+		return 0
+	}
+	return f.Overbooking - f.overbooked
+}
+
+// Quote returns the base price of a fare class in a cabin, from the cabin's fare or, on flights without cabins,
+// from the flight's. The caller must hold the flight's lock.
+//
+// Parameters:
+//   - class: The cabin class, or empty for the first cabin the flight sells.
+//   - fareClass: The code of the fare class, or empty for the first class the cabin sells.
+//
+// Return:
+//   - The price of the fare class, its code and the cabin class quoted, which is empty for flights without cabins.
+//   - ErrCabinNotSold or ErrFareClassNotSold if the flight does not sell the cabin or the fare class.
+func (f *Flight) Quote(class CabinClass, fareClass string) (Price, string, CabinClass, error) {
+	cabin, err := f.Cabin(class)
+	if err != nil {
+		return Price{}, "", "", err
+	}
+
+	fare := f.Fare
+	if cabin != nil {
+		fare = cabin.Fare
+		class = cabin.Class
+	} else {
+		class = ""
+	}
+
+	price, code, err := fare.Quote(fareClass)
+	if err != nil {
+		return Price{}, "", "", err
+	}
+	return price, code, class, nil
+}
+
+// AcceptReservation reserves a seat for a flight in a cabin and fare class and returns the ticket if successful.
+// If there are no seats available in the cabin, the cabin or fare class is not sold, or the chosen seat cannot be taken,
+// it returns an error. It is AcceptReservations for a single seat.
+//
+// Parameters:
+//   - class: The cabin class, or empty for the first cabin the flight sells.
+//   - fareClass: The code of the fare class, or empty for the first class the cabin sells.
+//   - seat: The code of the chosen seat, as "12A", or empty to leave the seat unassigned.
+func (f *Flight) AcceptReservation(class CabinClass, fareClass string, seat string) (*Ticket, error) {
+	tickets, err := f.AcceptReservations(class, fareClass, []string{seat})
+	if err != nil {
+		return nil, err
+	}
+	return tickets[0], nil
 }
 
-// AcceptReservation reserves a seat for a flight and returns the ticket if successful.
-// If there are no seats available, it returns an error.
+// AcceptReservations reserves several seats for a flight in a cabin and fare class and returns their tickets if successful.
+// The seats are reserved all together or not at all: if there are not enough seats available in the cabin, the cabin or
+// fare class is not sold, or any chosen seat cannot be taken, none is reserved and it returns an error.
 //
 // The function locks the Flight's mutex to ensure thread safety while processing the reservation.
-// It checks if there are any available seats by comparing the number of seats with the length of the passengers slice.
-// If there are seats available, it decrements the number of seats, creates a new Ticket, assigns the Flight's ID to the ticket,
-// appends the ticket to the passengers slice, and returns the ticket along with a nil error.
-// If there are no seats available, it returns nil and an error indicating that there are no seats available.
-func (f *Flight) AcceptReservation() (*Ticket, error) {
+// It quotes the fare class in the cabin, checks the chosen seats, and checks if there are enough available seats in the cabin,
+// overbooking it, within the flight's allowance, if it has too few. Only reservations choosing no seat can be overbooked.
+// If there are, it decrements the number of seats of the cabin and of the flight, creates a new Ticket for each seat,
+// assigns the Flight's ID, the cabin, the quoted price and the chosen seat to each ticket, and returns the tickets along with a nil error.
+// The tickets only join the passengers once they are bought, but their seats are taken right away.
+// If there are not enough seats available, it returns nil and an error indicating that there are no seats available.
+//
+// Parameters:
+//   - class: The cabin class, or empty for the first cabin the flight sells.
+//   - fareClass: The code of the fare class, or empty for the first class the cabin sells.
+//   - seats: The code of the seat chosen for each ticket, as "12A", or empty to leave that seat unassigned.
+func (f *Flight) AcceptReservations(class CabinClass, fareClass string, seats []string) ([]*Ticket, error) {
 	f.Mu.Lock()
 	defer f.Mu.Unlock()
 
-	if f.Seats > 0 {
-		f.Seats--
+	price, code, class, err := f.Quote(class, fareClass)
+	if err != nil {
+		return nil, err
+	}
+
+	chosen := make([]string, len(seats))
+	anyChosen := false
+	for i, seat := range seats {
+		if seat == "" {
+			continue
+		}
+		anyChosen = true
+		if f.SeatMap == nil {
+			return nil, ErrNoSeatMap
+		}
+		found, exists := f.SeatMap.Seat(seat)
+		if !exists {
+			return nil, ErrUnknownSeat
+		}
+		if class != "" && found.Cabin != class {
+			return nil, ErrSeatNotInCabin
+		}
+		if f.SeatTaken(found.Code) {
+			return nil, ErrSeatTaken
+		}
+		for _, other := range chosen[:i] {
+			if other == found.Code {
+				return nil, ErrSeatTaken
+			}
+		}
+		chosen[i] = found.Code
+	}
+
+	count := uint(len(seats))
+	cabin, _ := f.Cabin(class)
+	left := f.Seats
+	if cabin != nil {
+		left = cabin.Seats
+	}
+
+	regular, extra := count, uint(0)
+	if left < count {
+		regular, extra = left, count-left
+	}
+	if count == 0 || extra > f.overbookingLeft() || (extra > 0 && anyChosen) {
+		return nil, errors.New("no seats available")
+	}
+
+	f.Seats -= regular
+	f.overbooked += extra
+	if cabin != nil {
+		cabin.Seats -= regular
+		cabin.overbooked += extra
+	}
+
+	tickets := make([]*Ticket, len(chosen))
+	for i, seat := range chosen {
 		ticket := new(Ticket)
 		ticket.Id = uuid.New()
 		ticket.FlightId = f.Id
-		f.Passengers = append(f.Passengers, ticket)
-		return ticket, nil
+		ticket.Cabin = class
+		ticket.FareClass = code
+		ticket.Price = price
+		ticket.Seat = seat
+		if seat != "" {
+			f.takeSeat(seat, ticket.Id)
+		}
+		tickets[i] = ticket
+	}
+	return tickets, nil
+}
+
+// SeatTaken reports whether a seat is held by a reservation or sold. The caller must hold the flight's lock.
+func (f *Flight) SeatTaken(code string) bool {
+	_, taken := f.taken[code]
+	return taken
+}
+
+// ReleaseSeat returns the seat of a released reservation or cancelled ticket to the flight and to its cabin,
+// freeing the seat it had chosen, if any. While the cabin is overbooked, the seat goes to an overbooked passenger
+// instead of back on sale. The caller must hold the flight's lock.
+func (f *Flight) ReleaseSeat(ticket *Ticket) {
+	var cabin *Cabin
+	if ticket.Cabin != "" {
+		cabin, _ = f.Cabin(ticket.Cabin)
+	}
+
+	switch {
+	case cabin != nil && cabin.overbooked > 0:
+		cabin.overbooked--
+		f.overbooked--
+	case cabin == nil && len(f.Cabins) == 0 && f.overbooked > 0:
+		f.overbooked--
+	default:
+		f.Seats++
+		if cabin != nil {
+			cabin.Seats++
+		}
+	}
+	if ticket.Seat != "" && f.taken[ticket.Seat] == ticket.Id {
+		delete(f.taken, ticket.Seat)
+	}
+}
+
+// takeSeat marks a seat as taken by a ticket. The caller must hold the flight's lock.
+func (f *Flight) takeSeat(code string, ticketId uuid.UUID) {
+	if f.taken == nil {
+		f.taken = make(map[string]uuid.UUID)
+	}
+	f.taken[code] = ticketId
+}
+
+// Start runs the reservation queue of the flight in its own goroutine, unless it is already running
+// or the flight was cancelled.
+func (f *Flight) Start() {
+	if f.claimQueue() {
+		go f.processReservations()
 	}
-	return nil, errors.New("no seats available")
 }
 
-// ProcessReservations processes reservations for a flight.
-// It iterates over a queue of sessions and attempts to reserve a seat for each session.
-// If a seat is available, it creates a new ticket, assigns the client ID to the ticket,
-// and adds the reservation to the session's reservations map.
-// If no seats are available, it logs an error message.
+// Cancel marks the flight as cancelled and stops its reservation queue.
+// Requests still waiting in the queue, and every later one, fail with ErrFlightCancelled.
+//
+// Return:
+//   - false if the flight was already cancelled, true otherwise.
+func (f *Flight) Cancel() bool {
+	f.Mu.Lock()
+	defer f.Mu.Unlock()
+
+	if f.Cancelled {
+		return false
+	}
+
+	f.Cancelled = true
+	close(f.stopped)
+	return true
+}
+
+// Reserve sends a reservation request for a cart to the flight's queue and waits for its outcome.
+// Requests choosing the same seat are served one at a time by the queue, so only the first one gets it.
+//
+// Parameters:
+//   - cart: The cart the reservation is added to.
+//   - class: The cabin class of the reservation, or empty for the first cabin the flight sells.
+//   - fareClass: The fare class of the reservation, or empty for the first class the cabin sells.
+//   - seat: The code of the chosen seat, or empty to leave the seat unassigned.
+//   - price: The price charged for the reservation, already quoted to the client, or nil for the base price of the class.
+//
+// Return:
+//   - nil if the seat was reserved, the error of AcceptReservation if it was not,
+//     or ErrFlightCancelled if the flight was cancelled before the request was served.
+func (f *Flight) Reserve(cart *Cart, class CabinClass, fareClass string, seat string, price *Price) error {
+	return f.Submit(&ReservationRequest{Cart: cart, Cabin: class, FareClass: fareClass, Seat: seat, Price: price})
+}
+
+// Submit sends a reservation request to the flight's queue and waits for its outcome.
+// Requests for several passengers reserve all of their seats at once, or none of them.
+//
+// Parameters:
+//   - request: The reservation request. Its Result channel is created if it is not set.
+//
+// Return:
+//   - nil if the seats were reserved, the error of AcceptReservations if they were not,
+//     or ErrFlightCancelled if the flight was cancelled before the request was served.
+func (f *Flight) Submit(request *ReservationRequest) error {
+	if request.Result == nil {
+		request.Result = make(chan error, 1)
+	}
+
+	select {
+	case f.Queue <- request:
+	case <-f.stopped:
+		return ErrFlightCancelled
+	}
+
+	select {
+	case err := <-request.Result:
+		return err
+	case <-f.stopped:
+		// The request may have been served right before the queue stopped.
+		select {
+		case err := <-request.Result:
+			return err
+		default:
+			return ErrFlightCancelled
+		}
+	}
+}
+
+// claimQueue marks the queue as running and reports whether the caller should serve it.
+func (f *Flight) claimQueue() bool {
+	f.Mu.Lock()
+	defer f.Mu.Unlock()
+
+	if f.running || f.Cancelled {
+		return false
+	}
+	f.running = true
+	return true
+}
+
+// ProcessReservations serves the reservation queue of the flight until the flight is cancelled.
+// It does nothing if the queue is already served by another goroutine.
 func (f *Flight) ProcessReservations() {
-	for session := range f.Queue {
-		session.Mu.Lock()
-		ticket, err := f.AcceptReservation()
+	if f.claimQueue() {
+		f.processReservations()
+	}
+}
+
+// processReservations processes reservations for a flight.
+// It iterates over a queue of reservation requests and attempts to reserve a seat for each of them,
+// or one seat per passenger for requests naming passengers.
+// If the seats are available, it creates a new ticket for each one, assigns the client ID, the price of the request
+// and the passenger to the ticket, and adds the reservations to the client's cart, grouped under the request's GroupId,
+// before reporting success on the request's Result channel.
+// If no seats are available, the error is reported on the Result channel instead.
+// It returns when the flight is cancelled.
+func (f *Flight) processReservations() {
+	for {
+		var request *ReservationRequest
+		select {
+		case request = <-f.Queue:
+		case <-f.stopped:
+			return
+		}
+
+		cart := request.Cart
+		seats := []string{request.Seat}
+		if len(request.Passengers) > 0 {
+			seats = make([]string, len(request.Passengers))
+			copy(seats, request.Seats)
+		}
+
+		tickets, err := f.AcceptReservations(request.Cabin, request.FareClass, seats)
 		if err != nil {
-			fmt.Printf("Session %s: error reserving for flight %s - %s\n", session.ID, f.Id, err)
-			session.FailedReservations <- f.Id.String()
-		} else {
-			session.FailedReservations <- "success"
-			fmt.Println("session" + session.ID.String())
-			ticket.ClientId = session.ClientID
+			fmt.Printf("Client %s: error reserving for flight %s - %s\n", cart.ClientID, f.Id, err)
+			request.Result <- err
+			continue
+		}
+
+		now := time.Now()
+
+		cart.Mu.Lock()
+		for i, ticket := range tickets {
+			ticket.ClientId = cart.ClientID
+			if request.Price != nil {
+				ticket.Price = *request.Price
+			}
+			if len(request.Passengers) > 0 {
+				passenger := request.Passengers[i]
+				ticket.Passenger = &passenger
+			}
+
 			id := uuid.New()
-			session.Reservations[id] = Reservation{
+			cart.Reservations[id] = Reservation{
 				Id:        id,
-				CreatedAt: time.Now(),
+				CreatedAt: now,
+				ExpiresAt: request.ExpiresAt,
+				GroupId:   request.GroupId,
 				Ticket:    ticket,
 			}
-			fmt.Printf("Session %s: flight %s reserved successfully!\n", session.ID, f.Id)
 		}
-		fmt.Println(session.FailedReservations)
-		session.Mu.Unlock()
+		cart.Mu.Unlock()
+
+		fmt.Printf("Client %s: flight %s reserved successfully!\n", cart.ClientID, f.Id)
+		request.Result <- nil
 	}
 }
@@ -4,4 +4,13 @@ import "github.com/google/uuid"
 
 type FlightsRequest struct {
 	FlightIds []uuid.UUID
+	FareClass string       // fare class reserved on every flight; the first class each cabin sells if empty
+	Cabins    []CabinClass // cabin class reserved on each flight, in the order of FlightIds; the first cabin each flight sells if empty
+	QuoteIds  []uuid.UUID  // quotes whose prices are charged, if they have not expired
+	Seats     []string     // seat chosen on each flight, in the order of FlightIds; empty for no choice
+
+	// Passengers are the people flying, one seat each on every flight; the client alone if empty.
+	// PassengerSeats holds the seats chosen for each passenger, in the order of Passengers, on each flight, in the order of FlightIds.
+	Passengers     []Passenger
+	PassengerSeats [][]string
 }
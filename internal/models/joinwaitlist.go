@@ -0,0 +1,10 @@
+package models
+
+import "github.com/google/uuid"
+
+type JoinWaitlistRequest struct {
+	FlightId   uuid.UUID
+	Cabin      CabinClass // cabin waited for; the first cabin the flight sells if empty
+	FareClass  string
+	Passengers []Passenger
+}
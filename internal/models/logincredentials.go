@@ -3,4 +3,5 @@ package models
 type LoginCredentials struct {
 	Username string `json:"username"`
 	Password string `json:"password"`
+	Device   string `json:"device,omitempty"`
 }
@@ -0,0 +1,16 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Notification is a message left for a client about something that happened to its bookings
+// without its request, e.g. a cancelled flight.
+type Notification struct {
+	Id        uuid.UUID
+	ClientId  uuid.UUID
+	Message   string
+	CreatedAt time.Time
+}
@@ -0,0 +1,64 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// MaxPassengers is the largest number of passengers a single reservation request may name.
+const MaxPassengers = 9
+
+// Passenger is a person flying on a ticket bought by a client, who may be someone else than the client.
+type Passenger struct {
+	Name        string
+	Document    string
+	DateOfBirth string // as "2006-01-02"
+}
+
+// Validate checks that the passenger can fly.
+//
+// Return:
+//   - An error describing the first invalid field, or nil.
+func (p Passenger) Validate() error {
+	if strings.TrimSpace(p.Name) == "" {
+		return errors.New("a passenger needs a name")
+	}
+	if strings.TrimSpace(p.Document) == "" {
+		return fmt.Errorf("passenger %s needs a document", p.Name)
+	}
+	birth, err := time.Parse(DateLayout, p.DateOfBirth)
+	if err != nil || birth.After(time.Now()) {
+		return fmt.Errorf("invalid date of birth for passenger %s", p.Name)
+	}
+	return nil
+}
+
+// ValidatePassengers checks the passengers of a reservation request: each of them must be valid,
+// no more than MaxPassengers can be named, and no document can be named twice.
+//
+// Parameters:
+//   - passengers: The passengers named by the request.
+//
+// Return:
+//   - An error describing the first invalid passenger, or nil.
+func ValidatePassengers(passengers []Passenger) error {
+	if len(passengers) > MaxPassengers {
+		return fmt.Errorf("a reservation cannot have more than %d passengers", MaxPassengers)
+	}
+
+	documents := make(map[string]bool, len(passengers))
+	for _, passenger := range passengers {
+		if err := passenger.Validate(); err != nil {
+			return err
+		}
+
+		document := strings.ToUpper(strings.TrimSpace(passenger.Document))
+		if documents[document] {
+			return fmt.Errorf("passenger document listed twice: %s", passenger.Document)
+		}
+		documents[document] = true
+	}
+	return nil
+}
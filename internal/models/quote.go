@@ -0,0 +1,19 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Quote is the price of a fare class in a cabin of a flight offered to a client. Reserving the flight with
+// the quote before it expires charges the quoted price, whatever the price is by then.
+type Quote struct {
+	Id        uuid.UUID
+	ClientId  uuid.UUID
+	FlightId  uuid.UUID
+	Cabin     CabinClass
+	FareClass string
+	Price     Price
+	ExpiresAt time.Time
+}
@@ -0,0 +1,7 @@
+package models
+
+type RegisterRequest struct {
+	Name     string
+	Username string
+	Password string
+}
@@ -1,6 +1,7 @@
 package models
 
 type Request struct {
+	Id     string      `json:"Id"` // Identificador ecoado na resposta, permite respostas fora de ordem
 	Action string      `json:"Action"`
 	Auth   string      `json:"Auth"` // Modifique para aceitar nulos
 	Data   interface{} `json:"Data"`
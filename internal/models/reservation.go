@@ -6,8 +6,25 @@ import (
 	"github.com/google/uuid"
 )
 
+// Reservation is a seat held in a client's cart until it is bought, released, or its hold expires at ExpiresAt.
+// Reservations made together for several passengers share a GroupId and are bought as a single order.
+// Extensions counts the times the hold was extended.
 type Reservation struct {
-	Id        uuid.UUID
-	CreatedAt time.Time
+	Id         uuid.UUID
+	CreatedAt  time.Time
+	ExpiresAt  time.Time
+	Extensions uint
+	GroupId    uuid.UUID
 	*Ticket
 }
+
+// Expired reports whether the hold of the reservation has expired at the given time.
+//
+// Parameters:
+//   - now: The time to check the hold against.
+//
+// Return:
+//   - true if the reservation no longer holds its seat, false otherwise.
+func (r Reservation) Expired(now time.Time) bool {
+	return !now.Before(r.ExpiresAt)
+}
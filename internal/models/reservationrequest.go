@@ -0,0 +1,27 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ReservationRequest is an entry of a flight's reservation queue.
+// The flight adds the reservation, in Cabin and FareClass and in Seat if one was chosen, to Cart and reports the outcome on Result,
+// which must be buffered, so concurrent requests of the same client never read each other's outcome.
+// The reservation is charged Price if it is set, or the base price of the fare class otherwise.
+// A request naming Passengers reserves one seat for each of them, in the seat chosen for each one in Seats, if any,
+// and adds their reservations to Cart under GroupId.
+// The reservations hold their seats until ExpiresAt.
+type ReservationRequest struct {
+	Cart       *Cart
+	Cabin      CabinClass
+	FareClass  string
+	Seat       string
+	Passengers []Passenger
+	Seats      []string
+	GroupId    uuid.UUID
+	Price      *Price
+	ExpiresAt  time.Time
+	Result     chan error
+}
@@ -1,6 +1,7 @@
 package models
 
 type Response struct {
+	Id    string
 	Error string
 	Data  map[string]interface{}
 }
@@ -0,0 +1,30 @@
+package models
+
+// Role is the set of permissions of a client. Each role includes the permissions of the roles below it:
+// a customer books for itself, an agent also serves other customers and an admin manages the whole system.
+type Role string
+
+const (
+	RoleCustomer Role = "customer"
+	RoleAgent    Role = "agent"
+	RoleAdmin    Role = "admin"
+)
+
+// roleRanks orders the roles from the least to the most privileged.
+var roleRanks = map[Role]int{
+	RoleCustomer: 1,
+	RoleAgent:    2,
+	RoleAdmin:    3,
+}
+
+// Valid reports whether r is one of the known roles.
+func (r Role) Valid() bool {
+	_, exists := roleRanks[r]
+	return exists
+}
+
+// Allows reports whether a client with role r may run an action that requires the role required.
+// An empty required role is allowed to every client.
+func (r Role) Allows(required Role) bool {
+	return roleRanks[r] >= roleRanks[required]
+}
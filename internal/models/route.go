@@ -1,10 +1,16 @@
 package models
 
 import (
+	"time"
+
 	"github.com/google/uuid"
 )
 
 type Route struct {
-	Path     []City
-	FlightId uuid.UUID
+	Path      []City
+	FlightId  uuid.UUID
+	Number    string     `json:",omitempty"`
+	Departure *time.Time `json:",omitempty"`
+	Arrival   *time.Time `json:",omitempty"`
+	Quotes    []*Quote
 }
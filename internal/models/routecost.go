@@ -0,0 +1,36 @@
+package models
+
+import "github.com/google/uuid"
+
+// RouteSort is the cost a route search minimizes.
+type RouteSort string
+
+const (
+	// SortFlights finds the route with the fewest flights.
+	SortFlights RouteSort = ""
+	// SortDistance finds the route with the shortest great-circle distance flown.
+	SortDistance RouteSort = "distance"
+	// SortPrice finds the cheapest route.
+	SortPrice RouteSort = "price"
+	// SortDuration finds the route with the shortest travel time, layovers included.
+	SortDuration RouteSort = "duration"
+)
+
+// Valid reports whether the sort is one of the costs a route search can minimize.
+func (s RouteSort) Valid() bool {
+	switch s {
+	case SortFlights, SortDistance, SortPrice, SortDuration:
+		return true
+	}
+	return false
+}
+
+// RouteCost weighs the flights of a route for a shortest-path search.
+type RouteCost interface {
+	// Cost returns the cost of taking a flight after the previous flight of the route, nil for the first one.
+	// It must not be negative; a flight that cannot be weighed costs +Inf and is never taken.
+	Cost(previous *Flight, flight *Flight) float64
+	// Estimate returns a lower bound of the cost of reaching the destination from an airport,
+	// or 0 if there is none, which turns the search into Dijkstra's.
+	Estimate(airportId uuid.UUID) float64
+}
@@ -3,4 +3,7 @@ package models
 type RouteRequest struct {
 	Source string
 	Dest   string
+	Date   string     // travel date, as "2006-01-02"; any date if empty
+	Cabin  CabinClass // cabin class every flight of the route must have seats left in; any cabin if empty
+	Sort   RouteSort  // cost the route minimizes; the fewest flights if empty
 }
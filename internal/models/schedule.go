@@ -0,0 +1,145 @@
+package models
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// DateLayout is the layout of the travel dates exchanged with clients.
+const DateLayout = "2006-01-02"
+
+// clockLayout is the layout of the local departure time of a schedule.
+const clockLayout = "15:04"
+
+// Schedule is a recurring flight. Every day it operates on, it generates a dated instance of the
+// flight, departing at the same local time of the source airport.
+type Schedule struct {
+	Id              uuid.UUID
+	FlightNumber    string
+	SourceAirportId uuid.UUID
+	DestAirportId   uuid.UUID
+	DepartureTime   string         // local time at the source airport, as "15:04"
+	DurationMinutes uint           // time from departure to arrival
+	Weekdays        []time.Weekday // days the flight operates on; every day if empty
+	StartDate       string         // first day the flight operates on, as "2006-01-02"
+	EndDate         string         // last day the flight operates on, or empty if it has no end
+	Seats           uint
+	Fare            Fare     // fare of every flight generated
+	Cabins          []Cabin  // cabin classes of every flight generated, adding up to Seats, or none
+	SeatMap         *SeatMap // seat map of every flight generated, or nil
+	Overbooking     uint     // seats every flight generated can sell beyond its capacity
+}
+
+// Validate checks that the schedule can generate flights.
+//
+// Return:
+//   - An error describing the first invalid field, or nil.
+func (s *Schedule) Validate() error {
+	if s.FlightNumber == "" {
+		return errors.New("a schedule needs a flight number")
+	}
+	if s.Seats == 0 {
+		return errors.New("a flight needs at least one seat")
+	}
+	if s.SeatMap != nil && s.Seats > s.SeatMap.Capacity() {
+		return errors.New("a flight cannot sell more seats than its seat map has")
+	}
+	if len(s.Cabins) > 0 {
+		if _, _, err := NewCabins(s.Cabins, s.SeatMap); err != nil {
+			return err
+		}
+	}
+	if s.DurationMinutes == 0 {
+		return errors.New("a flight needs a duration")
+	}
+	if err := s.Fare.WithDefaults().Validate(); err != nil {
+		return err
+	}
+	if s.SourceAirportId == s.DestAirportId {
+		return errors.New("source and destination must be different airports")
+	}
+	if _, err := time.Parse(clockLayout, s.DepartureTime); err != nil {
+		return errors.New("invalid departure time")
+	}
+	start, err := time.Parse(DateLayout, s.StartDate)
+	if err != nil {
+		return errors.New("invalid start date")
+	}
+	if s.EndDate != "" {
+		end, err := time.Parse(DateLayout, s.EndDate)
+		if err != nil {
+			return errors.New("invalid end date")
+		}
+		if end.Before(start) {
+			return errors.New("end date is before the start date")
+		}
+	}
+	for _, weekday := range s.Weekdays {
+		if weekday < time.Sunday || weekday > time.Saturday {
+			return errors.New("invalid weekday")
+		}
+	}
+	return nil
+}
+
+// OperatesOn reports whether the schedule has a flight on the given calendar day.
+func (s *Schedule) OperatesOn(day time.Time) bool {
+	date := day.Format(DateLayout)
+	if date < s.StartDate || (s.EndDate != "" && date > s.EndDate) {
+		return false
+	}
+
+	if len(s.Weekdays) == 0 {
+		return true
+	}
+	for _, weekday := range s.Weekdays {
+		if weekday == day.Weekday() {
+			return true
+		}
+	}
+	return false
+}
+
+// InstanceId returns the ID of the flight the schedule generates on a calendar day.
+// It is derived from the schedule and the date, so a day's flight is only generated once.
+func (s *Schedule) InstanceId(day time.Time) uuid.UUID {
+	return uuid.NewSHA1(s.Id, []byte(day.Format(DateLayout)))
+}
+
+// Instance builds the flight the schedule generates on a calendar day.
+// The departure is given in the time zone of the source airport and the arrival in the one of the destination.
+//
+// Parameters:
+//   - day: The calendar day of the departure, in the source airport's local time.
+//   - from: The time zone of the source airport.
+//   - to: The time zone of the destination airport.
+//
+// Return:
+//   - The flight, with its reservation queue created but not started.
+func (s *Schedule) Instance(day time.Time, from, to *time.Location) *Flight {
+	clock, _ := time.Parse(clockLayout, s.DepartureTime)
+	departure := time.Date(day.Year(), day.Month(), day.Day(), clock.Hour(), clock.Minute(), 0, 0, from)
+	arrival := departure.Add(time.Duration(s.DurationMinutes) * time.Minute).In(to)
+
+	flight := &Flight{
+		Id:              s.InstanceId(day),
+		Number:          s.FlightNumber,
+		ScheduleId:      s.Id,
+		SourceAirportId: s.SourceAirportId,
+		DestAirportId:   s.DestAirportId,
+		Departure:       departure,
+		Arrival:         arrival,
+		Passengers:      []*Ticket{},
+		Seats:           s.Seats,
+		Capacity:        s.Seats,
+		Fare:            s.Fare.WithDefaults(),
+		Cabins:          append([]Cabin(nil), s.Cabins...),
+		SeatMap:         s.SeatMap,
+		Overbooking:     s.Overbooking,
+	}
+	flight.InitQueue()
+
+	return flight
+}
@@ -0,0 +1,179 @@
+package models
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// CabinClass is a cabin of an aircraft.
+type CabinClass string
+
+const (
+	CabinEconomy  CabinClass = "economy"
+	CabinPremium  CabinClass = "premium"
+	CabinBusiness CabinClass = "business"
+)
+
+// Valid reports whether the cabin is one of the known ones.
+func (c CabinClass) Valid() bool {
+	switch c {
+	case CabinEconomy, CabinPremium, CabinBusiness:
+		return true
+	}
+	return false
+}
+
+var (
+	// ErrNoSeatMap is returned when a seat is chosen on a flight without a seat map.
+	ErrNoSeatMap = errors.New("flight has no seat map")
+	// ErrUnknownSeat is returned when a seat is not on the seat map of the flight.
+	ErrUnknownSeat = errors.New("seat not found on this flight")
+	// ErrSeatTaken is returned when a seat is already held by a reservation or sold.
+	ErrSeatTaken = errors.New("seat already taken")
+)
+
+// CabinLayout describes the rows of a cabin. Letters lists the seats of every row from window to window,
+// with a space for each aisle, as "ABC DEF".
+type CabinLayout struct {
+	Cabin   CabinClass
+	Rows    uint
+	Letters string
+}
+
+// Seat is a seat of a flight's aircraft.
+type Seat struct {
+	Code   string // row and letter, as "12A"
+	Row    uint
+	Letter string
+	Cabin  CabinClass
+	Window bool
+	Aisle  bool
+}
+
+// SeatMap is the layout of the seats of a flight's aircraft, made of its cabins from the front to the back.
+// Rows are numbered from 1 across the cabins. Only the cabins are stored; the seats are built from them.
+type SeatMap struct {
+	Cabins []CabinLayout
+	seats  []Seat
+	index  map[string]int
+}
+
+// NewSeatMap builds the seat map of the given cabins.
+//
+// Parameters:
+//   - cabins: The layouts of the cabins, from the front to the back of the aircraft.
+//
+// Return:
+//   - A pointer to the seat map.
+//   - An error describing the first invalid cabin, or nil.
+func NewSeatMap(cabins []CabinLayout) (*SeatMap, error) {
+	m := &SeatMap{Cabins: cabins}
+	if err := m.build(); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// UnmarshalJSON decodes a stored seat map and builds its seats.
+func (m *SeatMap) UnmarshalJSON(b []byte) error {
+	var stored struct{ Cabins []CabinLayout }
+	if err := json.Unmarshal(b, &stored); err != nil {
+		return err
+	}
+
+	m.Cabins = stored.Cabins
+	return m.build()
+}
+
+// build lays the seats of every cabin out, checking the cabins on the way.
+func (m *SeatMap) build() error {
+	if len(m.Cabins) == 0 {
+		return errors.New("a seat map needs at least one cabin")
+	}
+
+	m.seats = nil
+	m.index = make(map[string]int)
+	row := uint(0)
+
+	for _, cabin := range m.Cabins {
+		if !cabin.Cabin.Valid() {
+			return fmt.Errorf("invalid cabin: %s", cabin.Cabin)
+		}
+		if cabin.Rows == 0 {
+			return fmt.Errorf("the %s cabin needs at least one row", cabin.Cabin)
+		}
+
+		letters := cabin.Letters
+		if letters == "" || strings.TrimSpace(letters) != letters || strings.Contains(letters, "  ") {
+			return fmt.Errorf("invalid seat letters in the %s cabin: %q", cabin.Cabin, letters)
+		}
+		seen := make(map[rune]bool)
+		for _, letter := range letters {
+			if letter == ' ' {
+				continue
+			}
+			if letter < 'A' || letter > 'Z' || seen[letter] {
+				return fmt.Errorf("invalid seat letters in the %s cabin: %q", cabin.Cabin, letters)
+			}
+			seen[letter] = true
+		}
+
+		for i := uint(0); i < cabin.Rows; i++ {
+			row++
+			for j, letter := range letters {
+				if letter == ' ' {
+					continue
+				}
+				seat := Seat{
+					Code:   fmt.Sprintf("%d%c", row, letter),
+					Row:    row,
+					Letter: string(letter),
+					Cabin:  cabin.Cabin,
+					Window: j == 0 || j == len(letters)-1,
+					Aisle:  (j > 0 && letters[j-1] == ' ') || (j < len(letters)-1 && letters[j+1] == ' '),
+				}
+				m.index[seat.Code] = len(m.seats)
+				m.seats = append(m.seats, seat)
+			}
+		}
+	}
+	return nil
+}
+
+// Seats returns every seat of the map, row by row.
+func (m *SeatMap) Seats() []Seat {
+	return m.seats
+}
+
+// Capacity returns the number of seats of the map.
+func (m *SeatMap) Capacity() uint {
+	return uint(len(m.seats))
+}
+
+// CabinSeats returns the number of seats of the map in a cabin class.
+func (m *SeatMap) CabinSeats(class CabinClass) uint {
+	seats := uint(0)
+	for _, seat := range m.seats {
+		if seat.Cabin == class {
+			seats++
+		}
+	}
+	return seats
+}
+
+// Seat looks a seat up by its code, in any letter case.
+//
+// Parameters:
+//   - code: The code of the seat, as "12A".
+//
+// Return:
+//   - The seat, and whether it is on the map.
+func (m *SeatMap) Seat(code string) (Seat, bool) {
+	i, exists := m.index[strings.ToUpper(strings.TrimSpace(code))]
+	if !exists {
+		return Seat{}, false
+	}
+	return m.seats[i], true
+}
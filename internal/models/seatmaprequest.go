@@ -0,0 +1,7 @@
+package models
+
+import "github.com/google/uuid"
+
+type SeatMapRequest struct {
+	FlightId uuid.UUID
+}
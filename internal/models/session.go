@@ -7,11 +7,13 @@ import (
 	"github.com/google/uuid"
 )
 
+// Session is a login of a client. A client may have several sessions at once, one per device,
+// all of them sharing the client's Cart.
 type Session struct {
-	ID                 uuid.UUID
-	ClientID           uuid.UUID
-	LastTimeActive     time.Time
-	Reservations       map[uuid.UUID]Reservation
-	FailedReservations chan string
-	Mu                 sync.RWMutex
+	ID             uuid.UUID
+	ClientID       uuid.UUID
+	Device         string
+	CreatedAt      time.Time
+	LastTimeActive time.Time
+	Mu             sync.RWMutex
 }
@@ -0,0 +1,6 @@
+package models
+
+type SetRoleRequest struct {
+	Username string
+	Role     Role
+}
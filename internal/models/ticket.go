@@ -4,8 +4,23 @@ import (
 	"github.com/google/uuid"
 )
 
+// Ticket is a seat on a flight, held by a reservation or bought by a client.
+// FareClass and Price are quoted when the seat is reserved and kept as they were,
+// so a ticket remembers the price paid even if the flight's fare changes later.
+// PaymentId is the payment intent that paid for a bought ticket, refunded if the ticket is canceled,
+// and OrderId groups the tickets bought together, e.g. the legs of a route.
+// Passenger is the person flying on the ticket when the client bought it for someone, or nil when the client flies.
+// Cabin is the cabin class the ticket flies in on flights with cabins, or empty on flights without them.
+// Seat is the code of the seat chosen on flights with a seat map, or empty if none was chosen.
 type Ticket struct {
-	Id       uuid.UUID
-	ClientId uuid.UUID
-	FlightId uuid.UUID
+	Id        uuid.UUID
+	ClientId  uuid.UUID
+	Passenger *Passenger `json:",omitempty"`
+	FlightId  uuid.UUID
+	Cabin     CabinClass
+	FareClass string
+	Price     Price
+	Seat      string
+	PaymentId uuid.UUID
+	OrderId   uuid.UUID
 }
@@ -0,0 +1,12 @@
+package models
+
+import "github.com/google/uuid"
+
+// UpdateAirportRequest renames or relocates an airport. Empty names and missing coordinates are left unchanged.
+type UpdateAirportRequest struct {
+	AirportId uuid.UUID
+	Name      string
+	CityName  string
+	Latitude  *float32
+	Longitude *float32
+}
@@ -0,0 +1,9 @@
+package models
+
+import "github.com/google/uuid"
+
+type UpdateFareRequest struct {
+	FlightId uuid.UUID
+	Cabin    CabinClass // cabin whose fare changes, needed on flights with cabins
+	Fare     Fare
+}
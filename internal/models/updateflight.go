@@ -0,0 +1,9 @@
+package models
+
+import "github.com/google/uuid"
+
+type UpdateFlightRequest struct {
+	FlightId uuid.UUID
+	Cabin    CabinClass // cabin whose capacity changes, needed on flights with cabins
+	Capacity uint
+}
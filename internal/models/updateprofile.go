@@ -0,0 +1,6 @@
+package models
+
+type UpdateProfileRequest struct {
+	Name     string
+	Username string
+}
@@ -0,0 +1,5 @@
+package models
+
+type UserTicketsRequest struct {
+	Username string
+}
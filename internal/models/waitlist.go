@@ -0,0 +1,27 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// WaitlistEntry is a client waiting for seats on a full flight. When seats are freed, the entries of the flight
+// are served in the order they joined, and each one served is turned into reservations in the client's cart.
+type WaitlistEntry struct {
+	Id         uuid.UUID
+	ClientId   uuid.UUID
+	FlightId   uuid.UUID
+	Cabin      CabinClass
+	FareClass  string
+	Passengers []Passenger // people waiting for a seat each; the client alone if empty
+	CreatedAt  time.Time
+}
+
+// Seats returns the number of seats the entry is waiting for.
+func (e *WaitlistEntry) Seats() uint {
+	if len(e.Passengers) == 0 {
+		return 1
+	}
+	return uint(len(e.Passengers))
+}
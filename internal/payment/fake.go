@@ -0,0 +1,133 @@
+package payment
+
+import (
+	"context"
+	"sync"
+	"time"
+	"vendepass/internal/models"
+
+	"github.com/google/uuid"
+)
+
+// Fake is a Provider keeping the intents in memory and charging no one, for tests and local runs.
+// It approves every capture unless told to decline them or to take longer than the caller waits.
+type Fake struct {
+	mu      sync.Mutex
+	intents map[uuid.UUID]*Intent
+	decline bool
+	latency time.Duration
+}
+
+// NewFake creates a fake provider approving every capture right away.
+func NewFake() *Fake {
+	return &Fake{intents: make(map[uuid.UUID]*Intent)}
+}
+
+// SetDecline makes the following captures be declined, or approved again.
+func (f *Fake) SetDecline(decline bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.decline = decline
+}
+
+// SetLatency makes the following captures take the given time to answer.
+func (f *Fake) SetLatency(latency time.Duration) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.latency = latency
+}
+
+// CreateIntent creates a pending intent for the amount.
+func (f *Fake) CreateIntent(clientId uuid.UUID, amount models.Price) (*Intent, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	intent := &Intent{Id: uuid.New(), ClientId: clientId, Amount: amount, Status: StatusPending, CreatedAt: time.Now()}
+	f.intents[intent.Id] = intent
+
+	created := *intent
+	return &created, nil
+}
+
+// Capture charges a pending intent after the configured latency, unless the context is done first.
+func (f *Fake) Capture(ctx context.Context, intentId uuid.UUID) error {
+	f.mu.Lock()
+	latency := f.latency
+	f.mu.Unlock()
+
+	select {
+	case <-time.After(latency):
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	intent, exists := f.intents[intentId]
+	if !exists {
+		return ErrIntentNotFound
+	}
+	if intent.Status != StatusPending {
+		return ErrInvalidStatus
+	}
+	if f.decline {
+		intent.Status = StatusFailed
+		return ErrDeclined
+	}
+
+	intent.Status = StatusCaptured
+	return nil
+}
+
+// Cancel abandons an intent that was not charged. Canceling a failed or canceled intent does nothing.
+func (f *Fake) Cancel(intentId uuid.UUID) error {
+	return f.transition(intentId, StatusCanceled, StatusPending, StatusFailed, StatusCanceled)
+}
+
+// Refund gives back the amount of a captured intent.
+func (f *Fake) Refund(intentId uuid.UUID) error {
+	return f.transition(intentId, StatusRefunded, StatusCaptured)
+}
+
+// Intent returns a copy of an intent, so tests can check how it ended.
+func (f *Fake) Intent(intentId uuid.UUID) (Intent, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	intent, exists := f.intents[intentId]
+	if !exists {
+		return Intent{}, ErrIntentNotFound
+	}
+	return *intent, nil
+}
+
+// Intents returns a copy of every intent created, in no particular order.
+func (f *Fake) Intents() []Intent {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	intents := make([]Intent, 0, len(f.intents))
+	for _, intent := range f.intents {
+		intents = append(intents, *intent)
+	}
+	return intents
+}
+
+// transition moves an intent to a status, if it is in one of the given ones.
+func (f *Fake) transition(intentId uuid.UUID, to Status, from ...Status) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	intent, exists := f.intents[intentId]
+	if !exists {
+		return ErrIntentNotFound
+	}
+	for _, status := range from {
+		if intent.Status == status {
+			intent.Status = to
+			return nil
+		}
+	}
+	return ErrInvalidStatus
+}
@@ -0,0 +1,52 @@
+// Package payment defines how the server charges clients for their tickets, through a payment provider.
+package payment
+
+import (
+	"context"
+	"errors"
+	"time"
+	"vendepass/internal/models"
+
+	"github.com/google/uuid"
+)
+
+// Status is the stage of a payment intent.
+type Status string
+
+const (
+	StatusPending  Status = "pending"  // created, not charged yet
+	StatusCaptured Status = "captured" // the client was charged
+	StatusFailed   Status = "failed"   // the charge was declined
+	StatusCanceled Status = "canceled" // abandoned before being charged
+	StatusRefunded Status = "refunded" // charged and given back
+)
+
+var (
+	// ErrDeclined is returned when the provider refuses to charge the client.
+	ErrDeclined = errors.New("payment declined")
+	// ErrIntentNotFound is returned for IDs the provider does not know.
+	ErrIntentNotFound = errors.New("payment intent not found")
+	// ErrInvalidStatus is returned when an intent is not in a stage allowing the operation.
+	ErrInvalidStatus = errors.New("payment intent in invalid status")
+)
+
+// Intent is a payment being made by a client: created at checkout, then captured when the client
+// is charged, or canceled if the charge cannot be completed.
+type Intent struct {
+	Id        uuid.UUID
+	ClientId  uuid.UUID
+	Amount    models.Price
+	Status    Status
+	CreatedAt time.Time
+}
+
+// Provider charges and refunds the clients. Implementations must be safe for concurrent use.
+//
+// Capture must give up when its context is done, so a slow provider cannot hold a checkout forever;
+// the caller then cancels the intent, so it is never charged late.
+type Provider interface {
+	CreateIntent(clientId uuid.UUID, amount models.Price) (*Intent, error)
+	Capture(ctx context.Context, intentId uuid.UUID) error
+	Cancel(intentId uuid.UUID) error
+	Refund(intentId uuid.UUID) error
+}
@@ -0,0 +1,139 @@
+// Package pricing implements the engines that adjust the fares of flights to their demand.
+package pricing
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"sort"
+	"time"
+	"vendepass/internal/models"
+)
+
+// Input is what an engine knows about a flight when pricing one of its fare classes.
+type Input struct {
+	Base       models.Price // price of the fare class before any adjustment
+	LoadFactor float64      // share of the flight's capacity already sold or held, from 0 to 1
+	// TimeToDeparture is the time left until the flight departs. It is only meaningful when Dated is set.
+	TimeToDeparture time.Duration
+	Dated           bool
+}
+
+// Engine prices a fare class of a flight. Implementations must be safe for concurrent use.
+type Engine interface {
+	Price(Input) models.Price
+}
+
+// Static is an Engine that always charges the base price.
+type Static struct{}
+
+// Price returns the base price of the input.
+func (Static) Price(in Input) models.Price {
+	return in.Base
+}
+
+// LoadStep adds a markup to flights whose load factor is at least From.
+type LoadStep struct {
+	From   float64
+	Markup int // percent added to the base price; negative for a discount
+}
+
+// TimeStep adds a markup to dated flights departing within the given number of hours.
+type TimeStep struct {
+	WithinHours uint
+	Markup      int // percent added to the base price; negative for a discount
+}
+
+// Rules is an Engine configured by steps of load factor and of time to departure.
+// The markup of the highest load step reached is added to the one of the closest time step reached,
+// and the sum, limited to MinMarkup and MaxMarkup, is applied to the base price.
+type Rules struct {
+	LoadFactor      []LoadStep
+	TimeToDeparture []TimeStep
+	MinMarkup       int // lowest total markup, in percent; -100, a free fare, if zero
+	MaxMarkup       int // highest total markup, in percent; no limit if zero
+}
+
+// LoadRules reads pricing rules from a JSON file.
+//
+// Parameters:
+//   - path: The path of the file.
+//
+// Return:
+//   - The rules, with their steps sorted.
+//   - An error if the file could not be read or holds invalid rules.
+func LoadRules(path string) (*Rules, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	rules := new(Rules)
+	if err := json.Unmarshal(b, rules); err != nil {
+		return nil, err
+	}
+	if err := rules.validate(); err != nil {
+		return nil, err
+	}
+
+	sort.Slice(rules.LoadFactor, func(i, j int) bool {
+		return rules.LoadFactor[i].From < rules.LoadFactor[j].From
+	})
+	sort.Slice(rules.TimeToDeparture, func(i, j int) bool {
+		return rules.TimeToDeparture[i].WithinHours < rules.TimeToDeparture[j].WithinHours
+	})
+
+	return rules, nil
+}
+
+// validate checks that the rules can never price a fare below zero.
+func (r *Rules) validate() error {
+	if r.MinMarkup < -100 {
+		return errors.New("the minimum markup cannot be below -100%")
+	}
+	if r.MaxMarkup != 0 && r.MinMarkup != 0 && r.MaxMarkup < r.MinMarkup {
+		return errors.New("the maximum markup is below the minimum one")
+	}
+	for _, step := range r.LoadFactor {
+		if step.From < 0 || step.From > 1 {
+			return errors.New("load factors go from 0 to 1")
+		}
+	}
+	return nil
+}
+
+// Price applies the markups of the steps the input reaches to its base price.
+// The steps must be sorted, as they are by LoadRules.
+func (r *Rules) Price(in Input) models.Price {
+	markup := 0
+
+	for _, step := range r.LoadFactor {
+		if in.LoadFactor >= step.From {
+			markup = step.Markup
+		}
+	}
+
+	if in.Dated {
+		for _, step := range r.TimeToDeparture {
+			if in.TimeToDeparture <= time.Duration(step.WithinHours)*time.Hour {
+				markup += step.Markup
+				break
+			}
+		}
+	}
+
+	floor := r.MinMarkup
+	if floor == 0 {
+		floor = -100
+	}
+	if markup < floor {
+		markup = floor
+	}
+	if r.MaxMarkup != 0 && markup > r.MaxMarkup {
+		markup = r.MaxMarkup
+	}
+
+	price := in.Base
+	price.Amount += price.Amount * int64(markup) / 100
+	return price
+}
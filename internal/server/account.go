@@ -0,0 +1,263 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"vendepass/internal/auth"
+	"vendepass/internal/dao"
+	"vendepass/internal/models"
+)
+
+const (
+	minPasswordLength = 8
+	maxPasswordLength = 72 // bcrypt ignores every byte past the 72nd
+)
+
+// usernamePattern lists the characters accepted in a username and its length limits.
+var usernamePattern = regexp.MustCompile(`^[a-z0-9._-]{3,32}$`)
+
+// validateUsername checks that a username is well formed.
+//
+// Parameters:
+//   - username: The username to be checked.
+//
+// Return:
+//   - An error describing the problem, or nil if the username is valid.
+func validateUsername(username string) error {
+	if !usernamePattern.MatchString(username) {
+		return errors.New("username must have 3 to 32 lowercase letters, digits, dots, dashes or underscores")
+	}
+	return nil
+}
+
+// hashNewPassword checks that a new password is long enough and can be hashed, and hashes it.
+//
+// Parameters:
+//   - password: The plain text password chosen by the client.
+//
+// Return:
+//   - The bcrypt hash of the password.
+//   - An error describing the problem, or nil if the password was accepted.
+func hashNewPassword(password string) (string, error) {
+	if len(password) < minPasswordLength || len(password) > maxPasswordLength {
+		return "", fmt.Errorf("password must have %d to %d characters", minPasswordLength, maxPasswordLength)
+	}
+
+	hash, err := auth.HashPassword(password)
+	if err != nil {
+		return "", errors.New("could not store password")
+	}
+	return hash, nil
+}
+
+// register creates a new client from the provided name, username and password.
+// The username must be valid and not used by any other client, and the password is stored hashed.
+// On success, the new client is sent back as a models.ClientResponse.
+//
+// Parameters:
+//   - data: An interface containing the request data. It should be of type models.RegisterRequest.
+//   - conn: A *RequestConn representing the connection to the client.
+func register(_ *models.Session, data interface{}, conn *RequestConn) {
+	var registerRequest models.RegisterRequest
+
+	jsonData, _ := json.Marshal(data)
+	json.Unmarshal(jsonData, &registerRequest)
+
+	registerRequest.Name = strings.TrimSpace(registerRequest.Name)
+	registerRequest.Username = strings.TrimSpace(registerRequest.Username)
+
+	if registerRequest.Name == "" {
+		WriteNewResponse(models.Response{
+			Error: "name is required",
+		}, conn)
+		return
+	}
+
+	if err := validateUsername(registerRequest.Username); err != nil {
+		WriteNewResponse(models.Response{
+			Error: err.Error(),
+		}, conn)
+		return
+	}
+
+	hash, err := hashNewPassword(registerRequest.Password)
+	if err != nil {
+		WriteNewResponse(models.Response{
+			Error: err.Error(),
+		}, conn)
+		return
+	}
+
+	client := &models.Client{
+		Name:           registerRequest.Name,
+		Username:       registerRequest.Username,
+		Password:       hash,
+		Role:           models.RoleCustomer,
+		Client_flights: []*models.Ticket{},
+	}
+
+	if err := dao.GetClientDAO().Insert(client); err != nil {
+		WriteNewResponse(models.Response{
+			Error: err.Error(),
+		}, conn)
+		return
+	}
+
+	WriteNewResponse(models.Response{
+		Data: map[string]interface{}{
+			"user": models.NewClientResponse(client),
+		},
+	}, conn)
+}
+
+// updateProfile changes the name and/or the username of the logged in client.
+// Empty fields are left unchanged. A new username must be valid and not used by any other client.
+//
+// Parameters:
+//   - session: The session of the client making the request, verified before the handler runs.
+//   - data: An interface containing the request data. It should be of type models.UpdateProfileRequest.
+//   - conn: A *RequestConn representing the connection to the client.
+func updateProfile(session *models.Session, data interface{}, conn *RequestConn) {
+	var updateRequest models.UpdateProfileRequest
+
+	jsonData, _ := json.Marshal(data)
+	json.Unmarshal(jsonData, &updateRequest)
+
+	name := strings.TrimSpace(updateRequest.Name)
+	username := strings.TrimSpace(updateRequest.Username)
+
+	if username != "" {
+		if err := validateUsername(username); err != nil {
+			WriteNewResponse(models.Response{
+				Error: err.Error(),
+			}, conn)
+			return
+		}
+	}
+
+	// Only the edited fields are changed on the stored client, so a ticket bought or cancelled meanwhile is kept.
+	updated, err := dao.GetClientDAO().Modify(session.ClientID, func(client *models.Client) error {
+		if name != "" {
+			client.Name = name
+		}
+		if username != "" {
+			client.Username = username
+		}
+		return nil
+	})
+	if err != nil {
+		WriteNewResponse(models.Response{
+			Error: err.Error(),
+		}, conn)
+		return
+	}
+
+	WriteNewResponse(models.Response{
+		Data: map[string]interface{}{
+			"user": models.NewClientResponse(updated),
+		},
+	}, conn)
+}
+
+// changePassword replaces the password of the logged in client after checking the current one.
+//
+// Parameters:
+//   - session: The session of the client making the request, verified before the handler runs.
+//   - data: An interface containing the request data. It should be of type models.ChangePasswordRequest.
+//   - conn: A *RequestConn representing the connection to the client.
+func changePassword(session *models.Session, data interface{}, conn *RequestConn) {
+	var changeRequest models.ChangePasswordRequest
+
+	jsonData, _ := json.Marshal(data)
+	json.Unmarshal(jsonData, &changeRequest)
+
+	client, err := dao.GetClientDAO().FindById(session.ClientID)
+	if err != nil {
+		WriteNewResponse(models.Response{
+			Error: "client not found",
+		}, conn)
+		return
+	}
+
+	if !passwordMatches(client, changeRequest.CurrentPassword) {
+		WriteNewResponse(models.Response{
+			Error: "invalid credentials",
+		}, conn)
+		return
+	}
+
+	hash, err := hashNewPassword(changeRequest.NewPassword)
+	if err != nil {
+		WriteNewResponse(models.Response{
+			Error: err.Error(),
+		}, conn)
+		return
+	}
+
+	_, err = dao.GetClientDAO().Modify(client.Id, func(client *models.Client) error {
+		client.Password = hash
+		return nil
+	})
+	if err != nil {
+		WriteNewResponse(models.Response{
+			Error: err.Error(),
+		}, conn)
+		return
+	}
+
+	WriteNewResponse(models.Response{
+		Data: map[string]interface{}{
+			"msg": "password changed successfully",
+		},
+	}, conn)
+}
+
+// deleteAccount removes the logged in client after checking their password.
+// Every session of the client is closed, its reserved seats are released
+// and the seats of its bought tickets are returned to the flights. Tickets of flights that have already departed
+// were flown, so they are neither refunded nor returned.
+//
+// Parameters:
+//   - session: The session of the client making the request, verified before the handler runs.
+//   - data: An interface containing the request data. It should be of type models.DeleteAccountRequest.
+//   - conn: A *RequestConn representing the connection to the client.
+func deleteAccount(session *models.Session, data interface{}, conn *RequestConn) {
+	var deleteRequest models.DeleteAccountRequest
+
+	jsonData, _ := json.Marshal(data)
+	json.Unmarshal(jsonData, &deleteRequest)
+
+	client, err := dao.GetClientDAO().FindById(session.ClientID)
+	if err != nil {
+		WriteNewResponse(models.Response{
+			Error: "client not found",
+		}, conn)
+		return
+	}
+
+	if !passwordMatches(client, deleteRequest.Password) {
+		WriteNewResponse(models.Response{
+			Error: "invalid credentials",
+		}, conn)
+		return
+	}
+
+	for _, s := range dao.GetSessionDAO().FindByClientId(client.Id) {
+		endSession(s)
+	}
+
+	for _, ticket := range append([]*models.Ticket(nil), client.Client_flights...) {
+		cancelTicket(client.Id, ticket.Id)
+	}
+
+	dao.GetClientDAO().Delete(*client)
+
+	WriteNewResponse(models.Response{
+		Data: map[string]interface{}{
+			"msg": "account deleted successfully",
+		},
+	}, conn)
+}
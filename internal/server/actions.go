@@ -0,0 +1,95 @@
+package server
+
+import (
+	"errors"
+	"vendepass/internal/dao"
+	"vendepass/internal/models"
+)
+
+// ErrForbidden is answered to requests of clients whose role does not allow the action.
+var ErrForbidden = errors.New("forbidden")
+
+// handlerFunc is the signature shared by every action handler. The session is the one of the
+// client making the request, already verified and authorized, or nil for anonymous actions.
+type handlerFunc func(session *models.Session, data interface{}, conn *RequestConn)
+
+// action describes how a request action is served.
+type action struct {
+	handler handlerFunc
+	// anonymous actions are served without a token; every other action requires a valid one.
+	anonymous bool
+	// role is the least privileged role allowed to run the action. Actions without a role are
+	// allowed to every logged in client.
+	role models.Role
+}
+
+// actions maps every action name accepted by the server to its handler and the permission it requires.
+var actions = map[string]action{
+	"login":              {handler: login, anonymous: true},
+	"register":           {handler: register, anonymous: true},
+	"find-booking":       {handler: FindBooking, anonymous: true},
+	"get-user":           {handler: getUserBySessionToken},
+	"logout":             {handler: logout},
+	"refresh-token":      {handler: refreshToken},
+	"sessions":           {handler: Sessions},
+	"revoke-session":     {handler: RevokeSession},
+	"update-profile":     {handler: updateProfile},
+	"change-password":    {handler: changePassword},
+	"delete-account":     {handler: deleteAccount},
+	"all-routes":         {handler: AllRoutes},
+	"route":              {handler: Route},
+	"flights":            {handler: Flights},
+	"seat-map":           {handler: SeatMap},
+	"reservation":        {handler: Reservation},
+	"cancel-reservation": {handler: CancelReservation},
+	"extend-reservation": {handler: ExtendReservation},
+	"cart":               {handler: GetCart},
+	"buy":                {handler: BuyTicket},
+	"checkout":           {handler: Checkout},
+	"cancel-buy":         {handler: CancelBuy},
+	"tickets":            {handler: GetTickets},
+	"cancel-booking":     {handler: CancelBooking},
+	"join-waitlist":      {handler: JoinWaitlist},
+	"leave-waitlist":     {handler: LeaveWaitlist},
+	"waitlist":           {handler: Waitlist},
+	"user-tickets":       {handler: GetUserTickets, role: models.RoleAdmin},
+	"notifications":      {handler: Notifications},
+	"set-role":           {handler: SetRole, role: models.RoleAdmin},
+
+	"admin-create-flight": {handler: AdminCreateFlight, role: models.RoleAdmin},
+	"admin-update-flight": {handler: AdminUpdateFlight, role: models.RoleAdmin},
+	"admin-update-fare":   {handler: AdminUpdateFare, role: models.RoleAdmin},
+	"admin-cancel-flight": {handler: AdminCancelFlight, role: models.RoleAdmin},
+
+	"admin-create-schedule": {handler: AdminCreateSchedule, role: models.RoleAdmin},
+	"admin-delete-schedule": {handler: AdminDeleteSchedule, role: models.RoleAdmin},
+
+	"admin-create-airport":       {handler: AdminCreateAirport, role: models.RoleAdmin},
+	"admin-update-airport":       {handler: AdminUpdateAirport, role: models.RoleAdmin},
+	"admin-decommission-airport": {handler: AdminDecommissionAirport, role: models.RoleAdmin},
+}
+
+// authorize checks that the client owning a session has a role allowed to run an action.
+// The role is read from the stored client on every request, so a role change applies at once.
+//
+// Parameters:
+//   - session: The verified session of the request.
+//   - required: The least privileged role allowed to run the action.
+//
+// Return:
+//   - ErrNotAuthorized if the client no longer exists, ErrForbidden if its role is not allowed, or nil.
+func authorize(session *models.Session, required models.Role) error {
+	if required == "" {
+		return nil
+	}
+
+	client, err := dao.GetClientDAO().FindById(session.ClientID)
+	if err != nil {
+		return ErrNotAuthorized
+	}
+
+	if !client.GetRole().Allows(required) {
+		return ErrForbidden
+	}
+	return nil
+}
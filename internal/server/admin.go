@@ -0,0 +1,715 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+	"vendepass/internal/dao"
+	"vendepass/internal/models"
+
+	"github.com/google/uuid"
+)
+
+// SetRole changes the role of a client, found by its username. Only admins may run it,
+// and an admin cannot change its own role, so the system is never left without the admin running it.
+//
+// Parameters:
+//   - session: The session of the client making the request, verified before the handler runs.
+//   - data: An interface containing the request data. It should be of type models.SetRoleRequest.
+//   - conn: A *RequestConn representing the connection to the client.
+func SetRole(session *models.Session, data interface{}, conn *RequestConn) {
+	var roleRequest models.SetRoleRequest
+
+	jsonData, _ := json.Marshal(data)
+	json.Unmarshal(jsonData, &roleRequest)
+
+	if !roleRequest.Role.Valid() {
+		WriteNewResponse(models.Response{
+			Error: "invalid role",
+		}, conn)
+		return
+	}
+
+	client, err := dao.GetClientDAO().FindByUsername(roleRequest.Username)
+	if err != nil {
+		WriteNewResponse(models.Response{
+			Error: "client not found",
+		}, conn)
+		return
+	}
+
+	if client.Id == session.ClientID {
+		WriteNewResponse(models.Response{
+			Error: "cannot change your own role",
+		}, conn)
+		return
+	}
+
+	// Only the role is changed on the stored client, so a ticket bought or cancelled meanwhile is kept.
+	updated, err := dao.GetClientDAO().Modify(client.Id, func(client *models.Client) error {
+		client.Role = roleRequest.Role
+		return nil
+	})
+	if err != nil {
+		WriteNewResponse(models.Response{
+			Error: err.Error(),
+		}, conn)
+		return
+	}
+
+	WriteNewResponse(models.Response{
+		Data: map[string]interface{}{
+			"user": models.NewClientResponse(updated),
+		},
+	}, conn)
+}
+
+// AdminCreateFlight creates a flight between two existing airports and starts its reservation queue.
+// Only admins may run it. Flights given a seat map sell every seat of the map unless fewer seats are asked for.
+// Flights given cabins sell each cabin class out of its own seats and fare, in place of the request's Seats and Fare.
+// Flights given an overbooking allowance keep selling that many seats, without a seat choice, once they are full.
+// On success, the new flight is sent back in its stored form.
+//
+// Parameters:
+//   - data: An interface containing the request data. It should be of type models.CreateFlightRequest.
+//   - conn: A *RequestConn representing the connection to the client.
+func AdminCreateFlight(_ *models.Session, data interface{}, conn *RequestConn) {
+	var createRequest models.CreateFlightRequest
+
+	jsonData, _ := json.Marshal(data)
+	json.Unmarshal(jsonData, &createRequest)
+
+	seatMap, seats, cabins, err := buildInventory(createRequest.SeatMap, createRequest.Seats, createRequest.Cabins)
+	if err != nil {
+		WriteNewResponse(models.Response{
+			Error: err.Error(),
+		}, conn)
+		return
+	}
+
+	if seats == 0 {
+		WriteNewResponse(models.Response{
+			Error: "a flight needs at least one seat",
+		}, conn)
+		return
+	}
+
+	fare := createRequest.Fare.WithDefaults()
+	if err := fare.Validate(); err != nil {
+		WriteNewResponse(models.Response{
+			Error: err.Error(),
+		}, conn)
+		return
+	}
+
+	if createRequest.SourceAirportId == createRequest.DestAirportId {
+		WriteNewResponse(models.Response{
+			Error: "source and destination must be different airports",
+		}, conn)
+		return
+	}
+
+	for _, id := range []uuid.UUID{createRequest.SourceAirportId, createRequest.DestAirportId} {
+		if _, err := dao.GetAirportDAO().FindById(id); err != nil {
+			WriteNewResponse(models.Response{
+				Error: fmt.Sprintf("airport not found: %s", id),
+			}, conn)
+			return
+		}
+	}
+
+	flight := &models.Flight{
+		SourceAirportId: createRequest.SourceAirportId,
+		DestAirportId:   createRequest.DestAirportId,
+		Passengers:      []*models.Ticket{},
+		Seats:           seats,
+		Capacity:        seats,
+		Fare:            fare,
+		Cabins:          cabins,
+		SeatMap:         seatMap,
+		Overbooking:     createRequest.Overbooking,
+	}
+
+	dao.GetFlightDAO().Insert(flight)
+	flight.Start()
+
+	fmt.Printf("Voo %s criado\n", flight.Id)
+
+	WriteNewResponse(models.Response{
+		Data: map[string]interface{}{
+			"flight": flight.ToJSON(),
+		},
+	}, conn)
+}
+
+// AdminUpdateFlight changes the capacity of a flight, or of one of its cabins on flights with cabins. Only admins may run it.
+// The capacity cannot be lower than the seats already sold or held by reservations, nor higher than the seats of the flight's seat map,
+// and the difference from the old capacity is added to, or taken from, the seats left of the cabin and of the flight.
+//
+// Parameters:
+//   - data: An interface containing the request data. It should be of type models.UpdateFlightRequest.
+//   - conn: A *RequestConn representing the connection to the client.
+func AdminUpdateFlight(_ *models.Session, data interface{}, conn *RequestConn) {
+	var updateRequest models.UpdateFlightRequest
+
+	jsonData, _ := json.Marshal(data)
+	json.Unmarshal(jsonData, &updateRequest)
+
+	flight, err := dao.GetFlightDAO().FindById(updateRequest.FlightId)
+	if err != nil {
+		WriteNewResponse(models.Response{
+			Error: err.Error(),
+		}, conn)
+		return
+	}
+
+	flight.Mu.Lock()
+	if flight.Cancelled {
+		flight.Mu.Unlock()
+		WriteNewResponse(models.Response{
+			Error: models.ErrFlightCancelled.Error(),
+		}, conn)
+		return
+	}
+
+	cabin, err := flightCabin(flight, updateRequest.Cabin)
+	if err != nil {
+		flight.Mu.Unlock()
+		WriteNewResponse(models.Response{
+			Error: err.Error(),
+		}, conn)
+		return
+	}
+
+	capacity, seats, seatsOnMap := flight.Capacity, flight.Seats, uint(0)
+	if flight.SeatMap != nil {
+		seatsOnMap = flight.SeatMap.Capacity()
+	}
+	if cabin != nil {
+		capacity, seats = cabin.Capacity, cabin.Seats
+		if flight.SeatMap != nil {
+			seatsOnMap = flight.SeatMap.CabinSeats(cabin.Class)
+		}
+	}
+
+	if flight.SeatMap != nil && updateRequest.Capacity > seatsOnMap {
+		flight.Mu.Unlock()
+		WriteNewResponse(models.Response{
+			Error: "a flight cannot sell more seats than its seat map has",
+		}, conn)
+		return
+	}
+
+	taken := capacity - seats
+	if updateRequest.Capacity < taken {
+		flight.Mu.Unlock()
+		WriteNewResponse(models.Response{
+			Error: fmt.Sprintf("capacity cannot be lower than the %d seats already sold or reserved", taken),
+		}, conn)
+		return
+	}
+
+	flight.Capacity = flight.Capacity - capacity + updateRequest.Capacity
+	flight.Seats = flight.Seats - seats + updateRequest.Capacity - taken
+	if cabin != nil {
+		cabin.Capacity = updateRequest.Capacity
+		cabin.Seats = updateRequest.Capacity - taken
+	}
+	flight.Mu.Unlock()
+
+	if err := dao.GetFlightDAO().Update(flight); err != nil {
+		fmt.Println("Error storing flight:", err)
+	}
+
+	WriteNewResponse(models.Response{
+		Data: map[string]interface{}{
+			"flight": flight.ToJSON(),
+		},
+	}, conn)
+}
+
+// buildInventory builds the seat map of a new flight or schedule, if its layout is given, its cabins, if any,
+// and the number of seats it sells.
+//
+// Parameters:
+//   - layout: The cabins of the aircraft, or none for a flight without a seat map.
+//   - seats: The number of seats asked for; every seat of the map if zero. Ignored when cabins are given.
+//   - cabins: The cabin classes sold, each with its seats and fare, or none to sell every seat in economy.
+//
+// Return:
+//   - A pointer to the seat map, or nil if no layout was given.
+//   - The number of seats sold, which add the cabins up on flights with cabins.
+//   - The cabins, ready to be sold.
+//   - An error if the layout or the cabins are invalid, or more seats are asked for than the map has.
+func buildInventory(layout []models.CabinLayout, seats uint, cabins []models.Cabin) (*models.SeatMap, uint, []models.Cabin, error) {
+	var seatMap *models.SeatMap
+	if len(layout) > 0 {
+		var err error
+		if seatMap, err = models.NewSeatMap(layout); err != nil {
+			return nil, 0, nil, err
+		}
+	}
+
+	if len(cabins) > 0 {
+		cabins, seats, err := models.NewCabins(cabins, seatMap)
+		if err != nil {
+			return nil, 0, nil, err
+		}
+		return seatMap, seats, cabins, nil
+	}
+
+	if seatMap == nil {
+		return nil, seats, nil, nil
+	}
+	if seats == 0 {
+		seats = seatMap.Capacity()
+	}
+	if seats > seatMap.Capacity() {
+		return nil, 0, nil, errors.New("a flight cannot sell more seats than its seat map has")
+	}
+	return seatMap, seats, nil, nil
+}
+
+// flightCabin returns the cabin an admin request changes. The caller must hold the flight's lock.
+//
+// Parameters:
+//   - flight: The flight being changed.
+//   - class: The cabin class named by the request.
+//
+// Return:
+//   - A pointer to the cabin, or nil for flights without cabins, which take no class.
+//   - An error if the flight has cabins and the class is missing or not sold, or if a class is named on a flight without cabins.
+func flightCabin(flight *models.Flight, class models.CabinClass) (*models.Cabin, error) {
+	if len(flight.Cabins) == 0 {
+		if class != "" {
+			return nil, models.ErrCabinNotSold
+		}
+		return nil, nil
+	}
+	if class == "" {
+		return nil, errors.New("a cabin class is needed on flights with cabins")
+	}
+	return flight.Cabin(class)
+}
+
+// AdminUpdateFare changes the fare of a flight, or of one of its cabins on flights with cabins. Only admins may run it.
+// Reservations already held and tickets already bought keep the price they were quoted.
+//
+// Parameters:
+//   - data: An interface containing the request data. It should be of type models.UpdateFareRequest.
+//   - conn: A *RequestConn representing the connection to the client.
+func AdminUpdateFare(_ *models.Session, data interface{}, conn *RequestConn) {
+	var fareRequest models.UpdateFareRequest
+
+	jsonData, _ := json.Marshal(data)
+	json.Unmarshal(jsonData, &fareRequest)
+
+	fare := fareRequest.Fare.WithDefaults()
+	if err := fare.Validate(); err != nil {
+		WriteNewResponse(models.Response{
+			Error: err.Error(),
+		}, conn)
+		return
+	}
+
+	flight, err := dao.GetFlightDAO().FindById(fareRequest.FlightId)
+	if err != nil {
+		WriteNewResponse(models.Response{
+			Error: err.Error(),
+		}, conn)
+		return
+	}
+
+	flight.Mu.Lock()
+	cabin, err := flightCabin(flight, fareRequest.Cabin)
+	if err != nil {
+		flight.Mu.Unlock()
+		WriteNewResponse(models.Response{
+			Error: err.Error(),
+		}, conn)
+		return
+	}
+	if cabin != nil {
+		cabin.Fare = fare
+	} else {
+		flight.Fare = fare
+	}
+	flight.Mu.Unlock()
+
+	if err := dao.GetFlightDAO().Update(flight); err != nil {
+		fmt.Println("Error storing flight:", err)
+	}
+
+	WriteNewResponse(models.Response{
+		Data: map[string]interface{}{
+			"flight": flight.ToJSON(),
+		},
+	}, conn)
+}
+
+// AdminCancelFlight cancels a flight. Only admins may run it.
+// The flight's reservation queue is stopped, the reservations held for it are released,
+// its tickets are refunded and every affected client is notified.
+// The flight is kept, marked as cancelled, so it is no longer offered in routes or reservations.
+//
+// Parameters:
+//   - data: An interface containing the request data. It should be of type models.CancelFlightRequest.
+//   - conn: A *RequestConn representing the connection to the client.
+func AdminCancelFlight(_ *models.Session, data interface{}, conn *RequestConn) {
+	var cancelRequest models.CancelFlightRequest
+
+	jsonData, _ := json.Marshal(data)
+	json.Unmarshal(jsonData, &cancelRequest)
+
+	flight, err := dao.GetFlightDAO().FindById(cancelRequest.FlightId)
+	if err != nil {
+		WriteNewResponse(models.Response{
+			Error: err.Error(),
+		}, conn)
+		return
+	}
+
+	if !flight.Cancel() {
+		WriteNewResponse(models.Response{
+			Error: "flight already cancelled",
+		}, conn)
+		return
+	}
+
+	reason := cancelRequest.Reason
+	if reason == "" {
+		reason = "cancelled by the airline"
+	}
+
+	released, refunded := cancelFlightBookings(flight, reason)
+
+	fmt.Printf("Voo %s cancelado: %d reservas liberadas, %d bilhetes reembolsados\n", flight.Id, released, refunded)
+
+	WriteNewResponse(models.Response{
+		Data: map[string]interface{}{
+			"msg":      "success",
+			"released": released,
+			"refunded": refunded,
+		},
+	}, conn)
+}
+
+// cancelFlightBookings releases the reservations held for a cancelled flight and refunds its tickets,
+// notifying the clients that held them and the ones on its waitlist, and stores the flight and the clients.
+//
+// Parameters:
+//   - flight: A pointer to the cancelled flight. Its lock must not be held.
+//   - reason: The reason of the cancellation, included in the notifications.
+//
+// Return:
+//   - The number of reservations released.
+//   - The number of tickets refunded.
+func cancelFlightBookings(flight *models.Flight, reason string) (int, int) {
+	released := 0
+	dropWaitlist(flight, reason)
+
+	for _, cart := range dao.GetCartDAO().FindAll() {
+		count := 0
+
+		cart.Mu.Lock()
+		for id, reservation := range cart.Reservations {
+			if reservation.FlightId == flight.Id {
+				delete(cart.Reservations, id)
+				count++
+			}
+		}
+		cart.Mu.Unlock()
+
+		if count > 0 {
+			released += count
+			notify(cart.ClientID, "Your reservation for flight %s was released: %s.", flight.Id, reason)
+		}
+	}
+
+	flight.Mu.Lock()
+	tickets := flight.Passengers
+	flight.Passengers = []*models.Ticket{}
+	flight.Seats = 0
+	for i := range flight.Cabins {
+		flight.Cabins[i].Seats = 0
+	}
+	flight.Mu.Unlock()
+
+	refunded := 0
+	for _, ticket := range tickets {
+		// A ticket its client is cancelling at the same time is refunded by that cancellation instead.
+		if _, err := takeTicket(ticket.ClientId, ticket.Id); err != nil {
+			continue
+		}
+
+		refundTicket(ticket)
+		cancelBookingTicket(ticket)
+		refunded++
+
+		notify(ticket.ClientId, "Your ticket %s for flight %s was refunded: %s.", ticket.Id, flight.Id, reason)
+	}
+
+	if err := dao.GetFlightDAO().Update(flight); err != nil {
+		fmt.Println("Error storing flight:", err)
+	}
+
+	return released, refunded
+}
+
+// AdminCreateAirport adds an airport to the network. Only admins may run it.
+// Airports are looked up by the name of their city, so two airports cannot serve a city of the same name.
+//
+// Parameters:
+//   - data: An interface containing the request data. It should be of type models.CreateAirportRequest.
+//   - conn: A *RequestConn representing the connection to the client.
+func AdminCreateAirport(_ *models.Session, data interface{}, conn *RequestConn) {
+	var createRequest models.CreateAirportRequest
+
+	jsonData, _ := json.Marshal(data)
+	json.Unmarshal(jsonData, &createRequest)
+
+	if createRequest.Name == "" || createRequest.City.Name == "" {
+		WriteNewResponse(models.Response{
+			Error: "an airport needs a name and a city",
+		}, conn)
+		return
+	}
+
+	if err := validateCoordinates(createRequest.City.Latitude, createRequest.City.Longitude); err != nil {
+		WriteNewResponse(models.Response{
+			Error: err.Error(),
+		}, conn)
+		return
+	}
+
+	if dao.GetAirportDAO().FindByName(createRequest.City.Name) != nil {
+		WriteNewResponse(models.Response{
+			Error: "an airport already serves this city",
+		}, conn)
+		return
+	}
+
+	airport := &models.Airport{
+		Name: createRequest.Name,
+		City: createRequest.City,
+	}
+
+	dao.GetAirportDAO().Insert(airport)
+
+	fmt.Printf("Aeroporto %s criado\n", airport.Id)
+
+	WriteNewResponse(models.Response{
+		Data: map[string]interface{}{
+			"airport": airportResponse(airport),
+		},
+	}, conn)
+}
+
+// AdminUpdateAirport renames an airport or its city, or moves the city to new coordinates.
+// Only admins may run it. Fields left empty in the request keep their current values.
+//
+// Parameters:
+//   - data: An interface containing the request data. It should be of type models.UpdateAirportRequest.
+//   - conn: A *RequestConn representing the connection to the client.
+func AdminUpdateAirport(_ *models.Session, data interface{}, conn *RequestConn) {
+	var updateRequest models.UpdateAirportRequest
+
+	jsonData, _ := json.Marshal(data)
+	json.Unmarshal(jsonData, &updateRequest)
+
+	airport, err := dao.GetAirportDAO().FindById(updateRequest.AirportId)
+	if err != nil {
+		WriteNewResponse(models.Response{
+			Error: "airport not found",
+		}, conn)
+		return
+	}
+
+	if updateRequest.CityName != "" {
+		if other := dao.GetAirportDAO().FindByName(updateRequest.CityName); other != nil && other.Id != airport.Id {
+			WriteNewResponse(models.Response{
+				Error: "an airport already serves this city",
+			}, conn)
+			return
+		}
+	}
+
+	airport.Mu.Lock()
+	latitude, longitude := airport.City.Latitude, airport.City.Longitude
+	if updateRequest.Latitude != nil {
+		latitude = *updateRequest.Latitude
+	}
+	if updateRequest.Longitude != nil {
+		longitude = *updateRequest.Longitude
+	}
+
+	if err := validateCoordinates(latitude, longitude); err != nil {
+		airport.Mu.Unlock()
+		WriteNewResponse(models.Response{
+			Error: err.Error(),
+		}, conn)
+		return
+	}
+
+	if updateRequest.Name != "" {
+		airport.Name = updateRequest.Name
+	}
+	if updateRequest.CityName != "" {
+		airport.City.Name = updateRequest.CityName
+	}
+	airport.City.Latitude, airport.City.Longitude = latitude, longitude
+	airport.Mu.Unlock()
+
+	if err := dao.GetAirportDAO().Update(airport); err != nil {
+		fmt.Println("Error storing airport:", err)
+	}
+
+	WriteNewResponse(models.Response{
+		Data: map[string]interface{}{
+			"airport": airportResponse(airport),
+		},
+	}, conn)
+}
+
+// AdminDecommissionAirport removes an airport from the network. Only admins may run it.
+// An airport that is still the source or destination of active flights, which have neither been cancelled
+// nor departed, or of schedules is kept, unless the request
+// asks to cancel those flights, in which case the schedules are deleted first, so they generate no more flights,
+// and the flights are cancelled as by AdminCancelFlight.
+// The flights touching the airport are then removed with it, so no flight or schedule is left pointing to a missing airport.
+//
+// Parameters:
+//   - data: An interface containing the request data. It should be of type models.DecommissionAirportRequest.
+//   - conn: A *RequestConn representing the connection to the client.
+func AdminDecommissionAirport(_ *models.Session, data interface{}, conn *RequestConn) {
+	var decommissionRequest models.DecommissionAirportRequest
+
+	jsonData, _ := json.Marshal(data)
+	json.Unmarshal(jsonData, &decommissionRequest)
+
+	airport, err := dao.GetAirportDAO().FindById(decommissionRequest.AirportId)
+	if err != nil {
+		WriteNewResponse(models.Response{
+			Error: "airport not found",
+		}, conn)
+		return
+	}
+
+	schedules := schedulesAt(airport.Id)
+	if len(schedules) > 0 && !decommissionRequest.CancelFlights {
+		WriteNewResponse(models.Response{
+			Error: fmt.Sprintf("airport is still served by %d schedules", len(schedules)),
+		}, conn)
+		return
+	}
+
+	for _, schedule := range schedules {
+		dao.GetScheduleDAO().Delete(schedule)
+	}
+
+	flights := activeFlightsAt(airport.Id)
+
+	if len(flights) > 0 && !decommissionRequest.CancelFlights {
+		WriteNewResponse(models.Response{
+			Error: fmt.Sprintf("airport is still served by %d active flights", len(flights)),
+		}, conn)
+		return
+	}
+
+	reason := decommissionRequest.Reason
+	if reason == "" {
+		reason = fmt.Sprintf("airport %s was decommissioned", airport.Name)
+	}
+
+	cancelled := 0
+	for _, flight := range flights {
+		if !flight.Cancel() {
+			continue
+		}
+		cancelFlightBookings(flight, reason)
+		cancelled++
+	}
+
+	for _, flight := range dao.GetFlightDAO().FindAll() {
+		if flight.SourceAirportId == airport.Id || flight.DestAirportId == airport.Id {
+			dao.GetFlightDAO().Delete(flight)
+		}
+	}
+
+	dao.GetAirportDAO().Delete(airport)
+
+	fmt.Printf("Aeroporto %s desativado: %d voos cancelados, %d programações removidas\n", airport.Id, cancelled, len(schedules))
+
+	WriteNewResponse(models.Response{
+		Data: map[string]interface{}{
+			"msg":       "success",
+			"cancelled": cancelled,
+			"schedules": len(schedules),
+		},
+	}, conn)
+}
+
+// schedulesAt lists the schedules whose flights depart from or arrive at an airport.
+//
+// Parameters:
+//   - airportId: The ID of the airport.
+//
+// Return:
+//   - The schedules touching the airport.
+func schedulesAt(airportId uuid.UUID) []*models.Schedule {
+	var schedules []*models.Schedule
+
+	for _, schedule := range dao.GetScheduleDAO().FindAll() {
+		if schedule.SourceAirportId == airportId || schedule.DestAirportId == airportId {
+			schedules = append(schedules, schedule)
+		}
+	}
+
+	return schedules
+}
+
+// activeFlightsAt lists the flights that are neither cancelled nor departed and depart from or arrive at an airport.
+// Flights that have already departed were flown, so they are never cancelled nor refunded.
+//
+// Parameters:
+//   - airportId: The ID of the airport.
+//
+// Return:
+//   - The active flights touching the airport.
+func activeFlightsAt(airportId uuid.UUID) []*models.Flight {
+	var flights []*models.Flight
+	now := time.Now()
+
+	for _, flight := range dao.GetFlightDAO().FindAll() {
+		flight.Mu.Lock()
+		active := !flight.Cancelled && !flight.Departed(now) && (flight.SourceAirportId == airportId || flight.DestAirportId == airportId)
+		flight.Mu.Unlock()
+
+		if active {
+			flights = append(flights, flight)
+		}
+	}
+
+	return flights
+}
+
+// airportResponse builds the representation of an airport sent to clients.
+func airportResponse(airport *models.Airport) map[string]interface{} {
+	airport.Mu.RLock()
+	defer airport.Mu.RUnlock()
+
+	return map[string]interface{}{
+		"Id":   airport.Id,
+		"Name": airport.Name,
+		"City": airport.City,
+	}
+}
+
+// validateCoordinates checks that a latitude and a longitude are within their ranges in degrees.
+func validateCoordinates(latitude, longitude float32) error {
+	if latitude < -90 || latitude > 90 || longitude < -180 || longitude > 180 {
+		return errors.New("coordinates out of range")
+	}
+	return nil
+}
@@ -0,0 +1,224 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"vendepass/internal/dao"
+	"vendepass/internal/models"
+
+	"github.com/google/uuid"
+)
+
+// CancelBooking cancels every valid leg of a booking of the authenticated client, refunding each of them
+// and returning their seats to the flights, as if each ticket was cancelled with CancelBuy.
+// The booking is named by its ID or by its reference.
+// A booking with a leg whose flight has already departed cannot be cancelled.
+//
+// Parameters:
+//   - session: The session of the client making the request, verified before the handler runs.
+//   - data: An interface containing the request data. It should be of type models.CancelBookingRequest.
+//   - conn: A *RequestConn representing the connection to the client.
+func CancelBooking(session *models.Session, data interface{}, conn *RequestConn) {
+	var cancelRequest models.CancelBookingRequest
+
+	jsonData, _ := json.Marshal(data)
+	json.Unmarshal(jsonData, &cancelRequest)
+
+	var booking *models.Booking
+	var err error
+	if cancelRequest.BookingId != uuid.Nil {
+		booking, err = dao.GetBookingDAO().FindById(cancelRequest.BookingId)
+	} else {
+		booking, err = dao.GetBookingDAO().FindByReference(cancelRequest.Reference)
+	}
+	if err != nil || booking.ClientId != session.ClientID {
+		WriteNewResponse(models.Response{
+			Error: "booking not found",
+		}, conn)
+		return
+	}
+
+	if booking.Status == models.BookingCancelled {
+		WriteNewResponse(models.Response{
+			Error: "booking already cancelled",
+		}, conn)
+		return
+	}
+
+	// A booking with a leg already flown cannot be cancelled; the legs still to fly can be cancelled one by one.
+	for _, leg := range booking.Tickets {
+		if !booking.TicketCancelled(leg.Id) && ticketDeparted(leg) {
+			WriteNewResponse(models.Response{
+				Error: ErrFlightDeparted.Error(),
+			}, conn)
+			return
+		}
+	}
+
+	// Legs already cancelled on their own are no longer among the client's tickets, and are skipped.
+	for _, leg := range booking.Tickets {
+		cancelTicket(session.ClientID, leg.Id)
+	}
+
+	fmt.Printf("Reserva %s cancelada\n", booking.Reference)
+
+	WriteNewResponse(models.Response{
+		Data: map[string]interface{}{
+			"msg": "success",
+		},
+	}, conn)
+}
+
+// FindBooking looks a booking up by its reference and a last name, as airlines do, so a customer can check the booking
+// without logging in. The last name may be the one of the client who bought it or of any passenger flying on it.
+// Both must match, otherwise the booking is not found, so the reference alone does not reveal whether a booking exists.
+//
+// Parameters:
+//   - data: An interface containing the request data. It should be of type models.FindBookingRequest.
+//   - conn: A *RequestConn representing the connection to the client.
+func FindBooking(_ *models.Session, data interface{}, conn *RequestConn) {
+	var findRequest models.FindBookingRequest
+
+	jsonData, _ := json.Marshal(data)
+	json.Unmarshal(jsonData, &findRequest)
+
+	booking, err := dao.GetBookingDAO().FindByReference(strings.TrimSpace(findRequest.Reference))
+	if err == nil {
+		if !bookingHasLastName(booking, strings.TrimSpace(findRequest.LastName)) {
+			err = errors.New("booking not found")
+		}
+	}
+	if err != nil {
+		WriteNewResponse(models.Response{
+			Error: "booking not found",
+		}, conn)
+		return
+	}
+
+	WriteNewResponse(models.Response{
+		Data: map[string]interface{}{
+			"Booking": bookingResponse(booking),
+		},
+	}, conn)
+}
+
+// bookingHasLastName reports whether a last name is the one of the client who bought a booking,
+// or of a passenger flying on one of its tickets. Case is ignored.
+//
+// Parameters:
+//   - booking: A pointer to the booking.
+//   - name: The last name to be checked.
+//
+// Return:
+//   - true if the buyer or a passenger of the booking has the last name, false otherwise.
+func bookingHasLastName(booking *models.Booking, name string) bool {
+	if name == "" {
+		return false
+	}
+
+	if client, err := dao.GetClientDAO().FindById(booking.ClientId); err == nil && strings.EqualFold(lastName(client.Name), name) {
+		return true
+	}
+
+	for _, ticket := range booking.Tickets {
+		if ticket.Passenger != nil && strings.EqualFold(lastName(ticket.Passenger.Name), name) {
+			return true
+		}
+	}
+	return false
+}
+
+// lastName returns the last word of a client's or a passenger's name.
+//
+// Parameters:
+//   - name: The full name of the client or passenger.
+//
+// Return:
+//   - The last word of the name, or an empty string if the name has none.
+func lastName(name string) string {
+	words := strings.Fields(name)
+	if len(words) == 0 {
+		return ""
+	}
+	return words[len(words)-1]
+}
+
+// recordBooking stores the booking of the tickets of a completed order.
+//
+// Parameters:
+//   - orderId: The ID of the order, which becomes the ID of the booking.
+//   - clientId: The ID of the client who bought the tickets.
+//   - tickets: The tickets of the order.
+//
+// Return:
+//   - A pointer to the stored booking.
+func recordBooking(orderId uuid.UUID, clientId uuid.UUID, tickets []*models.Ticket) *models.Booking {
+	booking := models.NewBooking(orderId, clientId, tickets)
+	dao.GetBookingDAO().Insert(booking)
+	return booking
+}
+
+// cancelBookingTicket records the cancellation of a ticket in the booking it belongs to.
+// Tickets bought before bookings existed belong to none and are ignored.
+//
+// Parameters:
+//   - ticket: A pointer to the cancelled ticket.
+func cancelBookingTicket(ticket *models.Ticket) {
+	booking, err := dao.GetBookingDAO().FindById(ticket.OrderId)
+	if err != nil {
+		return
+	}
+
+	if booking.CancelTicket(ticket.Id) {
+		if err := dao.GetBookingDAO().Update(booking); err != nil {
+			fmt.Println("Error storing booking:", err)
+		}
+	}
+}
+
+// bookingsResponse builds the list of bookings of a client sent back to the users, each with its legs.
+//
+// Parameters:
+//   - clientId: The ID of the client owning the bookings.
+//
+// Return:
+//   - A slice of maps, one per booking, as built by bookingResponse.
+func bookingsResponse(clientId uuid.UUID) []map[string]interface{} {
+	responseData := make([]map[string]interface{}, 0)
+
+	for _, booking := range dao.GetBookingDAO().FindByClientId(clientId) {
+		responseData = append(responseData, bookingResponse(booking))
+	}
+
+	return responseData
+}
+
+// bookingResponse describes a booking to the users, with its legs.
+//
+// Parameters:
+//   - booking: A pointer to the booking.
+//
+// Return:
+//   - A map with the keys "Id", "Reference", "Status", "CreatedAt", "TotalPaid" and "Legs".
+//     Each leg is described as by ticketResponse, with a "Cancelled" key telling whether it was cancelled.
+func bookingResponse(booking *models.Booking) map[string]interface{} {
+	legs := make([]map[string]interface{}, 0, len(booking.Tickets))
+	for _, ticket := range booking.Tickets {
+		leg := ticketResponse(ticket)
+		leg["Cancelled"] = booking.TicketCancelled(ticket.Id)
+		legs = append(legs, leg)
+	}
+
+	bookingresponse := make(map[string]interface{})
+
+	bookingresponse["Id"] = booking.Id
+	bookingresponse["Reference"] = booking.Reference
+	bookingresponse["Status"] = booking.Status
+	bookingresponse["CreatedAt"] = booking.CreatedAt
+	bookingresponse["TotalPaid"] = booking.TotalPaid
+	bookingresponse["Legs"] = legs
+
+	return bookingresponse
+}
@@ -1,45 +1,68 @@
 package server
 
 import (
-	"net"
 	"vendepass/internal/dao"
 	"vendepass/internal/models"
 )
 
-// GetCart retrieves the user's cart information based on the provided authentication token.
-// It sends a response containing the list of reservations along with their corresponding source and destination cities.
+// GetCart retrieves the cart of the client owning the session.
+// It sends a response containing the list of reservations in the client's cart along with their corresponding source and destination cities,
+// their fare classes, prices and chosen seats, the time each reservation expires and the extensions it has left,
+// and the total price of the cart, added up by currency.
+// The cities are left out of reservations whose flight or airports no longer exist, as after an airport is decommissioned.
 //
 // Parameters:
-// 	- auth: A string representing the user's authentication token.
-// 	- conn: A net.Conn object representing the connection to the client.
-func GetCart(auth string, conn net.Conn) {
-	session, exists := SessionIfExists(auth)
-
-	if !exists {
-		WriteNewResponse(models.Response{
-			Error: "not authorized",
-		}, conn)
-		return
-	}
+// 	- session: The session of the client making the request, verified before the handler runs.
+// 	- conn: A *RequestConn representing the connection to the client.
+func GetCart(session *models.Session, _ interface{}, conn *RequestConn) {
 	responseData := make([]map[string]interface{}, 0)
 
-	for _, reservation := range session.Reservations {
-		flight, _ := dao.GetFlightDAO().FindById(reservation.FlightId)
+	cart := dao.GetCartDAO().FindOrCreate(session.ClientID)
+
+	cart.Mu.RLock()
+	reservations := make([]models.Reservation, 0, len(cart.Reservations))
+	for _, reservation := range cart.Reservations {
+		reservations = append(reservations, reservation)
+	}
+	cart.Mu.RUnlock()
 
-		src, _ := dao.GetAirportDAO().FindById(flight.SourceAirportId)
-		dest, _ := dao.GetAirportDAO().FindById(flight.DestAirportId)
+	prices := make([]models.Price, 0, len(reservations))
 
+	for _, reservation := range reservations {
 		flightresponse := make(map[string]interface{})
 
-		flightresponse["Src"] = src.City
-		flightresponse["Dest"] = dest.City
+		if flight, err := dao.GetFlightDAO().FindById(reservation.FlightId); err == nil {
+			if src, found := airportCity(flight.SourceAirportId); found {
+				flightresponse["Src"] = src
+			}
+			if dest, found := airportCity(flight.DestAirportId); found {
+				flightresponse["Dest"] = dest
+			}
+		}
 		flightresponse["Id"] = reservation.Id
+		flightresponse["Passenger"] = reservation.Passenger
+		flightresponse["Cabin"] = reservation.Cabin
+		flightresponse["FareClass"] = reservation.FareClass
+		flightresponse["Price"] = reservation.Price
+		flightresponse["Seat"] = reservation.Seat
+		flightresponse["ExpiresAt"] = reservation.ExpiresAt
+		flightresponse["ExtensionsLeft"] = extensionsLeft(reservation)
 		responseData = append(responseData, flightresponse)
+		prices = append(prices, reservation.Price)
 	}
 
 	WriteNewResponse(models.Response{
 		Data: map[string]interface{}{
 			"Reservations": responseData,
+			"Total":        models.Total(prices),
 		},
 	}, conn)
 }
+
+// extensionsLeft returns the number of times the hold of a reservation can still be extended.
+func extensionsLeft(reservation models.Reservation) uint {
+	if reservation.Extensions >= MaxHoldExtensions {
+		return 0
+	}
+	return MaxHoldExtensions - reservation.Extensions
+}
@@ -0,0 +1,179 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+	"vendepass/internal/dao"
+	"vendepass/internal/models"
+
+	"github.com/google/uuid"
+)
+
+// ErrReservationExpired is answered to purchases of reservations whose hold has expired.
+var ErrReservationExpired = errors.New("reservation expired")
+
+// Checkout buys every reservation in the cart of the authenticated client as a single order,
+// so the legs of a route are either all bought or none of them is.
+// Each reservation is paid through the payment provider; if any payment fails, or any flight was cancelled
+// in the meantime, the payments already captured are refunded and the reservations go back to the cart.
+// If any hold has expired, nothing is charged and the expired reservations are released.
+//
+// Parameters:
+//   - session: The session of the client making the request, verified before the handler runs.
+//   - conn: A *RequestConn representing the connection to the client.
+func Checkout(session *models.Session, _ interface{}, conn *RequestConn) {
+	cart := dao.GetCartDAO().FindOrCreate(session.ClientID)
+
+	cart.Mu.Lock()
+	reservations := make([]models.Reservation, 0, len(cart.Reservations))
+	for _, reservation := range cart.Reservations {
+		reservations = append(reservations, reservation)
+	}
+	cart.Reservations = make(map[uuid.UUID]models.Reservation)
+	cart.Mu.Unlock()
+
+	if len(reservations) == 0 {
+		WriteNewResponse(models.Response{
+			Error: "cart is empty",
+		}, conn)
+		return
+	}
+
+	booking, err := buyReservations(uuid.New(), reservations)
+	if err != nil {
+		for _, reservation := range reservations {
+			restoreReservation(reservation)
+		}
+		WriteNewResponse(models.Response{
+			Error: err.Error(),
+		}, conn)
+		return
+	}
+
+	fmt.Printf("Pedido %s concluído: %d bilhetes\n", booking.Id, len(reservations))
+
+	WriteNewResponse(models.Response{
+		Data: map[string]interface{}{
+			"msg":       "success",
+			"OrderId":   booking.Id,
+			"Reference": booking.Reference,
+			"Total":     booking.TotalPaid,
+		},
+	}, conn)
+}
+
+// buyReservations turns reservations, already removed from their cart, into tickets of a single order.
+// Reservations whose hold has expired cannot be bought, and none of them is charged.
+// Every reservation is charged first; the tickets are only issued once all of them are paid,
+// holding the locks of all their flights, so no flight can be cancelled halfway through.
+// The tickets are given to the client and grouped in a booking of the client under the same locks. On failure, every payment captured is refunded
+// and no ticket is issued; putting the reservations back in the cart is left to the caller.
+//
+// Parameters:
+//   - orderId: The ID of the order grouping the tickets.
+//   - reservations: The reservations being bought, all of the same client.
+//
+// Return:
+//   - The booking of the tickets.
+//   - An error describing why the order was not completed, or nil.
+func buyReservations(orderId uuid.UUID, reservations []models.Reservation) (*models.Booking, error) {
+	client, err := dao.GetClientDAO().FindById(reservations[0].ClientId)
+	if err != nil {
+		return nil, errors.New("client not found")
+	}
+
+	now := time.Now()
+	flights := make(map[uuid.UUID]*models.Flight)
+	for _, reservation := range reservations {
+		if reservation.Expired(now) {
+			return nil, ErrReservationExpired
+		}
+		flight, err := dao.GetFlightDAO().FindById(reservation.FlightId)
+		if err != nil {
+			return nil, errors.New("flight not found")
+		}
+		flights[flight.Id] = flight
+	}
+
+	paid := make([]*models.Ticket, 0, len(reservations))
+	rollback := func() {
+		for _, ticket := range paid {
+			refundTicket(ticket)
+			ticket.PaymentId = uuid.Nil
+			ticket.OrderId = uuid.Nil
+		}
+	}
+
+	for _, reservation := range reservations {
+		paymentId, err := chargeReservation(reservation)
+		if err != nil {
+			fmt.Printf("Pagamento da reserva %s não concluído: %s\n", reservation.Id, err)
+			rollback()
+			return nil, errors.New(paymentError(err))
+		}
+		reservation.Ticket.PaymentId = paymentId
+		reservation.Ticket.OrderId = orderId
+		paid = append(paid, reservation.Ticket)
+	}
+
+	// The flights are locked in the order of their IDs, so two checkouts never wait for each other.
+	locked := make([]*models.Flight, 0, len(flights))
+	for _, flight := range flights {
+		locked = append(locked, flight)
+	}
+	sort.Slice(locked, func(i, j int) bool {
+		return locked[i].Id.String() < locked[j].Id.String()
+	})
+
+	for _, flight := range locked {
+		flight.Mu.Lock()
+	}
+
+	cancelled := false
+	for _, flight := range locked {
+		cancelled = cancelled || flight.Cancelled
+	}
+
+	// The tickets are given to the client and booked before the flights are unlocked, so a cancellation
+	// of one of the flights finds each ticket among its client's tickets, refunds it and cancels it in its booking.
+	var booking *models.Booking
+	if !cancelled {
+		tickets := make([]*models.Ticket, 0, len(reservations))
+		for _, reservation := range reservations {
+			flight := flights[reservation.FlightId]
+			flight.Passengers = append(flight.Passengers, reservation.Ticket)
+			tickets = append(tickets, reservation.Ticket)
+		}
+
+		// The tickets are added to the stored client, so a ticket cancelled meanwhile is not brought back.
+		_, err = dao.GetClientDAO().Modify(client.Id, func(client *models.Client) error {
+			client.Client_flights = append(client.Client_flights, tickets...)
+			return nil
+		})
+		if err != nil {
+			fmt.Println("Error storing ticket:", err)
+		}
+
+		booking = recordBooking(orderId, client.Id, tickets)
+	}
+
+	for _, flight := range locked {
+		flight.Mu.Unlock()
+	}
+
+	if cancelled {
+		// A flight was cancelled while the payments were captured, after its bookings were refunded.
+		rollback()
+		return nil, models.ErrFlightCancelled
+	}
+
+	for _, flight := range locked {
+		if err := dao.GetFlightDAO().Update(flight); err != nil {
+			fmt.Println("Error storing ticket:", err)
+		}
+	}
+
+	return booking, nil
+}
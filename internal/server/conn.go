@@ -0,0 +1,33 @@
+package server
+
+import (
+	"net"
+	"sync"
+)
+
+// RequestConn is the connection as seen by the handler of a single request.
+// A client may pipeline many requests over the same connection, and they are served concurrently,
+// so every RequestConn of a connection shares the same write lock and tags its response with the
+// ID of the request it answers.
+type RequestConn struct {
+	conn      net.Conn
+	writeMu   *sync.Mutex
+	requestId string
+}
+
+// newRequestConn creates the view of conn used to answer the request identified by requestId.
+//
+// Parameters:
+//   - conn: The client connection the request was read from.
+//   - writeMu: The lock that serializes every write on conn.
+//   - requestId: The ID of the request, echoed back in the response.
+//
+// Return:
+//   - A pointer to the new RequestConn.
+func newRequestConn(conn net.Conn, writeMu *sync.Mutex, requestId string) *RequestConn {
+	return &RequestConn{
+		conn:      conn,
+		writeMu:   writeMu,
+		requestId: requestId,
+	}
+}
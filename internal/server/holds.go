@@ -0,0 +1,210 @@
+package server
+
+import (
+	"container/heap"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+	"vendepass/internal/dao"
+	"vendepass/internal/models"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// DefaultHoldDuration is the time a reservation holds its seat unless HoldDuration is replaced.
+	DefaultHoldDuration = 30 * time.Minute
+	// DefaultMaxHoldExtensions is the number of times a reservation can be extended unless MaxHoldExtensions is replaced.
+	DefaultMaxHoldExtensions = 2
+)
+
+// HoldDuration is the time a reservation holds its seat before it expires and the seat is released, unless it is
+// bought first. Extending a reservation holds its seat for HoldDuration again. It is independent of the session timeout,
+// and can be replaced before the server starts accepting connections.
+var HoldDuration = DefaultHoldDuration
+
+// MaxHoldExtensions is the number of times the hold of a reservation can be extended.
+// It can be replaced before the server starts accepting connections.
+var MaxHoldExtensions uint = DefaultMaxHoldExtensions
+
+// hold is a time at which reservations of a client's cart are due to expire.
+type hold struct {
+	clientId  uuid.UUID
+	expiresAt time.Time
+}
+
+// holdQueue is a priority queue of holds, the earliest first. It implements heap.Interface.
+type holdQueue []hold
+
+func (q holdQueue) Len() int           { return len(q) }
+func (q holdQueue) Less(i, j int) bool { return q[i].expiresAt.Before(q[j].expiresAt) }
+func (q holdQueue) Swap(i, j int)      { q[i], q[j] = q[j], q[i] }
+
+func (q *holdQueue) Push(x interface{}) {
+	*q = append(*q, x.(hold))
+}
+
+func (q *holdQueue) Pop() interface{} {
+	old := *q
+	last := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return last
+}
+
+// holds keeps the holds waiting to expire. Holds are never removed when a reservation is bought, released
+// or extended: the cart is checked again when the hold is due, and only the reservations expired by then are released.
+var holds = struct {
+	mu    sync.Mutex
+	queue holdQueue
+	// wake interrupts the wait of the expiry loop when a hold earlier than the ones it waits for is scheduled.
+	wake  chan struct{}
+	start sync.Once
+}{wake: make(chan struct{}, 1)}
+
+// scheduleHold schedules the expiry of the reservations a client's cart holds until a given time.
+// The expiry loop is started with the first hold scheduled.
+//
+// Parameters:
+//   - clientId: The ID of the client owning the cart.
+//   - expiresAt: The time the reservations expire.
+func scheduleHold(clientId uuid.UUID, expiresAt time.Time) {
+	holds.start.Do(func() { go expireHolds() })
+
+	holds.mu.Lock()
+	heap.Push(&holds.queue, hold{clientId: clientId, expiresAt: expiresAt})
+	earliest := holds.queue[0].expiresAt.Equal(expiresAt)
+	holds.mu.Unlock()
+
+	if earliest {
+		select {
+		case holds.wake <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// expireHolds is the expiry loop. It sleeps until the earliest hold is due, or until an earlier one is scheduled,
+// and releases the reservations of the carts whose holds are due, so a seat returns to sale, or to its flight's
+// waitlist, as soon as its reservation expires.
+func expireHolds() {
+	timer := time.NewTimer(time.Hour)
+	defer timer.Stop()
+
+	for {
+		now := time.Now()
+		var due []uuid.UUID
+		wait := time.Hour
+
+		holds.mu.Lock()
+		for len(holds.queue) > 0 && !holds.queue[0].expiresAt.After(now) {
+			due = append(due, heap.Pop(&holds.queue).(hold).clientId)
+		}
+		if len(holds.queue) > 0 {
+			wait = holds.queue[0].expiresAt.Sub(now)
+		}
+		holds.mu.Unlock()
+
+		for _, clientId := range due {
+			expireReservations(clientId, now)
+		}
+
+		if !timer.Stop() {
+			select {
+			case <-timer.C:
+			default:
+			}
+		}
+		timer.Reset(wait)
+
+		select {
+		case <-timer.C:
+		case <-holds.wake:
+		}
+	}
+}
+
+// expireReservations removes the expired reservations from a client's cart and releases their seats.
+//
+// Parameters:
+//   - clientId: The ID of the client owning the cart.
+//   - now: The time the reservations are checked against.
+func expireReservations(clientId uuid.UUID, now time.Time) {
+	cart, err := dao.GetCartDAO().FindByClientId(clientId)
+	if err != nil {
+		return
+	}
+
+	var expired []models.Reservation
+
+	cart.Mu.Lock()
+	for key, reservation := range cart.Reservations {
+		if reservation.Expired(now) {
+			fmt.Printf("Encerrando reserva %s por expiração\n", reservation.Id)
+			expired = append(expired, reservation)
+			delete(cart.Reservations, key)
+		}
+	}
+	cart.Mu.Unlock()
+
+	for _, reservation := range expired {
+		releaseReservation(reservation)
+	}
+}
+
+// ExtendReservation extends the hold of a reservation in the client's cart, so it holds its seat for HoldDuration
+// from now. A reservation can be extended up to MaxHoldExtensions times; extending a reservation made for several
+// passengers extends the whole group, since it is bought as a whole.
+// On success, the new expiry time and the number of extensions left are sent back.
+//
+// Parameters:
+//   - session: The session of the client making the request, verified before the handler runs.
+//   - data: An interface containing the request data. It should be of type models.ExtendReservationRequest.
+//   - conn: A *RequestConn representing the connection to the client.
+func ExtendReservation(session *models.Session, data interface{}, conn *RequestConn) {
+	var extendRequest models.ExtendReservationRequest
+
+	jsonData, _ := json.Marshal(data)
+	json.Unmarshal(jsonData, &extendRequest)
+
+	cart := dao.GetCartDAO().FindOrCreate(session.ClientID)
+	now := time.Now()
+
+	cart.Mu.Lock()
+	reservation, exists := cart.Reservations[extendRequest.ReservationId]
+	if !exists || reservation.Expired(now) {
+		cart.Mu.Unlock()
+		WriteNewResponse(models.Response{
+			Error: "reservation do not exists",
+		}, conn)
+		return
+	}
+	if reservation.Extensions >= MaxHoldExtensions {
+		cart.Mu.Unlock()
+		WriteNewResponse(models.Response{
+			Error: "reservation cannot be extended any further",
+		}, conn)
+		return
+	}
+
+	expiresAt := now.Add(HoldDuration)
+	for key, other := range cart.Reservations {
+		if key == reservation.Id || (reservation.GroupId != uuid.Nil && other.GroupId == reservation.GroupId) {
+			other.ExpiresAt = expiresAt
+			other.Extensions++
+			cart.Reservations[key] = other
+		}
+	}
+	cart.Mu.Unlock()
+
+	scheduleHold(session.ClientID, expiresAt)
+
+	fmt.Printf("Session %s: reservation %s extended until %s\n", session.ID, reservation.Id, expiresAt.Format(time.RFC3339))
+
+	WriteNewResponse(models.Response{
+		Data: map[string]interface{}{
+			"ExpiresAt":      expiresAt,
+			"ExtensionsLeft": MaxHoldExtensions - reservation.Extensions - 1,
+		},
+	}, conn)
+}
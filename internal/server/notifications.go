@@ -0,0 +1,44 @@
+package server
+
+import (
+	"fmt"
+	"time"
+	"vendepass/internal/dao"
+	"vendepass/internal/models"
+
+	"github.com/google/uuid"
+)
+
+// Notifications delivers the notifications waiting for the authenticated client, oldest first.
+// Delivered notifications are removed as they are read, so each one is only sent once,
+// and one left while they are read waits for the next request.
+//
+// Parameters:
+//   - session: The session of the client making the request, verified before the handler runs.
+//   - conn: A *RequestConn representing the connection to the client.
+func Notifications(session *models.Session, _ interface{}, conn *RequestConn) {
+	notifications := dao.GetNotificationDAO().TakeByClientId(session.ClientID)
+
+	WriteNewResponse(models.Response{
+		Data: map[string]interface{}{
+			"Notifications": notifications,
+		},
+	}, conn)
+}
+
+// notify leaves a notification for a client, to be delivered by the next notifications request of any of its sessions.
+//
+// Parameters:
+//   - clientId: The ID of the client to be notified.
+//   - format: A fmt format string for the message, followed by its arguments.
+func notify(clientId uuid.UUID, format string, args ...interface{}) {
+	message := fmt.Sprintf(format, args...)
+
+	dao.GetNotificationDAO().Insert(&models.Notification{
+		ClientId:  clientId,
+		Message:   message,
+		CreatedAt: time.Now(),
+	})
+
+	fmt.Printf("Client %s notified: %s\n", clientId, message)
+}
@@ -0,0 +1,117 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+	"vendepass/internal/dao"
+	"vendepass/internal/models"
+	"vendepass/internal/payment"
+
+	"github.com/google/uuid"
+)
+
+// Payments is the provider charging the clients for their tickets and refunding them.
+// It is a fake provider, charging no one, unless replaced before the server starts accepting connections.
+var Payments payment.Provider = payment.NewFake()
+
+// PaymentTimeout is how long a checkout waits for the provider to capture a payment
+// before giving up and returning the seat to the client's cart.
+var PaymentTimeout = 30 * time.Second
+
+// chargeReservation pays for a reservation: it creates a payment intent for the reservation's price and captures it.
+// If the capture fails or times out, the intent is canceled, so the client is never charged for a ticket not issued.
+//
+// Parameters:
+//   - reservation: The reservation being bought.
+//
+// Return:
+//   - The ID of the captured payment intent.
+//   - An error if the payment was not completed.
+func chargeReservation(reservation models.Reservation) (uuid.UUID, error) {
+	intent, err := Payments.CreateIntent(reservation.ClientId, reservation.Price)
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), PaymentTimeout)
+	defer cancel()
+
+	if err := Payments.Capture(ctx, intent.Id); err != nil {
+		if err := Payments.Cancel(intent.Id); err != nil {
+			fmt.Println("Error canceling payment:", err)
+		}
+		return uuid.Nil, err
+	}
+
+	return intent.Id, nil
+}
+
+// paymentError describes a failed payment to the client.
+//
+// Parameters:
+//   - err: The error returned by chargeReservation.
+//
+// Return:
+//   - The error message sent back to the client.
+func paymentError(err error) string {
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		return "payment timed out"
+	case errors.Is(err, payment.ErrDeclined):
+		return "payment declined"
+	default:
+		return "payment failed"
+	}
+}
+
+// refundTicket gives back the payment of a canceled ticket through the provider.
+// Tickets bought before payments were taken have no payment to refund.
+//
+// Parameters:
+//   - ticket: A pointer to the canceled ticket.
+func refundTicket(ticket *models.Ticket) {
+	if ticket.PaymentId == uuid.Nil {
+		return
+	}
+
+	if err := Payments.Refund(ticket.PaymentId); err != nil {
+		fmt.Println("Error refunding payment:", err)
+	}
+}
+
+// restoreReservation puts a reservation whose payment failed back in its client's cart,
+// so the seat stays held and the client can try to buy it again until its hold expires.
+// Reservations whose hold expired while they were being paid are released instead,
+// and reservations of flights cancelled in the meantime are dropped, since their seats are gone.
+//
+// Parameters:
+//   - reservation: The reservation to be restored.
+func restoreReservation(reservation models.Reservation) {
+	if reservation.Expired(time.Now()) {
+		fmt.Printf("Encerrando reserva %s por expiração\n", reservation.Id)
+		releaseReservation(reservation)
+		return
+	}
+
+	flight, err := dao.GetFlightDAO().FindById(reservation.FlightId)
+	if err != nil {
+		return
+	}
+
+	flight.Mu.Lock()
+	cancelled := flight.Cancelled
+	flight.Mu.Unlock()
+	if cancelled {
+		return
+	}
+
+	cart := dao.GetCartDAO().FindOrCreate(reservation.ClientId)
+
+	cart.Mu.Lock()
+	cart.Reservations[reservation.Id] = reservation
+	cart.Mu.Unlock()
+
+	scheduleHold(reservation.ClientId, reservation.ExpiresAt)
+}
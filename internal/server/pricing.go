@@ -0,0 +1,143 @@
+package server
+
+import (
+	"time"
+	"vendepass/internal/dao"
+	"vendepass/internal/models"
+	"vendepass/internal/pricing"
+
+	"github.com/google/uuid"
+)
+
+// Pricing is the engine adjusting the fares of the flights to their demand.
+// It charges the base prices unless rules are configured at startup.
+var Pricing pricing.Engine = pricing.Static{}
+
+// QuoteTTL is how long a client can reserve a flight at the prices quoted by Flights and Route.
+const QuoteTTL = 15 * time.Minute
+
+// priceFlight prices a fare class in a cabin of a flight with the pricing engine, from the load of the cabin,
+// or of the flight if it has no cabins, and the time to departure. The caller must hold the flight's lock.
+//
+// Parameters:
+//   - flight: The flight being priced.
+//   - class: The cabin class, or empty for the first cabin the flight sells.
+//   - fareClass: The code of the fare class, or empty for the first class the cabin sells.
+//   - now: The time of the quote.
+//
+// Return:
+//   - The price of the class, its code and the cabin class priced, empty for flights without cabins.
+//   - models.ErrCabinNotSold or models.ErrFareClassNotSold if the flight does not sell the cabin or the fare class.
+func priceFlight(flight *models.Flight, class models.CabinClass, fareClass string, now time.Time) (models.Price, string, models.CabinClass, error) {
+	base, code, class, err := flight.Quote(class, fareClass)
+	if err != nil {
+		return models.Price{}, "", "", err
+	}
+
+	capacity, seats := flight.Capacity, flight.Seats
+	if cabin, _ := flight.Cabin(class); cabin != nil {
+		capacity, seats = cabin.Capacity, cabin.Seats
+	}
+
+	input := pricing.Input{Base: base, Dated: flight.Dated()}
+	if capacity > 0 && seats <= capacity {
+		input.LoadFactor = float64(capacity-seats) / float64(capacity)
+	}
+	if input.Dated {
+		input.TimeToDeparture = flight.Departure.Sub(now)
+	}
+
+	return Pricing.Price(input), code, class, nil
+}
+
+// offerQuotes prices every fare class of a flight's cabins for a client and stores the quotes,
+// so the client can reserve the flight at those prices until they expire.
+//
+// Parameters:
+//   - clientId: The ID of the client the quotes are offered to.
+//   - flight: The flight being quoted.
+//   - class: The cabin class quoted, or empty for every cabin the flight sells.
+//
+// Return:
+//   - The quotes, one per fare class of each cabin quoted.
+func offerQuotes(clientId uuid.UUID, flight *models.Flight, class models.CabinClass) []*models.Quote {
+	now := time.Now()
+
+	flight.Mu.Lock()
+	fares := map[models.CabinClass]models.Fare{"": flight.Fare}
+	cabins := []models.CabinClass{""}
+	if len(flight.Cabins) > 0 {
+		cabins = cabins[:0]
+		for _, cabin := range flight.Cabins {
+			if class == "" || class == cabin.Class {
+				fares[cabin.Class] = cabin.Fare
+				cabins = append(cabins, cabin.Class)
+			}
+		}
+	}
+
+	quotes := []*models.Quote{}
+	for _, cabin := range cabins {
+		classes := fares[cabin].Classes
+		if len(classes) == 0 {
+			classes = []models.FareClass{{Code: models.DefaultFareClass}}
+		}
+
+		for _, fareClass := range classes {
+			price, code, cabin, err := priceFlight(flight, cabin, fareClass.Code, now)
+			if err != nil {
+				continue
+			}
+			quotes = append(quotes, &models.Quote{
+				ClientId:  clientId,
+				FlightId:  flight.Id,
+				Cabin:     cabin,
+				FareClass: code,
+				Price:     price,
+				ExpiresAt: now.Add(QuoteTTL),
+			})
+		}
+	}
+	flight.Mu.Unlock()
+
+	for _, quote := range quotes {
+		dao.GetQuoteDAO().Insert(quote)
+	}
+
+	return quotes
+}
+
+// lockedPrice returns the price a reservation of a flight is charged: the price of a quote the request names,
+// if it was offered to the same client for the same flight, cabin and fare class and has not expired,
+// or the current price of the fare class otherwise. A quote is used up by the reservation it prices, so it is
+// deleted and cannot lock the price of another reservation. The caller must hold the flight's lock.
+//
+// Parameters:
+//   - clientId: The ID of the client reserving the flight.
+//   - flight: The flight being reserved.
+//   - class: The cabin class, or empty for the first cabin the flight sells.
+//   - fareClass: The code of the fare class, or empty for the first class the cabin sells.
+//   - quoteIds: The IDs of the quotes named by the reservation request.
+//
+// Return:
+//   - The price charged.
+//   - models.ErrCabinNotSold or models.ErrFareClassNotSold if the flight does not sell the cabin or the fare class.
+func lockedPrice(clientId uuid.UUID, flight *models.Flight, class models.CabinClass, fareClass string, quoteIds []uuid.UUID) (models.Price, error) {
+	price, code, class, err := priceFlight(flight, class, fareClass, time.Now())
+	if err != nil {
+		return models.Price{}, err
+	}
+
+	for _, id := range quoteIds {
+		quote, err := dao.GetQuoteDAO().FindById(id)
+		if err != nil {
+			continue
+		}
+		if quote.ClientId == clientId && quote.FlightId == flight.Id && quote.Cabin == class && quote.FareClass == code &&
+			dao.GetQuoteDAO().Delete(id) == nil {
+			return quote.Price, nil
+		}
+	}
+
+	return price, nil
+}
@@ -2,8 +2,9 @@ package server
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
-	"net"
+	"time"
 	"vendepass/internal/dao"
 	"vendepass/internal/models"
 
@@ -12,42 +13,80 @@ import (
 
 // Reservation handles the creation of reservations for a given set of flights.
 // It verifies the session's existence, deserializes the request data, processes each requested flight,
-// checks for availability, and sends the session to the respective flight's reservation queue.
+// checks for availability, and sends a request for the client's cart to the respective flight's reservation queue.
+// Cancelled flights, and dated flights that have already departed, are never available.
+// Every reservation is priced in the requested fare class, which every flight must sell, in the cabin class
+// requested for each flight, or in the first cabin the flight sells; the cabin must have seats left,
+// or the flight must still be allowed to overbook it.
+// A flight is charged the price of a quote the request names, so the client pays the price it saw in
+// Flights or Route while the quote lasts, or the current price of the fare class otherwise.
+// The price is kept with the reservation until it is bought or released.
+// A seat can be chosen on each flight with a seat map; requests for the same seat are served in order by the
+// flight's reservation queue, so the seat goes to the first of them and the others are answered that it is taken.
+// A request naming passengers reserves a seat for each of them on every flight, all of a flight's seats at once
+// or none of them, in the seats chosen for each passenger; their reservations are grouped, so buying any of them
+// buys the whole group as one booking of the client, with one ticket per passenger.
+// The reservations are kept in the client's cart, shared by all of the client's sessions, and hold their seats
+// for HoldDuration; the time they expire is sent back on success.
 // If any flight is not available, it responds with an error.
 //
 // Parameters:
-//   - auth: A string representing the session's authentication token.
+//   - session: The session of the client making the request, verified before the handler runs.
 //   - data: An interface containing the request data. It should be of type models.FlightsRequest.
-//   - conn: A net.Conn representing the connection to the client.
-func Reservation(auth string, data interface{}, conn net.Conn) {
-	// Check if the session exists
-	session, exists := SessionIfExists(auth)
+//   - conn: A *RequestConn representing the connection to the client.
+func Reservation(session *models.Session, data interface{}, conn *RequestConn) {
+	var flightRequest models.FlightsRequest
 
-	if !exists {
+	// Deserialize the request data
+	jsonData, _ := json.Marshal(data)
+	json.Unmarshal(jsonData, &flightRequest)
+
+	if err := models.ValidatePassengers(flightRequest.Passengers); err != nil {
 		WriteNewResponse(models.Response{
-			Error: "not authorized",
+			Error: err.Error(),
 		}, conn)
 		return
 	}
 
-	var flightRequest models.FlightsRequest
-
-	// Deserialize the request data
-	jsonData, _ := json.Marshal(data)
-	json.Unmarshal(jsonData, &flightRequest)
+	count := uint(1)
+	groupId := uuid.Nil
+	if len(flightRequest.Passengers) > 0 {
+		count = uint(len(flightRequest.Passengers))
+		groupId = uuid.New()
+	}
 
 	flights := make([]*models.Flight, len(flightRequest.FlightIds))
+	prices := make([]models.Price, len(flightRequest.FlightIds))
 	var notAvailableFlights []*models.Flight
 	// Process each requested flight
 	for i, id := range flightRequest.FlightIds {
-		flight, _ := dao.GetFlightDAO().FindById(id)
+		flight, err := dao.GetFlightDAO().FindById(id)
+		if err != nil {
+			WriteNewResponse(models.Response{
+				Error: err.Error(),
+			}, conn)
+			return
+		}
+		class := requestedCabin(flightRequest, i)
+
 		flight.Mu.Lock()
-		if flight.Seats <= 0 {
-			notAvailableFlights = append(notAvailableFlights, flight)
-		} else {
+		price, fareErr := lockedPrice(session.ClientID, flight, class, flightRequest.FareClass, flightRequest.QuoteIds)
+		available := !flight.Cancelled && !flight.Departed(time.Now()) && flight.Bookable(class, count)
+		flight.Mu.Unlock()
+
+		if fareErr != nil {
+			WriteNewResponse(models.Response{
+				Error: fmt.Sprintf("%s: %s", fareErr, id),
+			}, conn)
+			return
+		}
+
+		if available {
 			flights[i] = flight
+			prices[i] = price
+		} else {
+			notAvailableFlights = append(notAvailableFlights, flight)
 		}
-		flight.Mu.Unlock()
 	}
 
 	// Check if any flight is not available and respond with error
@@ -63,13 +102,36 @@ func Reservation(auth string, data interface{}, conn net.Conn) {
 		return
 	}
 
-	for _, flight := range flights {
-		// Send the session to the flight's reservation queue
-		flight.Queue <- session
-		status := <-session.FailedReservations
-		if status != "success" {
-			id, _ := uuid.Parse(status)
-			responseData, _ := getRoute([]uuid.UUID{id})
+	cart := dao.GetCartDAO().FindOrCreate(session.ClientID)
+	expiresAt := time.Now().Add(HoldDuration)
+	scheduleHold(session.ClientID, expiresAt)
+
+	for i, flight := range flights {
+		request := &models.ReservationRequest{
+			Cart:       cart,
+			Cabin:      requestedCabin(flightRequest, i),
+			FareClass:  flightRequest.FareClass,
+			Passengers: flightRequest.Passengers,
+			Seats:      passengerSeats(flightRequest, i),
+			GroupId:    groupId,
+			Price:      &prices[i],
+			ExpiresAt:  expiresAt,
+		}
+		if len(flightRequest.Passengers) == 0 && i < len(flightRequest.Seats) {
+			request.Seat = flightRequest.Seats[i]
+		}
+
+		// Send the request to the flight's reservation queue and wait for its own outcome
+		if err := flight.Submit(request); err != nil {
+			if errors.Is(err, models.ErrSeatTaken) || errors.Is(err, models.ErrUnknownSeat) || errors.Is(err, models.ErrNoSeatMap) ||
+				errors.Is(err, models.ErrSeatNotInCabin) {
+				WriteNewResponse(models.Response{
+					Error: fmt.Sprintf("%s: %s", err, flight.Id),
+				}, conn)
+				return
+			}
+
+			responseData, _ := getRoute([]uuid.UUID{flight.Id})
 
 			fmt.Printf("Session %s: flight %s failed\n", session.ID, flight.Id)
 			WriteNewResponse(models.Response{
@@ -85,50 +147,107 @@ func Reservation(auth string, data interface{}, conn net.Conn) {
 	// Success: Reservations created successfully
 	WriteNewResponse(models.Response{
 		Data: map[string]interface{}{
-			"msg": "success",
+			"msg":       "success",
+			"ExpiresAt": expiresAt,
 		},
 	}, conn)
 }
 
+// requestedCabin returns the cabin class a reservation request asks for on its i-th flight, or empty if it asks for none.
+func requestedCabin(flightRequest models.FlightsRequest, i int) models.CabinClass {
+	if i < len(flightRequest.Cabins) {
+		return flightRequest.Cabins[i]
+	}
+	return ""
+}
+
+// passengerSeats returns the seats a reservation request chooses for each of its passengers on its i-th flight,
+// empty for the passengers choosing none.
+func passengerSeats(flightRequest models.FlightsRequest, i int) []string {
+	seats := make([]string, len(flightRequest.Passengers))
+	for j := range seats {
+		if j < len(flightRequest.PassengerSeats) && i < len(flightRequest.PassengerSeats[j]) {
+			seats[j] = flightRequest.PassengerSeats[j][i]
+		}
+	}
+	return seats
+}
+
 // CancelReservation cancels a reservation for a specific flight.
-// It verifies the session's existence, deserializes the request data, retrieves the reservation,
-// releases the seat on the flight, and removes the reservation from the session.
+// It verifies the session's existence, deserializes the request data, retrieves the reservation from the client's cart,
+// releases the seat on the flight, and removes the reservation from the cart.
 //
 // Parameters:
-//   - auth: A string representing the session's authentication token.
+//   - session: The session of the client making the request, verified before the handler runs.
 //   - data: An interface containing the request data. It should be of type models.CancelReservationRequest.
-//   - conn: A net.Conn representing the connection to the client.
-func CancelReservation(auth string, data interface{}, conn net.Conn) {
-	// Verify if the session exists
-	session, exists := SessionIfExists(auth)
+//   - conn: A *RequestConn representing the connection to the client.
+func CancelReservation(session *models.Session, data interface{}, conn *RequestConn) {
+	var cancelReservation models.CancelReservationRequest
+
+	jsonData, _ := json.Marshal(data)
+	json.Unmarshal(jsonData, &cancelReservation)
+
+	cart := dao.GetCartDAO().FindOrCreate(session.ClientID)
+
+	cart.Mu.Lock()
+	reservation, exists := cart.Reservations[cancelReservation.ReservationId]
+	delete(cart.Reservations, cancelReservation.ReservationId)
+	cart.Mu.Unlock()
 
 	if !exists {
 		WriteNewResponse(models.Response{
-			Error: "not authorized",
+			Error: "reservation do not exists",
 		}, conn)
 		return
 	}
 
-	var cancelReservation models.CancelReservationRequest
+	releaseReservation(reservation)
 
-	jsonData, _ := json.Marshal(data)
-	json.Unmarshal(jsonData, &cancelReservation)
+	fmt.Printf("Session %s: flight %s canceled successfully\n", session.ID, reservation.FlightId)
 
-	reservation := session.Reservations[cancelReservation.ReservationId]
+	WriteNewResponse(models.Response{
+		Data: map[string]interface{}{
+			"msg": "success",
+		},
+	}, conn)
+}
 
-	flight, _ := dao.GetFlightDAO().FindById(reservation.FlightId)
+// releaseReservation returns the seat held by a reservation to its flight and gives it to the flight's waitlist, if any.
+// The reservation must already have been removed from its cart.
+//
+// Parameters:
+//   - reservation: The reservation whose seat is released.
+func releaseReservation(reservation models.Reservation) {
+	flight, err := dao.GetFlightDAO().FindById(reservation.FlightId)
+	if err != nil {
+		return
+	}
 
 	flight.Mu.Lock()
-	flight.Seats++
+	flight.ReleaseSeat(reservation.Ticket)
 	flight.Mu.Unlock()
 
-	delete(session.Reservations, cancelReservation.ReservationId)
+	promoteWaitlist(flight)
+}
+
+// releaseCart releases every reservation of a client's cart and removes the cart.
+//
+// Parameters:
+//   - clientId: The ID of the client whose cart is released.
+func releaseCart(clientId uuid.UUID) {
+	cart, err := dao.GetCartDAO().FindByClientId(clientId)
+	if err != nil {
+		return
+	}
+
+	dao.GetCartDAO().Delete(cart)
 
-	fmt.Printf("Session %s: flight %s canceled successfully\n", session.ID, flight.Id)
+	cart.Mu.Lock()
+	reservations := cart.Reservations
+	cart.Reservations = make(map[uuid.UUID]models.Reservation)
+	cart.Mu.Unlock()
 
-	WriteNewResponse(models.Response{
-		Data: map[string]interface{}{
-			"msg": "success",
-		},
-	}, conn)
+	for _, reservation := range reservations {
+		releaseReservation(reservation)
+	}
 }
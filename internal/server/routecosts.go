@@ -0,0 +1,121 @@
+package server
+
+import (
+	"math"
+	"time"
+	"vendepass/internal/dao"
+	"vendepass/internal/models"
+
+	"github.com/google/uuid"
+)
+
+// CruiseSpeed is the speed, in kilometers per hour, from which the flight time of flights that are not dated is estimated.
+const CruiseSpeed = 800.0
+
+// routeCost returns the cost a route search minimizes for a sort other than the fewest flights.
+//
+// Parameters:
+//   - sort: The cost to minimize.
+//   - dest: The destination airport of the route.
+//   - cabin: The cabin class the route is priced in, or empty for the first cabin each flight sells.
+//
+// Return:
+//   - The cost of the flights of a route, or nil for models.SortFlights, which is searched breadth-first.
+func routeCost(sort models.RouteSort, dest *models.Airport, cabin models.CabinClass) models.RouteCost {
+	cities := cityCache{}
+
+	switch sort {
+	case models.SortDistance:
+		return distanceCost{cities: cities, dest: cities.city(dest.Id)}
+	case models.SortPrice:
+		return priceCost{cabin: cabin, now: time.Now()}
+	case models.SortDuration:
+		return durationCost{cities: cities}
+	}
+	return nil
+}
+
+// cityCache keeps the cities of the airports a route search goes through, by airport ID,
+// so each airport is looked up only once per search.
+type cityCache map[uuid.UUID]models.City
+
+// city returns the city of an airport, or the zero city if the airport does not exist.
+func (c cityCache) city(airportId uuid.UUID) models.City {
+	if city, ok := c[airportId]; ok {
+		return city
+	}
+
+	city, _ := airportCity(airportId)
+	c[airportId] = city
+	return city
+}
+
+// distance returns the great-circle distance flown by a flight, in kilometers.
+func (c cityCache) distance(flight *models.Flight) float64 {
+	return c.city(flight.SourceAirportId).DistanceTo(c.city(flight.DestAirportId))
+}
+
+// distanceCost weighs each flight by the great-circle distance between its airports, and estimates the distance left
+// by the great-circle distance to the destination, which no route can beat, so the search is A*.
+type distanceCost struct {
+	cities cityCache
+	dest   models.City
+}
+
+func (c distanceCost) Cost(_ *models.Flight, flight *models.Flight) float64 {
+	return c.cities.distance(flight)
+}
+
+func (c distanceCost) Estimate(airportId uuid.UUID) float64 {
+	return c.cities.city(airportId).DistanceTo(c.dest)
+}
+
+// priceCost weighs each flight by its current price in the first fare class of the cabin, in the minor unit
+// of its currency. Prices in different currencies are not converted.
+type priceCost struct {
+	cabin models.CabinClass
+	now   time.Time
+}
+
+func (c priceCost) Cost(_ *models.Flight, flight *models.Flight) float64 {
+	flight.Mu.Lock()
+	price, _, _, err := priceFlight(flight, c.cabin, "", c.now)
+	flight.Mu.Unlock()
+
+	if err != nil {
+		return math.Inf(1)
+	}
+	return float64(price.Amount)
+}
+
+func (c priceCost) Estimate(uuid.UUID) float64 {
+	return 0
+}
+
+// durationCost weighs each flight by its flight time plus the layover before it, in minutes, so the cost of a route
+// is its whole travel time. The flight time of a flight that is not dated is estimated from its distance at CruiseSpeed,
+// and its layovers take dao.MinConnection.
+type durationCost struct {
+	cities cityCache
+}
+
+func (c durationCost) Cost(previous *models.Flight, flight *models.Flight) float64 {
+	duration := flight.Arrival.Sub(flight.Departure)
+	if !flight.Dated() {
+		duration = time.Duration(c.cities.distance(flight) / CruiseSpeed * float64(time.Hour))
+	}
+
+	if previous != nil {
+		if previous.Dated() && flight.Dated() {
+			duration += flight.Departure.Sub(previous.Arrival)
+		} else {
+			duration += dao.MinConnection
+		}
+	}
+
+	return duration.Minutes()
+}
+
+func (c durationCost) Estimate(uuid.UUID) float64 {
+	return 0
+}
@@ -2,8 +2,9 @@ package server
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
-	"net"
+	"time"
 	"vendepass/internal/dao"
 	"vendepass/internal/models"
 
@@ -11,52 +12,76 @@ import (
 )
 
 // AllRoutes handles the retrieval of all available routes.
-// It checks if the provided authentication token is valid and returns a list of all routes if authorized.
+// It is only served to logged in clients.
+// Every flight is sent as built by flightSummary, without its passengers, whose tickets hold personal and payment data.
 //
 // Parameters:
-//   - auth: A string representing the authentication token provided by the client.
-//   - conn: A net.Conn object representing the connection to the client.
-func AllRoutes(auth string, conn net.Conn) {
+//   - conn: A *RequestConn representing the connection to the client.
+func AllRoutes(_ *models.Session, _ interface{}, conn *RequestConn) {
+	flights := dao.GetFlightDAO().FindAll()
 
-	_, exists := SessionIfExists(auth)
-
-	if !exists {
-		WriteNewResponse(models.Response{
-			Error: "not authorized",
-		}, conn)
-		return
+	routes := make([]map[string]interface{}, 0, len(flights))
+	for _, flight := range flights {
+		routes = append(routes, flightSummary(flight))
 	}
 
-	dao := dao.GetFlightDAO()
-	dao.New()
-
 	WriteNewResponse(models.Response{
 		Data: map[string]interface{}{
-			"all-routes": dao.FindAll(),
+			"all-routes": routes,
 		},
 	}, conn)
-
 }
 
-// Route handles the retrieval of a route between two cities.
-// It checks if the provided authentication token is valid and returns a route if authorized.
-// If the source or destination city is not found, it returns an error response.
-// If no route is found between the source and destination cities, it returns an error response.
+// flightSummary builds the representation of a flight sent to clients listing the flights, read under the flight's lock.
 //
 // Parameters:
-//   - auth: A string representing the authentication token provided by the client.
-//   - data: An interface containing the source and destination city names.
-//   - conn: A net.Conn object representing the connection to the client.
-func Route(auth string, data interface{}, conn net.Conn) {
-	_, exists := SessionIfExists(auth)
+//   - flight: A pointer to the flight.
+//
+// Return:
+//   - A map with the keys "Id", "SourceAirportId", "DestAirportId", "Seats", "Capacity", "Fare" and "Cancelled",
+//     "Cabins" for flights with cabins, and "Number", "Departure" and "Arrival" for dated flights.
+func flightSummary(flight *models.Flight) map[string]interface{} {
+	flight.Mu.Lock()
+	defer flight.Mu.Unlock()
 
-	if !exists {
-		WriteNewResponse(models.Response{
-			Error: "not authorized",
-		}, conn)
-		return
+	summary := map[string]interface{}{
+		"Id":              flight.Id,
+		"SourceAirportId": flight.SourceAirportId,
+		"DestAirportId":   flight.DestAirportId,
+		"Seats":           flight.Seats,
+		"Capacity":        flight.Capacity,
+		"Fare":            flight.Fare,
+		"Cancelled":       flight.Cancelled,
+	}
+	if len(flight.Cabins) > 0 {
+		summary["Cabins"] = append([]models.Cabin(nil), flight.Cabins...)
 	}
+	if flight.Dated() {
+		summary["Number"] = flight.Number
+		summary["Departure"] = flight.Departure
+		summary["Arrival"] = flight.Arrival
+	}
+	return summary
+}
 
+// Route handles the retrieval of a route between two cities.
+// It is only served to logged in clients.
+// Every flight of the route is sent with the quotes of its fare classes, which the client can reserve until they expire.
+// When the request names a cabin class, every flight of the route has seats left in that cabin and is only quoted in it.
+// When the request has a travel date, the route starts with a flight departing on that date,
+// and the dated flights of the route are sent with their numbers and their departure and arrival times.
+// The route has the fewest flights, unless the request sorts it by distance, price or duration, in which case
+// it is the route with the shortest great-circle distance, the lowest current price in the cabin requested,
+// or the shortest travel time, layovers included.
+// If the source or destination city is not found, or the date or the sort is not valid, it returns an error response.
+// If no route is found between the source and destination cities, or an airport of the route is decommissioned
+// while it is searched, it returns an error response.
+//
+// Parameters:
+//   - session: The session of the client making the request, verified before the handler runs.
+//   - data: An interface containing the source and destination city names.
+//   - conn: A *RequestConn representing the connection to the client.
+func Route(session *models.Session, data interface{}, conn *RequestConn) {
 	var routeRequest models.RouteRequest
 	var response models.Response
 
@@ -73,8 +98,40 @@ func Route(auth string, data interface{}, conn net.Conn) {
 		return
 	}
 
-	// route, err := dao.GetFlightDAO().FindBySourceAndDest(src.Id, dest.Id)
-	path, path_err := dao.GetFlightDAO().BreadthFirstSearch(src.Id, dest.Id)
+	var day time.Time
+	if routeRequest.Date != "" {
+		var err error
+		day, err = time.Parse(models.DateLayout, routeRequest.Date)
+		if err != nil {
+			WriteNewResponse(models.Response{
+				Error: "invalid date",
+			}, conn)
+			return
+		}
+	}
+
+	if routeRequest.Cabin != "" && !routeRequest.Cabin.Valid() {
+		WriteNewResponse(models.Response{
+			Error: "invalid cabin",
+		}, conn)
+		return
+	}
+
+	if !routeRequest.Sort.Valid() {
+		WriteNewResponse(models.Response{
+			Error: "invalid sort",
+		}, conn)
+		return
+	}
+
+	var path []*models.Flight
+	var path_err error
+	if routeRequest.Sort == models.SortFlights {
+		path, path_err = dao.GetFlightDAO().BreadthFirstSearch(src.Id, dest.Id, day, routeRequest.Cabin)
+	} else {
+		cost := routeCost(routeRequest.Sort, dest, routeRequest.Cabin)
+		path, path_err = dao.GetFlightDAO().ShortestPath(src.Id, dest.Id, day, routeRequest.Cabin, cost)
+	}
 	if path_err != nil {
 		response.Error = "no route"
 	} else {
@@ -82,45 +139,51 @@ func Route(auth string, data interface{}, conn net.Conn) {
 		for i, flight := range path {
 			cities_path[i].Path = make([]models.City, 2)
 			cities_path[i].FlightId = flight.Id
-			srcById, _ := dao.GetAirportDAO().FindById(flight.SourceAirportId)
-			cities_path[i].Path[0] = srcById.City
-			destById, _ := dao.GetAirportDAO().FindById(flight.DestAirportId)
-			cities_path[i].Path[1] = destById.City
+			cities_path[i].Quotes = offerQuotes(session.ClientID, flight, routeRequest.Cabin)
+			if flight.Dated() {
+				departure, arrival := flight.Departure, flight.Arrival
+				cities_path[i].Number = flight.Number
+				cities_path[i].Departure = &departure
+				cities_path[i].Arrival = &arrival
+			}
+			srcCity, srcFound := airportCity(flight.SourceAirportId)
+			destCity, destFound := airportCity(flight.DestAirportId)
+			if !srcFound || !destFound {
+				// An airport of the route was decommissioned while it was searched.
+				path_err = errors.New("airport not found")
+				break
+			}
+			cities_path[i].Path[0] = srcCity
+			cities_path[i].Path[1] = destCity
 		}
 
-		response.Data = map[string]interface{}{
-			"path": cities_path,
+		if path_err != nil {
+			response.Error = "no route"
+		} else {
+			response.Data = map[string]interface{}{
+				"path": cities_path,
+			}
 		}
-
 	}
 
 	WriteNewResponse(response, conn)
 }
 
 // Flights handles the retrieval of flight details based on provided flight IDs.
-// It checks if the provided authentication token is valid and returns flight details if authorized.
+// It is only served to logged in clients, and each flight is sent with the quotes of its fare classes ("Quotes"),
+// which the client can reserve until they expire, in the cabin requested for the flight or in every cabin it sells.
 // If any of the provided flight IDs does not exist, it returns an error response.
 //
 // Parameters:
-//   - auth: A string representing the authentication token provided by the client.
+//   - session: The session of the client making the request, verified before the handler runs.
 //   - data: An interface containing the flight IDs.
-//   - conn: A net.Conn object representing the connection to the client.
+//   - conn: A *RequestConn representing the connection to the client.
 //
 // Return:
 //   - This function does not return any value. It writes a response to the client's connection.
-//   - The response contains flight details if authorized and valid flight IDs are provided.
-//   - If not authorized, it returns an error response with the message "not authorized".
+//   - The response contains flight details if valid flight IDs are provided.
 //   - If any of the provided flight IDs does not exist, it returns an error response.
-func Flights(auth string, data interface{}, conn net.Conn) {
-	_, exists := SessionIfExists(auth)
-
-	if !exists {
-		WriteNewResponse(models.Response{
-			Error: "not authorized",
-		}, conn)
-		return
-	}
-
+func Flights(session *models.Session, data interface{}, conn *RequestConn) {
 	var flightsRequest models.FlightsRequest
 
 	jsonData, _ := json.Marshal(data)
@@ -131,6 +194,16 @@ func Flights(auth string, data interface{}, conn net.Conn) {
 		WriteNewResponse(models.Response{
 			Error: err.Error(),
 		}, conn)
+		return
+	}
+
+	for i, id := range flightsRequest.FlightIds {
+		flight, err := dao.GetFlightDAO().FindById(id)
+		if err != nil {
+			// The flight was removed after its details were read.
+			continue
+		}
+		responseData[i]["Quotes"] = offerQuotes(session.ClientID, flight, requestedCabin(flightsRequest, i))
 	}
 
 	WriteNewResponse(models.Response{
@@ -149,8 +222,12 @@ func Flights(auth string, data interface{}, conn net.Conn) {
 // Return:
 //   - A slice of map[string]interface{} containing the flight details. Each map represents a flight and contains the following keys:
 //   - "Seats": An integer representing the number of available seats on the flight.
-//   - "Src": A string representing the source city of the flight.
-//   - "Dest": A string representing the destination city of the flight.
+//   - "Cancelled": A boolean telling whether the flight was cancelled.
+//   - "Src": A string representing the source city of the flight, left out if its airport no longer exists.
+//   - "Dest": A string representing the destination city of the flight, left out if its airport no longer exists.
+//   - "Number", "Departure" and "Arrival": The flight number and times, only for dated flights.
+//   - "Fare": The current fare of the flight, with its base price, currency and fare classes.
+//   - "Cabins": The cabin classes of the flight, with the seats left and the fare of each one, only for flights with cabins.
 //   - An error if any of the provided flight IDs does not exist in the database.
 func getRoute(flightIds []uuid.UUID) ([]map[string]interface{}, error) {
 	responseData := make([]map[string]interface{}, len(flightIds))
@@ -161,18 +238,44 @@ func getRoute(flightIds []uuid.UUID) ([]map[string]interface{}, error) {
 			return nil, fmt.Errorf("some flight doesn't exist: %s", id)
 		}
 		flight.Mu.Lock()
-
-		src, _ := dao.GetAirportDAO().FindById(flight.SourceAirportId)
-		dest, _ := dao.GetAirportDAO().FindById(flight.DestAirportId)
-		src.Mu.RLock()
-		dest.Mu.RLock()
 		flightresponse["Seats"] = flight.Seats
+		flightresponse["Cancelled"] = flight.Cancelled
+		flightresponse["Fare"] = flight.Fare
+		if len(flight.Cabins) > 0 {
+			flightresponse["Cabins"] = append([]models.Cabin(nil), flight.Cabins...)
+		}
+		if flight.Dated() {
+			flightresponse["Number"] = flight.Number
+			flightresponse["Departure"] = flight.Departure
+			flightresponse["Arrival"] = flight.Arrival
+		}
 		flight.Mu.Unlock()
-		flightresponse["Src"] = src.City.Name
-		flightresponse["Dest"] = dest.City.Name
-		src.Mu.RUnlock()
-		dest.Mu.RUnlock()
+		if src, found := airportCity(flight.SourceAirportId); found {
+			flightresponse["Src"] = src.Name
+		}
+		if dest, found := airportCity(flight.DestAirportId); found {
+			flightresponse["Dest"] = dest.Name
+		}
 		responseData[i] = flightresponse
 	}
 	return responseData, nil
 }
+
+// airportCity returns the city of an airport, read under the airport's lock.
+//
+// Parameters:
+//   - airportId: The ID of the airport.
+//
+// Return:
+//   - The city of the airport.
+//   - false if the airport no longer exists, as after it was decommissioned.
+func airportCity(airportId uuid.UUID) (models.City, bool) {
+	airport, err := dao.GetAirportDAO().FindById(airportId)
+	if err != nil {
+		return models.City{}, false
+	}
+
+	airport.Mu.RLock()
+	defer airport.Mu.RUnlock()
+	return airport.City, true
+}
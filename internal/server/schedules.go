@@ -0,0 +1,195 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+	"vendepass/internal/dao"
+	"vendepass/internal/models"
+
+	"github.com/google/uuid"
+)
+
+// ScheduleHorizon is the number of days, from today, for which the schedules have their flights generated.
+const ScheduleHorizon = 30
+
+// GenerateFlights creates the flights of every schedule for the days of the horizon that do not have them yet,
+// and starts their reservation queues. It can be called again at any time; a day's flight is only created once.
+//
+// Parameters:
+//   - from: The first day to generate flights for.
+//   - days: The number of days to generate flights for.
+//
+// Return:
+//   - The number of flights created.
+func GenerateFlights(from time.Time, days int) int {
+	created := 0
+	for _, schedule := range dao.GetScheduleDAO().FindAll() {
+		created += generateScheduleFlights(schedule, from, days)
+	}
+	return created
+}
+
+// GenerateFlightsPeriodically calls GenerateFlights at every interval, so the horizon keeps moving forward.
+//
+// Parameters:
+//   - interval: The time between two generations.
+func GenerateFlightsPeriodically(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		if created := GenerateFlights(time.Now(), ScheduleHorizon); created > 0 {
+			fmt.Printf("%d voos programados criados\n", created)
+		}
+	}
+}
+
+// generateScheduleFlights creates the flights of a schedule for a range of days.
+// Days are counted in the time zone of the schedule's source airport, and flights that would have
+// already departed are not created.
+//
+// Parameters:
+//   - schedule: The schedule generating the flights.
+//   - from: The first day to generate flights for.
+//   - days: The number of days to generate flights for.
+//
+// Return:
+//   - The number of flights created.
+func generateScheduleFlights(schedule *models.Schedule, from time.Time, days int) int {
+	src, err := dao.GetAirportDAO().FindById(schedule.SourceAirportId)
+	if err != nil {
+		return 0
+	}
+	dest, err := dao.GetAirportDAO().FindById(schedule.DestAirportId)
+	if err != nil {
+		return 0
+	}
+
+	src.Mu.RLock()
+	srcLocation := src.City.Location()
+	src.Mu.RUnlock()
+	dest.Mu.RLock()
+	destLocation := dest.City.Location()
+	dest.Mu.RUnlock()
+
+	now := time.Now()
+	first := from.In(srcLocation)
+	created := 0
+
+	for i := 0; i < days; i++ {
+		day := time.Date(first.Year(), first.Month(), first.Day()+i, 0, 0, 0, 0, srcLocation)
+		if !schedule.OperatesOn(day) {
+			continue
+		}
+		if _, err := dao.GetFlightDAO().FindById(schedule.InstanceId(day)); err == nil {
+			continue
+		}
+
+		flight := schedule.Instance(day, srcLocation, destLocation)
+		if flight.Departed(now) {
+			continue
+		}
+
+		dao.GetFlightDAO().Insert(flight)
+		flight.Start()
+		created++
+	}
+
+	return created
+}
+
+// AdminCreateSchedule creates a recurring flight and generates its flights for the days of the horizon.
+// Only admins may run it. On success, the schedule and the number of flights generated are sent back.
+//
+// Parameters:
+//   - data: An interface containing the request data. It should be of type models.CreateScheduleRequest.
+//   - conn: A *RequestConn representing the connection to the client.
+func AdminCreateSchedule(_ *models.Session, data interface{}, conn *RequestConn) {
+	var createRequest models.CreateScheduleRequest
+
+	jsonData, _ := json.Marshal(data)
+	json.Unmarshal(jsonData, &createRequest)
+
+	seatMap, seats, cabins, err := buildInventory(createRequest.SeatMap, createRequest.Seats, createRequest.Cabins)
+	if err != nil {
+		WriteNewResponse(models.Response{
+			Error: err.Error(),
+		}, conn)
+		return
+	}
+
+	schedule := &models.Schedule{
+		FlightNumber:    createRequest.FlightNumber,
+		SourceAirportId: createRequest.SourceAirportId,
+		DestAirportId:   createRequest.DestAirportId,
+		DepartureTime:   createRequest.DepartureTime,
+		DurationMinutes: createRequest.DurationMinutes,
+		Weekdays:        createRequest.Weekdays,
+		StartDate:       createRequest.StartDate,
+		EndDate:         createRequest.EndDate,
+		Seats:           seats,
+		Fare:            createRequest.Fare.WithDefaults(),
+		Cabins:          cabins,
+		SeatMap:         seatMap,
+		Overbooking:     createRequest.Overbooking,
+	}
+
+	if err := schedule.Validate(); err != nil {
+		WriteNewResponse(models.Response{
+			Error: err.Error(),
+		}, conn)
+		return
+	}
+
+	for _, id := range []uuid.UUID{schedule.SourceAirportId, schedule.DestAirportId} {
+		if _, err := dao.GetAirportDAO().FindById(id); err != nil {
+			WriteNewResponse(models.Response{
+				Error: fmt.Sprintf("airport not found: %s", id),
+			}, conn)
+			return
+		}
+	}
+
+	dao.GetScheduleDAO().Insert(schedule)
+	created := generateScheduleFlights(schedule, time.Now(), ScheduleHorizon)
+
+	fmt.Printf("Voo %s programado: %d voos criados\n", schedule.FlightNumber, created)
+
+	WriteNewResponse(models.Response{
+		Data: map[string]interface{}{
+			"schedule": schedule,
+			"flights":  created,
+		},
+	}, conn)
+}
+
+// AdminDeleteSchedule stops a recurring flight from generating new flights. Only admins may run it.
+// The flights it already generated are kept, along with their reservations and tickets,
+// and can be cancelled one by one with AdminCancelFlight.
+//
+// Parameters:
+//   - data: An interface containing the request data. It should be of type models.DeleteScheduleRequest.
+//   - conn: A *RequestConn representing the connection to the client.
+func AdminDeleteSchedule(_ *models.Session, data interface{}, conn *RequestConn) {
+	var deleteRequest models.DeleteScheduleRequest
+
+	jsonData, _ := json.Marshal(data)
+	json.Unmarshal(jsonData, &deleteRequest)
+
+	schedule, err := dao.GetScheduleDAO().FindById(deleteRequest.ScheduleId)
+	if err != nil {
+		WriteNewResponse(models.Response{
+			Error: err.Error(),
+		}, conn)
+		return
+	}
+
+	dao.GetScheduleDAO().Delete(schedule)
+
+	WriteNewResponse(models.Response{
+		Data: map[string]interface{}{
+			"msg": "success",
+		},
+	}, conn)
+}
@@ -0,0 +1,64 @@
+package server
+
+import (
+	"encoding/json"
+	"vendepass/internal/dao"
+	"vendepass/internal/models"
+)
+
+// SeatMap sends the seat map of a flight, telling for each seat whether it can still be chosen.
+// A seat is not available if it is held by a reservation or sold, or if the flight, or the seat's cabin on flights
+// with cabins, has no seats left, since reservations without a chosen seat also count against those seats.
+//
+// Parameters:
+//   - data: An interface containing the request data. It should be of type models.SeatMapRequest.
+//   - conn: A *RequestConn representing the connection to the client.
+func SeatMap(_ *models.Session, data interface{}, conn *RequestConn) {
+	var seatMapRequest models.SeatMapRequest
+
+	jsonData, _ := json.Marshal(data)
+	json.Unmarshal(jsonData, &seatMapRequest)
+
+	flight, err := dao.GetFlightDAO().FindById(seatMapRequest.FlightId)
+	if err != nil {
+		WriteNewResponse(models.Response{
+			Error: err.Error(),
+		}, conn)
+		return
+	}
+
+	flight.Mu.Lock()
+	if flight.SeatMap == nil {
+		flight.Mu.Unlock()
+		WriteNewResponse(models.Response{
+			Error: models.ErrNoSeatMap.Error(),
+		}, conn)
+		return
+	}
+
+	open := flight.Seats > 0 && !flight.Cancelled
+	seats := make([]map[string]interface{}, 0, flight.SeatMap.Capacity())
+
+	for _, seat := range flight.SeatMap.Seats() {
+		seatresponse := make(map[string]interface{})
+
+		seatresponse["Code"] = seat.Code
+		seatresponse["Row"] = seat.Row
+		seatresponse["Letter"] = seat.Letter
+		seatresponse["Cabin"] = seat.Cabin
+		seatresponse["Window"] = seat.Window
+		seatresponse["Aisle"] = seat.Aisle
+		seatresponse["Available"] = open && !flight.SeatTaken(seat.Code) && (len(flight.Cabins) == 0 || flight.SeatsLeft(seat.Cabin) > 0)
+		seats = append(seats, seatresponse)
+	}
+	seatsLeft := flight.Seats
+	flight.Mu.Unlock()
+
+	WriteNewResponse(models.Response{
+		Data: map[string]interface{}{
+			"FlightId":  flight.Id,
+			"SeatsLeft": seatsLeft,
+			"Seats":     seats,
+		},
+	}, conn)
+}
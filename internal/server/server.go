@@ -1,56 +1,102 @@
 package server
 
 import (
-	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"net"
+	"sync"
 	"time"
+	"vendepass/internal/auth"
+	"vendepass/internal/codec"
 	"vendepass/internal/dao"
 	"vendepass/internal/models"
+)
+
+// Codec frames every message exchanged with the clients. Its maximum message size can be
+// replaced before the server starts accepting connections.
+var Codec = codec.New(codec.DefaultMaxMessageSize)
+
+// Tokens signs the tokens handed to the clients at login and verifies the ones sent with each request.
+// It uses a random secret unless replaced before the server starts accepting connections, which is
+// needed for the tokens to survive a restart or to be verified by the gateway.
+var Tokens = auth.NewSigner(auth.RandomSecret(), auth.DefaultTokenTTL)
 
-	"github.com/google/uuid"
+// MaxRequestsInFlight bounds how many requests of a single connection are served at the same time.
+// Once it is reached, the server stops reading from that connection until one of them is answered,
+// so a client pipelining faster than it is served is slowed down instead of piling up goroutines.
+// It can be replaced before the server starts accepting connections.
+var MaxRequestsInFlight = 32
+
+var (
+	// ErrNotAuthorized is answered to requests whose token is missing, forged or whose session has ended.
+	ErrNotAuthorized = errors.New("not authorized")
+	// ErrTokenExpired is answered to requests whose token has expired; the client must log in again.
+	ErrTokenExpired = errors.New("token expired")
 )
 
-// HandleConn manages a single client connection. It reads incoming requests, processes them,
-// and sends responses back to the client. If an error occurs during the handling of a request,
-// an appropriate error response is sent back to the client.
+// HandleConn manages a single client connection. It keeps reading requests until the client
+// closes the connection, and serves each of them in its own goroutine, so a client can pipeline
+// many requests and match the responses by the request ID, even if they arrive out of order.
+// At most MaxRequestsInFlight requests are served at once; the next one is only read when one
+// of them has been answered.
+// If a request cannot be read, an appropriate error response is sent back to the client.
 //
 // Parameters:
 //   - conn: The net.Conn object representing the client connection.
 func HandleConn(conn net.Conn) {
-	defer conn.Close()
+	var writeMu sync.Mutex
+	var wg sync.WaitGroup
+	inFlight := make(chan struct{}, max(MaxRequestsInFlight, 1))
 
-	buffer := make([]byte, 2048)
-	n, err := conn.Read(buffer)
+	defer conn.Close()
+	defer wg.Wait()
 
-	if err != nil {
-		WriteNewResponse(models.Response{
-			Error: "error when reading the buffer",
-		}, conn)
-		return
-	}
+	for {
+		inFlight <- struct{}{}
 
-	if n > 0 {
 		var request models.Request
-		err = json.Unmarshal(buffer[:n], &request)
+		err := Codec.Read(conn, &request)
+
 		if err != nil {
-			WriteNewResponse(models.Response{
-				Error: "error on request format",
-			}, conn)
+			errorConn := newRequestConn(conn, &writeMu, "")
+			switch {
+			case codec.IsFormatError(err):
+				// The whole frame was consumed, so the stream is still aligned.
+				WriteNewResponse(models.Response{
+					Error: "error on request format",
+				}, errorConn)
+				<-inFlight
+				continue
+			case errors.Is(err, codec.ErrMessageTooLarge):
+				WriteNewResponse(models.Response{
+					Error: "request too large",
+				}, errorConn)
+			case errors.Is(err, io.EOF):
+			default:
+				WriteNewResponse(models.Response{
+					Error: "error when reading the buffer",
+				}, errorConn)
+			}
 			return
 		}
 
-		handleRequest(request, conn)
+		wg.Add(1)
+		go func(request models.Request) {
+			defer wg.Done()
+			defer func() { <-inFlight }()
+			handleRequest(request, newRequestConn(conn, &writeMu, request.Id))
+		}(request)
 	}
 }
 
-// CleanupSessions periodically checks for inactive sessions and reservations, and cleans them up.
-// It runs every minute and checks each session and its reservations against the provided timeout.
-// If a session or a reservation is inactive (i.e., its last activity time is older than the timeout),
-// it is deleted from the system.
+// CleanupSessions periodically checks for inactive sessions and cleans them up, along with the expired quotes.
+// It runs every minute and checks each session against the provided timeout.
+// If a session is inactive (i.e., its last activity time is older than the timeout), it is deleted from the system.
+// Reservations are not bound to the timeout: each one expires on its own when its hold does, see HoldDuration.
 //
 // Parameters:
-//   - timeout: The duration after which a session or a reservation is considered inactive.
+//   - timeout: The duration after which a session is considered inactive.
 func CleanupSessions(timeout time.Duration) {
 	ticker := time.NewTicker(time.Minute)
 	defer ticker.Stop()
@@ -59,106 +105,120 @@ func CleanupSessions(timeout time.Duration) {
 		for _, session := range dao.GetSessionDAO().FindAll() {
 			if time.Since(session.LastTimeActive) > timeout {
 				fmt.Printf("Encerrando sessão %s por inatividade\n", session.ID)
-				dao.GetSessionDAO().Delete(session)
-			}
-			session.Mu.Lock()
-			for key, reservation := range session.Reservations {
-				if time.Since(reservation.CreatedAt) > timeout {
-					fmt.Printf("Encerrando reserva %s por inatividade\n", reservation.Id)
-					flight, _ := dao.GetFlightDAO().FindById(reservation.FlightId)
-					flight.Mu.Lock()
-					flight.Seats++
-					flight.Mu.Unlock()
-					delete(session.Reservations, key)
-				}
+				endSession(session)
 			}
-			session.Mu.Unlock()
 		}
+
+		dao.GetQuoteDAO().DeleteExpired(time.Now())
 	}
 }
 
 // handleRequest processes incoming requests and dispatches them to the appropriate handler function.
-// It reads a request from the provided net.Conn, unmarshals it into a models.Request struct, and then
-// performs an action based on the request's Action field.
+// It looks the request's Action field up in the actions table and, unless the action is anonymous,
+// verifies the request's token and the role the action requires before the handler runs,
+// so handlers always receive a valid session of a client allowed to run them.
+// Unknown actions and rejected tokens are answered with an error, so a client waiting for the
+// response of that request ID is never left hanging.
 //
 // Parameters:
 //   - request: A models.Request struct containing the incoming request data.
-//   - conn: A net.Conn object representing the client connection.
-func handleRequest(request models.Request, conn net.Conn) {
-	switch request.Action {
-	case "login":
-		login(request.Data, conn)
-	case "get-user":
-		getUserBySessionToken(request.Auth, conn)
-	case "logout":
-		logout(request.Auth, conn)
-	case "all-routes":
-		AllRoutes(request.Auth, conn)
-	case "route":
-		Route(request.Auth, request.Data, conn)
-	case "flights":
-		Flights(request.Auth, request.Data, conn)
-	case "reservation":
-		Reservation(request.Auth, request.Data, conn)
-	case "cancel-reservation":
-		CancelReservation(request.Auth, request.Data, conn)
-	case "cart":
-		GetCart(request.Auth, conn)
-	case "buy":
-		BuyTicket(request.Auth, request.Data, conn)
-	case "cancel-buy":
-		CancelBuy(request.Auth, request.Data, conn)
-	case "tickets":
-		GetTickets(request.Auth, conn)
+//   - conn: A *RequestConn representing the connection the request came from.
+func handleRequest(request models.Request, conn *RequestConn) {
+	action, exists := actions[request.Action]
+	if !exists {
+		WriteNewResponse(models.Response{
+			Error: "unknown action",
+		}, conn)
+		return
+	}
+
+	var session *models.Session
+	if !action.anonymous {
+		var err error
+		session, err = Authenticate(request.Auth)
+		if err == nil {
+			err = authorize(session, action.role)
+		}
+		if err != nil {
+			WriteNewResponse(models.Response{
+				Error: err.Error(),
+			}, conn)
+			return
+		}
 	}
+
+	action.handler(session, request.Data, conn)
 }
 
-// WriteNewResponse sends a response to the client over the provided net.Conn connection.
+// WriteNewResponse sends a response to the client over the provided connection.
 // It first checks if the response's Data field is nil. If it is, it initializes it as an empty map.
-// Then, it marshals the response into JSON format. If the marshalling process encounters an error,
-// it logs the error and returns without sending any response.
-// After successfully marshalling the response, it writes the JSON data to the connection.
-// If there is an error while writing the data, it logs the error and returns without sending any response.
+// Then, it tags the response with the ID of the request being answered and writes it as a single
+// framed message using the server Codec, holding the connection's write lock.
+// If the response cannot be encoded, or is too large, an error response with the same ID is sent in its place,
+// so the client is not left waiting. If the response cannot be written, it logs the error and returns.
 //
 // Parameters:
 //   - response: A models.Response struct containing the response data to be sent to the client.
-//   - conn: A net.Conn object representing the client connection.
-func WriteNewResponse(response models.Response, conn net.Conn) {
+//   - conn: A *RequestConn representing the connection the request came from.
+func WriteNewResponse(response models.Response, conn *RequestConn) {
 	if response.Data == nil {
 		response.Data = make(map[string]interface{})
 	}
+	response.Id = conn.requestId
 
-	jsonData, err := json.Marshal(response)
+	frame, err := Codec.Frame(response)
 	if err != nil {
-		fmt.Println("Error marshalling response:", err)
-		return
+		fmt.Println("Error encoding response:", err)
+
+		reason := "response could not be encoded"
+		if errors.Is(err, codec.ErrMessageTooLarge) {
+			reason = "response too large"
+		}
+		frame, err = Codec.Frame(models.Response{
+			Id:    response.Id,
+			Error: reason,
+			Data:  make(map[string]interface{}),
+		})
+		if err != nil {
+			fmt.Println("Error encoding response:", err)
+			return
+		}
 	}
-	_, err = conn.Write(jsonData)
-	if err != nil {
+
+	conn.writeMu.Lock()
+	defer conn.writeMu.Unlock()
+
+	if _, err := conn.conn.Write(frame); err != nil {
 		fmt.Println("Error writing response:", err)
 	}
 }
 
-// SessionIfExists checks if a session exists for the given token.
-// If a session is found, it updates the session's last activity time and returns the session along with a boolean value of true.
-// If no session is found or an error occurs during the process, it returns nil and false.
+// Authenticate verifies a token and returns the session it was issued for.
+// The token must be signed by Tokens and not expired, and its session must still exist and belong to
+// the client named by the token. On success, the session's last activity time is updated.
 //
 // Parameters:
-//   - token: A string representing the session token to be checked.
+//   - token: A string representing the token sent with the request.
 //
 // Return:
-//   - *models.Session: A pointer to the found session if it exists, or nil if no session is found or an error occurs.
-//   - bool: A boolean value indicating whether a session was found (true) or not (false).
-func SessionIfExists(token string) (*models.Session, bool) {
-	uuid, err := uuid.Parse(token)
-	if err != nil {
-		return nil, false
+//   - *models.Session: A pointer to the session of the token, or nil if the token is rejected.
+//   - error: ErrTokenExpired if the token has expired, ErrNotAuthorized if it is rejected for any other reason,
+//     or nil if the token is valid.
+func Authenticate(token string) (*models.Session, error) {
+	claims, err := Tokens.Verify(token)
+	if errors.Is(err, auth.ErrExpiredToken) {
+		return nil, ErrTokenExpired
 	}
-	session, err := dao.GetSessionDAO().FindById(uuid)
 	if err != nil {
-		return nil, false
+		return nil, ErrNotAuthorized
 	}
+
+	session, err := dao.GetSessionDAO().FindById(claims.SessionId)
+	if err != nil || session.ClientID != claims.ClientId {
+		return nil, ErrNotAuthorized
+	}
+
 	session.LastTimeActive = time.Now()
 	dao.GetSessionDAO().Update(session)
-	return session, true
+	return session, nil
 }
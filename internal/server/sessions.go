@@ -0,0 +1,100 @@
+package server
+
+import (
+	"encoding/json"
+	"sort"
+	"vendepass/internal/dao"
+	"vendepass/internal/models"
+)
+
+// Sessions lists every session of the authenticated client, so the client can tell its devices apart
+// and revoke the ones it no longer uses. The session making the request is flagged as current.
+//
+// Parameters:
+//   - session: The session of the client making the request, verified before the handler runs.
+//   - conn: A *RequestConn representing the connection to the client.
+func Sessions(session *models.Session, _ interface{}, conn *RequestConn) {
+	sessions := dao.GetSessionDAO().FindByClientId(session.ClientID)
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].CreatedAt.Before(sessions[j].CreatedAt)
+	})
+
+	responseData := make([]map[string]interface{}, 0, len(sessions))
+
+	for _, s := range sessions {
+		sessionresponse := make(map[string]interface{})
+
+		sessionresponse["Id"] = s.ID
+		sessionresponse["Device"] = s.Device
+		sessionresponse["CreatedAt"] = s.CreatedAt
+		sessionresponse["LastTimeActive"] = s.LastTimeActive
+		sessionresponse["Current"] = s.ID == session.ID
+		responseData = append(responseData, sessionresponse)
+	}
+
+	WriteNewResponse(models.Response{
+		Data: map[string]interface{}{
+			"Sessions": responseData,
+		},
+	}, conn)
+}
+
+// RevokeSession ends one of the sessions of the authenticated client, e.g. one left open on a lost device.
+// Only sessions of the same client can be revoked; revoking the current session works like a logout.
+//
+// Parameters:
+//   - session: The session of the client making the request, verified before the handler runs.
+//   - data: An interface containing the request data. It should be of type models.RevokeSessionRequest.
+//   - conn: A *RequestConn representing the connection to the client.
+func RevokeSession(session *models.Session, data interface{}, conn *RequestConn) {
+	var revokeRequest models.RevokeSessionRequest
+
+	jsonData, _ := json.Marshal(data)
+	json.Unmarshal(jsonData, &revokeRequest)
+
+	revoked, err := dao.GetSessionDAO().FindById(revokeRequest.SessionId)
+	if err != nil || revoked.ClientID != session.ClientID {
+		WriteNewResponse(models.Response{
+			Error: "session not found",
+		}, conn)
+		return
+	}
+
+	endSession(revoked)
+
+	WriteNewResponse(models.Response{
+		Data: map[string]interface{}{
+			"msg": "success",
+		},
+	}, conn)
+}
+
+// refreshToken issues a new token for the session of the request, with a new expiry time.
+// The token being refreshed must still be valid, so clients should refresh it before it expires.
+//
+// Parameters:
+//   - session: The session of the client making the request, verified before the handler runs.
+//   - conn: A *RequestConn representing the connection to the client.
+func refreshToken(session *models.Session, _ interface{}, conn *RequestConn) {
+	token, claims := Tokens.Issue(session.ID, session.ClientID)
+
+	WriteNewResponse(models.Response{
+		Data: map[string]interface{}{
+			"token":     token,
+			"expiresAt": claims.Expiry(),
+		},
+	}, conn)
+}
+
+// endSession deletes a session. When it was the last session of its client, the client's cart is
+// released as well, since no one is left to buy the reserved seats.
+//
+// Parameters:
+//   - session: A pointer to the session to be ended.
+func endSession(session *models.Session) {
+	dao.GetSessionDAO().Delete(session)
+
+	if len(dao.GetSessionDAO().FindByClientId(session.ClientID)) == 0 {
+		releaseCart(session.ClientID)
+	}
+}
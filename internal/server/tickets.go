@@ -2,152 +2,306 @@ package server
 
 import (
 	"encoding/json"
-	"net"
+	"errors"
+	"fmt"
+	"time"
 	"vendepass/internal/dao"
 	"vendepass/internal/models"
 
 	"github.com/google/uuid"
 )
 
+// ErrTicketNotFound is returned when a ticket is not, or no longer, among the tickets of its client.
+var ErrTicketNotFound = errors.New("ticket not found")
+
+// ErrFlightDeparted is returned when a ticket is cancelled after its flight has departed.
+var ErrFlightDeparted = errors.New("flight already departed")
+
 // GetTickets retrieves all tickets associated with the authenticated client.
-// It sends a response containing a list of tickets with their respective source, destination, and ID.
+// It sends a response containing the client's bookings, each with its legs, and the flat list of its valid tickets
+// with their respective source, destination, and ID.
 //
 // Parameters:
-//   - auth: A string representing the authentication token.
-//   - conn: A net.Conn object representing the connection to the client.
+//   - session: The session of the client making the request, verified before the handler runs.
+//   - conn: A *RequestConn representing the connection to the client.
 //
 // Return:
 //   - No return value.
-func GetTickets(auth string, conn net.Conn) {
-	session, exists := SessionIfExists(auth)
+func GetTickets(session *models.Session, _ interface{}, conn *RequestConn) {
+	client, _ := dao.GetClientDAO().FindById(session.ClientID)
 
-	if !exists {
+	WriteNewResponse(models.Response{
+		Data: map[string]interface{}{
+			"Bookings": bookingsResponse(client.Id),
+			"Tickets":  ticketsResponse(client),
+		},
+	}, conn)
+}
+
+// GetUserTickets retrieves all tickets and bookings of any client, found by its username. Only admins may run it.
+//
+// Parameters:
+//   - data: An interface containing the request data. It should be of type models.UserTicketsRequest.
+//   - conn: A *RequestConn representing the connection to the client.
+func GetUserTickets(_ *models.Session, data interface{}, conn *RequestConn) {
+	var ticketsRequest models.UserTicketsRequest
+
+	jsonData, _ := json.Marshal(data)
+	json.Unmarshal(jsonData, &ticketsRequest)
+
+	client, err := dao.GetClientDAO().FindByUsername(ticketsRequest.Username)
+	if err != nil {
 		WriteNewResponse(models.Response{
-			Error: "not authorized",
+			Error: "client not found",
 		}, conn)
 		return
 	}
-	responseData := make([]map[string]interface{}, 0)
 
-	client, _ := dao.GetClientDAO().FindById(session.ClientID)
+	WriteNewResponse(models.Response{
+		Data: map[string]interface{}{
+			"Bookings": bookingsResponse(client.Id),
+			"Tickets":  ticketsResponse(client),
+		},
+	}, conn)
+}
+
+// ticketsResponse builds the list of tickets of a client sent back to the users.
+//
+// Parameters:
+//   - client: A pointer to the client owning the tickets.
+//
+// Return:
+//   - A slice of maps, one per ticket, as built by ticketResponse.
+func ticketsResponse(client *models.Client) []map[string]interface{} {
+	responseData := make([]map[string]interface{}, 0)
 
 	for _, ticket := range client.Client_flights {
-		flight, _ := dao.GetFlightDAO().FindById(ticket.FlightId)
+		responseData = append(responseData, ticketResponse(ticket))
+	}
 
-		src, _ := dao.GetAirportDAO().FindById(flight.SourceAirportId)
-		dest, _ := dao.GetAirportDAO().FindById(flight.DestAirportId)
+	return responseData
+}
 
-		flightresponse := make(map[string]interface{})
+// ticketResponse describes a ticket to the users, with the source and destination cities of its flight,
+// its ID, the passenger flying on it, if not the client, the cabin and fare class and price paid, and the chosen seat, if any. The cities are left out if the flight or its airports no longer exist.
+//
+// Parameters:
+//   - ticket: A pointer to the ticket.
+//
+// Return:
+//   - A map with the keys "Src", "Dest", "Id", "Passenger", "Cabin", "FareClass", "Price" and "Seat".
+func ticketResponse(ticket *models.Ticket) map[string]interface{} {
+	flightresponse := make(map[string]interface{})
 
-		flightresponse["Src"] = src.City
-		flightresponse["Dest"] = dest.City
-		flightresponse["Id"] = ticket.Id
-		responseData = append(responseData, flightresponse)
+	if flight, err := dao.GetFlightDAO().FindById(ticket.FlightId); err == nil {
+		if src, found := airportCity(flight.SourceAirportId); found {
+			flightresponse["Src"] = src
+		}
+		if dest, found := airportCity(flight.DestAirportId); found {
+			flightresponse["Dest"] = dest
+		}
 	}
 
-	WriteNewResponse(models.Response{
-		Data: map[string]interface{}{
-			"Tickets": responseData,
-		},
-	}, conn)
+	flightresponse["Id"] = ticket.Id
+	flightresponse["Passenger"] = ticket.Passenger
+	flightresponse["Cabin"] = ticket.Cabin
+	flightresponse["FareClass"] = ticket.FareClass
+	flightresponse["Price"] = ticket.Price
+	flightresponse["Seat"] = ticket.Seat
+
+	return flightresponse
 }
 
 // BuyTicket handles the process of purchasing a ticket for an authenticated client.
-// It checks if the client is authorized, validates the reservation, updates the flight and client data,
-// and sends a response indicating success or failure.
+// It checks if the client is authorized, validates the reservation, and charges its price through the payment provider.
+// The ticket is only issued once the payment is captured: if it is declined or times out, the reservation goes back
+// to the client's cart, still holding the seat. The ticket is bought as an order of its own, like a checkout of a single reservation,
+// along with the other reservations of its group when it was made for several passengers.
+// Reservations whose hold has expired are not charged, and their seats are released.
+// Then it updates the flight and client data, and sends a response indicating success or failure.
 //
 // Parameters:
-//   - auth: A string representing the authentication token.
+//   - session: The session of the client making the request, verified before the handler runs.
 //   - data: An interface containing the necessary data for purchasing a ticket.
-//   - conn: A net.Conn object representing the connection to the client.
+//   - conn: A *RequestConn representing the connection to the client.
 //
 // Return:
 //   - No return value.
-func BuyTicket(auth string, data interface{}, conn net.Conn) {
-	session, exists := SessionIfExists(auth)
-
-	if !exists {
-		WriteNewResponse(models.Response{
-			Error: "not authorized",
-		}, conn)
-		return
-	}
-
+func BuyTicket(session *models.Session, data interface{}, conn *RequestConn) {
 	var buyTicket models.BuyTicket
 
 	jsonData, _ := json.Marshal(data)
 	json.Unmarshal(jsonData, &buyTicket)
 
-	res, exists := session.Reservations[buyTicket.ReservationId]
+	cart := dao.GetCartDAO().FindOrCreate(session.ClientID)
+
+	cart.Mu.Lock()
+	res, exists := cart.Reservations[buyTicket.ReservationId]
+	delete(cart.Reservations, buyTicket.ReservationId)
+	reservations := []models.Reservation{res}
+	if exists && res.GroupId != uuid.Nil {
+		for id, reservation := range cart.Reservations {
+			if reservation.GroupId == res.GroupId {
+				reservations = append(reservations, reservation)
+				delete(cart.Reservations, id)
+			}
+		}
+	}
+	cart.Mu.Unlock()
 
 	if !exists {
 		WriteNewResponse(models.Response{
 			Error: "reservation do not exists",
 		}, conn)
-	} else {
-		client, _ := dao.GetClientDAO().FindById(res.ClientId)
-		flight, _ := dao.GetFlightDAO().FindById(res.FlightId)
-
-		flight.Mu.Lock()
-
-		flight.Passengers = append(flight.Passengers, res.Ticket)
-
-		flight.Mu.Unlock()
-
-		client.Client_flights = append(client.Client_flights, res.Ticket)
+		return
+	}
 
-		delete(session.Reservations, res.Id)
+	booking, err := buyReservations(uuid.New(), reservations)
+	if err != nil {
+		for _, reservation := range reservations {
+			restoreReservation(reservation)
+		}
+		WriteNewResponse(models.Response{
+			Error: err.Error(),
+		}, conn)
+		return
 	}
 
 	WriteNewResponse(models.Response{
 		Data: map[string]interface{}{
-			"msg": "success",
+			"msg":       "success",
+			"Reference": booking.Reference,
 		},
 	}, conn)
 }
 
 // CancelBuy handles the cancellation of a ticket for an authenticated client.
-// It checks if the client is authorized, finds the ticket to be canceled, updates the flight and client data,
-// and sends a response indicating success or failure.
+// It checks if the client is authorized, finds the ticket to be canceled, refunds its payment, updates the flight and client data,
+// and sends a response indicating success or failure. Tickets of flights that have already departed cannot be cancelled.
 //
 // Parameters:
-//   - auth: A string representing the authentication token. This is used to identify the client.
+//   - session: The session of the client making the request, verified before the handler runs.
 //   - data: An interface containing the necessary data for canceling a ticket.
-//   - conn: A net.Conn object representing the connection to the client. This is used to send a response.
+//   - conn: A *RequestConn representing the connection to the client. This is used to send a response.
 //
 // Return:
 //   - No return value.
-func CancelBuy(auth string, data interface{}, conn net.Conn) {
-	session, exists := SessionIfExists(auth)
+func CancelBuy(session *models.Session, data interface{}, conn *RequestConn) {
+	var cancelReservation models.CancelBuyRequest
 
-	if !exists {
+	jsonData, _ := json.Marshal(data)
+	json.Unmarshal(jsonData, &cancelReservation)
+
+	if err := cancelTicket(session.ClientID, cancelReservation.TicketId); err != nil {
 		WriteNewResponse(models.Response{
-			Error: "not authorized",
+			Error: err.Error(),
 		}, conn)
 		return
 	}
 
-	var cancelReservation models.CancelBuyRequest
+	WriteNewResponse(models.Response{
+		Data: map[string]interface{}{
+			"msg": "success",
+		},
+	}, conn)
+}
 
-	jsonData, _ := json.Marshal(data)
-	json.Unmarshal(jsonData, &cancelReservation)
+// cancelTicket removes a bought ticket from its client and from its flight, returning the seat to the flight's sale or waitlist,
+// refunds the ticket's payment through the payment provider, marks it cancelled in its booking, and stores both of them.
+// Tickets of flights that have already departed cannot be cancelled, since they were flown.
+// The ticket is taken from its client first, so when the same ticket is cancelled twice at once, only one of them
+// refunds it and releases its seat.
+//
+// Parameters:
+//   - clientId: The ID of the client owning the ticket.
+//   - ticketId: The ID of the ticket to be canceled.
+//
+// Return:
+//   - ErrTicketNotFound if the client does not own the ticket, or it was already cancelled.
+//   - ErrFlightDeparted if the flight of the ticket has already departed.
+func cancelTicket(clientId uuid.UUID, ticketId uuid.UUID) error {
+	client, err := dao.GetClientDAO().FindById(clientId)
+	if err != nil {
+		return ErrTicketNotFound
+	}
 
-	client, _ := dao.GetClientDAO().FindById(session.ClientID)
-	ticket := findTicketById(client.Client_flights, cancelReservation.TicketId)
-	client.Client_flights = removeTicketByID(client.Client_flights, ticket.Id)
+	owned := findTicketById(client.Client_flights, ticketId)
+	if owned == nil {
+		return ErrTicketNotFound
+	}
+	if ticketDeparted(owned) {
+		return ErrFlightDeparted
+	}
+
+	ticket, err := takeTicket(clientId, ticketId)
+	if err != nil {
+		return err
+	}
 
-	flight, _ := dao.GetFlightDAO().FindById(ticket.FlightId)
+	refundTicket(ticket)
+	cancelBookingTicket(ticket)
+
+	flight, err := dao.GetFlightDAO().FindById(ticket.FlightId)
+	if err != nil {
+		return nil
+	}
 
 	flight.Mu.Lock()
-	flight.Seats++
+	flight.ReleaseSeat(ticket)
 	flight.Passengers = removeTicketByID(flight.Passengers, ticket.Id)
 	flight.Mu.Unlock()
 
-	WriteNewResponse(models.Response{
-		Data: map[string]interface{}{
-			"msg": "success",
-		},
-	}, conn)
+	if err := dao.GetFlightDAO().Update(flight); err != nil {
+		fmt.Println("Error storing ticket cancellation:", err)
+	}
+
+	promoteWaitlist(flight)
+	return nil
+}
+
+// ticketDeparted reports whether the flight of a ticket has already departed, read under the flight's lock.
+// Tickets of flights that no longer exist have not departed.
+func ticketDeparted(ticket *models.Ticket) bool {
+	flight, err := dao.GetFlightDAO().FindById(ticket.FlightId)
+	if err != nil {
+		return false
+	}
+
+	flight.Mu.Lock()
+	defer flight.Mu.Unlock()
+	return flight.Departed(time.Now())
+}
+
+// takeTicket removes a ticket from the tickets of its client and stores the client, under the lock of the client DAO,
+// so a ticket is taken only once however many requests try to take it.
+//
+// Parameters:
+//   - clientId: The ID of the client owning the ticket.
+//   - ticketId: The ID of the ticket to be taken.
+//
+// Return:
+//   - A pointer to the ticket taken from the client.
+//   - ErrTicketNotFound if the client does not exist, does not own the ticket, or it was already taken.
+func takeTicket(clientId uuid.UUID, ticketId uuid.UUID) (*models.Ticket, error) {
+	var ticket *models.Ticket
+
+	_, err := dao.GetClientDAO().Modify(clientId, func(client *models.Client) error {
+		ticket = findTicketById(client.Client_flights, ticketId)
+		if ticket == nil {
+			return ErrTicketNotFound
+		}
+		client.Client_flights = removeTicketByID(client.Client_flights, ticketId)
+		return nil
+	})
+	if err != nil && ticket != nil {
+		// The ticket was found, but the client could not be stored without it.
+		fmt.Println("Error storing ticket cancellation:", err)
+		return ticket, nil
+	}
+	if err != nil {
+		return nil, ErrTicketNotFound
+	}
+	return ticket, nil
 }
 
 // findTicketById searches for a ticket with the given ID in a list of tickets.
@@ -175,12 +329,12 @@ func findTicketById(tickets []*models.Ticket, id uuid.UUID) *models.Ticket {
 //   - id: A uuid.UUID representing the ID of the ticket to find and remove.
 //
 // Return:
-//   - A slice of pointers to models.Ticket representing the updated list of tickets after removing the ticket with the given ID.
-//     If no ticket with the given ID is found, the original list is returned.
+//   - A new slice of pointers to models.Ticket representing the updated list of tickets after removing the ticket with the given ID,
+//     so a copy of the list taken before stays intact. If no ticket with the given ID is found, the original list is returned.
 func removeTicketByID(tickets []*models.Ticket, id uuid.UUID) []*models.Ticket {
 	for i, ticket := range tickets {
 		if ticket.Id == id {
-			return append(tickets[:i], tickets[i+1:]...)
+			return append(append(make([]*models.Ticket, 0, len(tickets)-1), tickets[:i]...), tickets[i+1:]...)
 		}
 	}
 	return tickets
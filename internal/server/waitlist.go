@@ -0,0 +1,239 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+	"vendepass/internal/dao"
+	"vendepass/internal/models"
+
+	"github.com/google/uuid"
+)
+
+// waitlistMu serializes the promotions of waitlisted clients, so an entry is never served twice.
+var waitlistMu sync.Mutex
+
+// JoinWaitlist puts the authenticated client on the waitlist of a full flight, for one seat or for a seat for each
+// of the passengers named. Only flights that can no longer be reserved in the cabin asked for, even by overbooking them,
+// have a waitlist. When seats are freed, the client is given the reservations automatically and notified.
+// On success, the ID of the entry and its position on the waitlist are sent back.
+//
+// Parameters:
+//   - session: The session of the client making the request, verified before the handler runs.
+//   - data: An interface containing the request data. It should be of type models.JoinWaitlistRequest.
+//   - conn: A *RequestConn representing the connection to the client.
+func JoinWaitlist(session *models.Session, data interface{}, conn *RequestConn) {
+	var joinRequest models.JoinWaitlistRequest
+
+	jsonData, _ := json.Marshal(data)
+	json.Unmarshal(jsonData, &joinRequest)
+
+	if err := models.ValidatePassengers(joinRequest.Passengers); err != nil {
+		WriteNewResponse(models.Response{
+			Error: err.Error(),
+		}, conn)
+		return
+	}
+
+	flight, err := dao.GetFlightDAO().FindById(joinRequest.FlightId)
+	if err != nil {
+		WriteNewResponse(models.Response{
+			Error: err.Error(),
+		}, conn)
+		return
+	}
+
+	entry := &models.WaitlistEntry{
+		ClientId:   session.ClientID,
+		FlightId:   flight.Id,
+		Cabin:      joinRequest.Cabin,
+		FareClass:  joinRequest.FareClass,
+		Passengers: joinRequest.Passengers,
+		CreatedAt:  time.Now(),
+	}
+
+	flight.Mu.Lock()
+	var joinErr error
+	_, _, _, quoteErr := flight.Quote(entry.Cabin, entry.FareClass)
+	switch {
+	case flight.Cancelled:
+		joinErr = models.ErrFlightCancelled
+	case flight.Departed(time.Now()):
+		joinErr = errors.New("flight already departed")
+	case quoteErr != nil:
+		joinErr = quoteErr
+	case flight.Bookable(entry.Cabin, entry.Seats()):
+		joinErr = errors.New("flight is not full")
+	}
+	flight.Mu.Unlock()
+
+	if joinErr != nil {
+		WriteNewResponse(models.Response{
+			Error: joinErr.Error(),
+		}, conn)
+		return
+	}
+
+	for _, other := range dao.GetWaitlistDAO().FindByClientId(session.ClientID) {
+		if other.FlightId == flight.Id {
+			WriteNewResponse(models.Response{
+				Error: "already on the waitlist",
+			}, conn)
+			return
+		}
+	}
+
+	dao.GetWaitlistDAO().Insert(entry)
+
+	fmt.Printf("Cliente %s entrou na lista de espera do voo %s\n", session.ClientID, flight.Id)
+
+	WriteNewResponse(models.Response{
+		Data: map[string]interface{}{
+			"EntryId":  entry.Id,
+			"Position": waitlistPosition(entry),
+		},
+	}, conn)
+}
+
+// LeaveWaitlist removes an entry of the authenticated client from the waitlist of its flight.
+//
+// Parameters:
+//   - session: The session of the client making the request, verified before the handler runs.
+//   - data: An interface containing the request data. It should be of type models.LeaveWaitlistRequest.
+//   - conn: A *RequestConn representing the connection to the client.
+func LeaveWaitlist(session *models.Session, data interface{}, conn *RequestConn) {
+	var leaveRequest models.LeaveWaitlistRequest
+
+	jsonData, _ := json.Marshal(data)
+	json.Unmarshal(jsonData, &leaveRequest)
+
+	entry, err := dao.GetWaitlistDAO().FindById(leaveRequest.EntryId)
+	if err != nil || entry.ClientId != session.ClientID || !dao.GetWaitlistDAO().Delete(entry) {
+		WriteNewResponse(models.Response{
+			Error: "waitlist entry not found",
+		}, conn)
+		return
+	}
+
+	WriteNewResponse(models.Response{
+		Data: map[string]interface{}{
+			"msg": "success",
+		},
+	}, conn)
+}
+
+// Waitlist lists the waitlist entries of the authenticated client, each with its position on the waitlist of its flight.
+//
+// Parameters:
+//   - session: The session of the client making the request, verified before the handler runs.
+//   - conn: A *RequestConn representing the connection to the client.
+func Waitlist(session *models.Session, _ interface{}, conn *RequestConn) {
+	entries := dao.GetWaitlistDAO().FindByClientId(session.ClientID)
+	responseData := make([]map[string]interface{}, 0, len(entries))
+
+	for _, entry := range entries {
+		entryresponse := make(map[string]interface{})
+
+		entryresponse["Id"] = entry.Id
+		entryresponse["FlightId"] = entry.FlightId
+		entryresponse["Cabin"] = entry.Cabin
+		entryresponse["FareClass"] = entry.FareClass
+		entryresponse["Passengers"] = entry.Passengers
+		entryresponse["Position"] = waitlistPosition(entry)
+		entryresponse["CreatedAt"] = entry.CreatedAt
+		responseData = append(responseData, entryresponse)
+	}
+
+	WriteNewResponse(models.Response{
+		Data: map[string]interface{}{
+			"Waitlist": responseData,
+		},
+	}, conn)
+}
+
+// waitlistPosition returns the position of an entry on the waitlist of its flight, starting from 1.
+func waitlistPosition(entry *models.WaitlistEntry) int {
+	for i, other := range dao.GetWaitlistDAO().FindByFlightId(entry.FlightId) {
+		if other.Id == entry.Id {
+			return i + 1
+		}
+	}
+	return 0
+}
+
+// promoteWaitlist gives the seats freed on a flight to the clients on its waitlist, in the order they joined it.
+// Each entry that fits in the seats left is turned into reservations in its client's cart, at the current price,
+// and the client is notified; entries waiting for more seats than are left keep their place for the next seats freed.
+// Entries of clients that no longer exist are dropped.
+//
+// Parameters:
+//   - flight: A pointer to the flight whose seats were freed. Its lock must not be held.
+func promoteWaitlist(flight *models.Flight) {
+	waitlistMu.Lock()
+	defer waitlistMu.Unlock()
+
+	for _, entry := range dao.GetWaitlistDAO().FindByFlightId(flight.Id) {
+		if _, err := dao.GetClientDAO().FindById(entry.ClientId); err != nil {
+			dao.GetWaitlistDAO().Delete(entry)
+			continue
+		}
+
+		flight.Mu.Lock()
+		open := !flight.Cancelled && !flight.Departed(time.Now())
+		bookable := open && flight.Bookable(entry.Cabin, entry.Seats())
+		var price models.Price
+		var err error
+		if bookable {
+			price, err = lockedPrice(entry.ClientId, flight, entry.Cabin, entry.FareClass, nil)
+		}
+		flight.Mu.Unlock()
+
+		if !open {
+			return
+		}
+		if !bookable || err != nil {
+			continue
+		}
+
+		expiresAt := time.Now().Add(HoldDuration)
+		scheduleHold(entry.ClientId, expiresAt)
+
+		request := &models.ReservationRequest{
+			Cart:       dao.GetCartDAO().FindOrCreate(entry.ClientId),
+			Cabin:      entry.Cabin,
+			FareClass:  entry.FareClass,
+			Passengers: entry.Passengers,
+			Seats:      make([]string, len(entry.Passengers)),
+			Price:      &price,
+			ExpiresAt:  expiresAt,
+		}
+		if len(entry.Passengers) > 0 {
+			request.GroupId = uuid.New()
+		}
+
+		if err := flight.Submit(request); err != nil {
+			continue
+		}
+
+		dao.GetWaitlistDAO().Delete(entry)
+		notify(entry.ClientId, "A seat on flight %s became available and was reserved for you from the waitlist.", flight.Id)
+	}
+}
+
+// dropWaitlist removes every entry of the waitlist of a cancelled flight, notifying the clients that were waiting.
+//
+// Parameters:
+//   - flight: A pointer to the cancelled flight.
+//   - reason: The reason of the cancellation, included in the notifications.
+func dropWaitlist(flight *models.Flight, reason string) {
+	waitlistMu.Lock()
+	defer waitlistMu.Unlock()
+
+	for _, entry := range dao.GetWaitlistDAO().FindByFlightId(flight.Id) {
+		if dao.GetWaitlistDAO().Delete(entry) {
+			notify(entry.ClientId, "You were removed from the waitlist of flight %s: %s.", flight.Id, reason)
+		}
+	}
+}
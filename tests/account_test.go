@@ -0,0 +1,78 @@
+package tests
+
+import (
+	"testing"
+	"vendepass/internal/client"
+	"vendepass/internal/codec"
+	"vendepass/internal/dao"
+	"vendepass/internal/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAccountLifecycle(t *testing.T) {
+	address := startServer(t)
+
+	conn, err := client.Dial("tcp", address, codec.New(0), 0)
+	assert.NoError(t, err, "expected no error, got %v", err)
+	defer conn.Close()
+
+	response, err := conn.Do(models.Request{Action: "register", Data: models.RegisterRequest{
+		Name: "Lucas Rocha", Username: "lucasrocha", Password: "senhaSegura123",
+	}})
+	assert.NoError(t, err, "expected no error, got %v", err)
+	assert.Empty(t, response.Error, "expected no error, got %s", response.Error)
+
+	response, _ = conn.Do(models.Request{Action: "register", Data: models.RegisterRequest{
+		Name: "Outro Lucas", Username: "lucasrocha", Password: "outraSenha123",
+	}})
+	assert.Equal(t, dao.ErrUsernameTaken.Error(), response.Error)
+
+	response, _ = conn.Do(models.Request{Action: "login", Data: models.LoginCredentials{
+		Username: "lucasrocha", Password: "senhaSegura123",
+	}})
+	token, _ := response.Data["token"].(string)
+	assert.NotEmpty(t, token, "expected a session token, got %v", response)
+
+	response, _ = conn.Do(models.Request{Action: "update-profile", Auth: token, Data: models.UpdateProfileRequest{
+		Username: "lucas.rocha",
+	}})
+	assert.Empty(t, response.Error, "expected no error, got %s", response.Error)
+
+	_, err = dao.GetClientDAO().FindByUsername("lucasrocha")
+	assert.Error(t, err, "old username should be free after the update")
+
+	response, _ = conn.Do(models.Request{Action: "change-password", Auth: token, Data: models.ChangePasswordRequest{
+		CurrentPassword: "senhaErrada", NewPassword: "novaSenha123",
+	}})
+	assert.Equal(t, "invalid credentials", response.Error)
+
+	response, _ = conn.Do(models.Request{Action: "change-password", Auth: token, Data: models.ChangePasswordRequest{
+		CurrentPassword: "senhaSegura123", NewPassword: "novaSenha123",
+	}})
+	assert.Empty(t, response.Error, "expected no error, got %s", response.Error)
+
+	response, _ = conn.Do(models.Request{Action: "delete-account", Auth: token, Data: models.DeleteAccountRequest{
+		Password: "novaSenha123",
+	}})
+	assert.Empty(t, response.Error, "expected no error, got %s", response.Error)
+
+	_, err = dao.GetClientDAO().FindByUsername("lucas.rocha")
+	assert.Error(t, err, "deleted client should not be found")
+
+	response, _ = conn.Do(models.Request{Action: "get-user", Auth: token})
+	assert.NotEmpty(t, response.Error, "sessions of a deleted client should be closed")
+}
+
+func TestRegisterRejectsShortPassword(t *testing.T) {
+	address := startServer(t)
+
+	conn, err := client.Dial("tcp", address, codec.New(0), 0)
+	assert.NoError(t, err, "expected no error, got %v", err)
+	defer conn.Close()
+
+	response, _ := conn.Do(models.Request{Action: "register", Data: models.RegisterRequest{
+		Name: "Lucas Rocha", Username: "lucascurto", Password: "123",
+	}})
+	assert.NotEmpty(t, response.Error, "expected a short password to be rejected")
+}
@@ -0,0 +1,234 @@
+package tests
+
+import (
+	"testing"
+	"time"
+	"vendepass/internal/client"
+	"vendepass/internal/codec"
+	"vendepass/internal/dao"
+	"vendepass/internal/models"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAdminAirportLifecycle(t *testing.T) {
+	loadAirportDAO(t)
+	address := startServer(t)
+
+	conn, err := client.Dial("tcp", address, codec.New(0), 0)
+	assert.NoError(t, err, "expected no error, got %v", err)
+	defer conn.Close()
+
+	admin := registerAdmin(t, conn, "miltonnascimento")
+
+	response, _ := conn.Do(models.Request{Action: "admin-create-airport", Auth: admin, Data: models.CreateAirportRequest{
+		Name: "Aeroporto de Três Pontas", City: models.City{Name: "Três Pontas", State: "MG", Country: "Brasil", Latitude: -21.36, Longitude: -45.51},
+	}})
+	assert.Empty(t, response.Error, "expected no error, got %s", response.Error)
+
+	response, _ = conn.Do(models.Request{Action: "admin-create-airport", Auth: admin, Data: models.CreateAirportRequest{
+		Name: "Outro Aeroporto", City: models.City{Name: "Três Pontas"},
+	}})
+	assert.NotEmpty(t, response.Error, "a city should only be served by one airport")
+
+	src := dao.GetAirportDAO().FindByName("Três Pontas")
+	assert.NotNil(t, src, "airport should be stored")
+
+	response, _ = conn.Do(models.Request{Action: "admin-create-airport", Auth: admin, Data: models.CreateAirportRequest{
+		Name: "Aeroporto de Varginha", City: models.City{Name: "Varginha", Latitude: -21.55, Longitude: -45.43},
+	}})
+	assert.Empty(t, response.Error, "expected no error, got %s", response.Error)
+	dest := dao.GetAirportDAO().FindByName("Varginha")
+
+	latitude, longitude := float32(-21.59), float32(-45.47)
+	response, _ = conn.Do(models.Request{Action: "admin-update-airport", Auth: admin, Data: models.UpdateAirportRequest{
+		AirportId: dest.Id, Name: "Aeroporto Major Brigadeiro Trompowsky", Latitude: &latitude, Longitude: &longitude,
+	}})
+	assert.Empty(t, response.Error, "expected no error, got %s", response.Error)
+	assert.Equal(t, "Aeroporto Major Brigadeiro Trompowsky", dest.Name)
+	assert.Equal(t, "Varginha", dest.City.Name, "the city should keep its name")
+	assert.Equal(t, latitude, dest.City.Latitude)
+
+	invalid := float32(120)
+	response, _ = conn.Do(models.Request{Action: "admin-update-airport", Auth: admin, Data: models.UpdateAirportRequest{AirportId: dest.Id, Latitude: &invalid}})
+	assert.NotEmpty(t, response.Error, "latitude out of range should be rejected")
+
+	response, _ = conn.Do(models.Request{Action: "admin-create-flight", Auth: admin, Data: models.CreateFlightRequest{
+		SourceAirportId: src.Id, DestAirportId: dest.Id, Seats: 2,
+	}})
+	assert.Empty(t, response.Error, "expected no error, got %s", response.Error)
+	flights, _ := dao.GetFlightDAO().FindBySourceAndDest(src.Id, dest.Id, time.Time{})
+	flight := flights[0]
+
+	response, _ = conn.Do(models.Request{Action: "admin-decommission-airport", Auth: admin, Data: models.DecommissionAirportRequest{AirportId: dest.Id}})
+	assert.NotEmpty(t, response.Error, "an airport with active flights should not be removed")
+
+	_, err = dao.GetAirportDAO().FindById(dest.Id)
+	assert.NoError(t, err, "expected no error, got %v", err)
+
+	response, _ = conn.Do(models.Request{Action: "admin-decommission-airport", Auth: admin, Data: models.DecommissionAirportRequest{
+		AirportId: dest.Id, CancelFlights: true,
+	}})
+	assert.Empty(t, response.Error, "expected no error, got %s", response.Error)
+	assert.Equal(t, float64(1), response.Data["cancelled"])
+	assert.True(t, flight.Cancelled, "the flight should be cancelled")
+
+	_, err = dao.GetAirportDAO().FindById(dest.Id)
+	assert.Error(t, err, "airport should be removed")
+
+	_, err = dao.GetFlightDAO().FindById(flight.Id)
+	assert.Error(t, err, "flights of the airport should be removed with it")
+
+	response, _ = conn.Do(models.Request{Action: "admin-decommission-airport", Auth: admin, Data: models.DecommissionAirportRequest{AirportId: uuid.New()}})
+	assert.Equal(t, "airport not found", response.Error)
+
+	dao.GetAirportDAO().Delete(src)
+}
+
+func TestMissingAirportsAreLeftOut(t *testing.T) {
+	loadAirportDAO(t)
+	address := startServer(t)
+
+	conn, err := client.Dial("tcp", address, codec.New(0), 0)
+	assert.NoError(t, err, "expected no error, got %v", err)
+	defer conn.Close()
+
+	src := &models.Airport{Name: "Aeroporto de Arapiraca", City: models.City{Name: "Arapiraca"}}
+	dest := &models.Airport{Name: "Aeroporto de Penedo", City: models.City{Name: "Penedo"}}
+	dao.GetAirportDAO().Insert(src)
+	dao.GetAirportDAO().Insert(dest)
+	defer dao.GetAirportDAO().Delete(src)
+
+	admin := registerAdmin(t, conn, "oswaldomontenegro")
+	response, _ := conn.Do(models.Request{Action: "admin-create-flight", Auth: admin, Data: models.CreateFlightRequest{
+		SourceAirportId: src.Id, DestAirportId: dest.Id, Seats: 5,
+	}})
+	assert.Empty(t, response.Error, "expected no error, got %s", response.Error)
+	flights, _ := dao.GetFlightDAO().FindBySourceAndDest(src.Id, dest.Id, time.Time{})
+	flight := flights[0]
+
+	for i := 0; i < 2; i++ {
+		response, _ = conn.Do(models.Request{Action: "reservation", Auth: admin, Data: models.FlightsRequest{FlightIds: []uuid.UUID{flight.Id}}})
+		assert.Empty(t, response.Error, "expected no error, got %s", response.Error)
+	}
+	account, _ := dao.GetClientDAO().FindByUsername("oswaldomontenegro")
+	cart, _ := dao.GetCartDAO().FindByClientId(account.Id)
+	var reservationId uuid.UUID
+	for id := range cart.Reservations {
+		reservationId = id
+	}
+	response, _ = conn.Do(models.Request{Action: "buy", Auth: admin, Data: models.BuyTicket{ReservationId: reservationId}})
+	assert.Empty(t, response.Error, "expected no error, got %s", response.Error)
+
+	// The airport goes away while the flight is still referenced, as while it is being decommissioned.
+	dao.GetAirportDAO().Delete(dest)
+
+	response, _ = conn.Do(models.Request{Action: "cart", Auth: admin})
+	assert.Empty(t, response.Error, "expected no error, got %s", response.Error)
+	reservations, _ := response.Data["Reservations"].([]interface{})
+	assert.Len(t, reservations, 1)
+	reservation, _ := reservations[0].(map[string]interface{})
+	assert.NotNil(t, reservation["Src"])
+	assert.NotContains(t, reservation, "Dest", "the missing airport should be left out")
+
+	response, _ = conn.Do(models.Request{Action: "tickets", Auth: admin})
+	assert.Empty(t, response.Error, "expected no error, got %s", response.Error)
+
+	response, _ = conn.Do(models.Request{Action: "flights", Auth: admin, Data: models.FlightsRequest{FlightIds: []uuid.UUID{flight.Id}}})
+	assert.Empty(t, response.Error, "expected no error, got %s", response.Error)
+
+	response, _ = conn.Do(models.Request{Action: "route", Auth: admin, Data: models.RouteRequest{Source: "Arapiraca", Dest: "Penedo"}})
+	assert.Equal(t, "not valid city name", response.Error)
+
+	dao.GetFlightDAO().Delete(flight)
+
+	response, _ = conn.Do(models.Request{Action: "cart", Auth: admin})
+	assert.Empty(t, response.Error, "expected no error, got %s", response.Error)
+	reservations, _ = response.Data["Reservations"].([]interface{})
+	assert.Len(t, reservations, 1, "the reservation should still be listed without its cities")
+
+	response, _ = conn.Do(models.Request{Action: "tickets", Auth: admin})
+	assert.Empty(t, response.Error, "expected no error, got %s", response.Error)
+}
+
+func TestDecommissionAirportWithSchedules(t *testing.T) {
+	loadAirportDAO(t)
+	address := startServer(t)
+	defer dao.GetFlightDAO().DeleteAll()
+
+	conn, err := client.Dial("tcp", address, codec.New(0), 0)
+	assert.NoError(t, err, "expected no error, got %v", err)
+	defer conn.Close()
+
+	src := &models.Airport{Name: "Aeroporto de Barreiras", City: models.City{Name: "Barreiras", TimeZone: "America/Bahia"}}
+	dest := &models.Airport{Name: "Aeroporto de Lençóis", City: models.City{Name: "Lençóis", TimeZone: "America/Bahia"}}
+	dao.GetAirportDAO().Insert(src)
+	dao.GetAirportDAO().Insert(dest)
+	defer dao.GetAirportDAO().Delete(src)
+	defer dao.GetAirportDAO().Delete(dest)
+
+	admin := registerAdmin(t, conn, "elomarfigueira")
+
+	// The schedule starts after the horizon, so it has no flights yet and only the schedule serves the airport.
+	start := time.Now().AddDate(0, 0, 60).Format(models.DateLayout)
+	response, _ := conn.Do(models.Request{Action: "admin-create-schedule", Auth: admin, Data: models.CreateScheduleRequest{
+		FlightNumber: "VP3030", SourceAirportId: src.Id, DestAirportId: dest.Id,
+		DepartureTime: "07:40", DurationMinutes: 45, StartDate: start, Seats: 50,
+	}})
+	assert.Empty(t, response.Error, "expected no error, got %s", response.Error)
+
+	response, _ = conn.Do(models.Request{Action: "admin-decommission-airport", Auth: admin, Data: models.DecommissionAirportRequest{AirportId: dest.Id}})
+	assert.Equal(t, "airport is still served by 1 schedules", response.Error)
+
+	_, err = dao.GetAirportDAO().FindById(dest.Id)
+	assert.NoError(t, err, "expected no error, got %v", err)
+
+	response, _ = conn.Do(models.Request{Action: "admin-decommission-airport", Auth: admin, Data: models.DecommissionAirportRequest{
+		AirportId: dest.Id, CancelFlights: true,
+	}})
+	assert.Empty(t, response.Error, "expected no error, got %s", response.Error)
+	assert.Equal(t, float64(1), response.Data["schedules"])
+
+	for _, schedule := range dao.GetScheduleDAO().FindAll() {
+		assert.NotEqual(t, dest.Id, schedule.DestAirportId, "the schedules of the airport should be removed with it")
+	}
+}
+
+func TestDecommissionAirportKeepsDepartedFlights(t *testing.T) {
+	loadAirportDAO(t)
+	address := startServer(t)
+
+	conn, err := client.Dial("tcp", address, codec.New(0), 0)
+	assert.NoError(t, err, "expected no error, got %v", err)
+	defer conn.Close()
+
+	src := &models.Airport{Name: "Aeroporto de Cruzeiro do Sul", City: models.City{Name: "Cruzeiro do Sul"}}
+	dest := &models.Airport{Name: "Aeroporto de Tarauacá", City: models.City{Name: "Tarauacá"}}
+	dao.GetAirportDAO().Insert(src)
+	dao.GetAirportDAO().Insert(dest)
+	defer dao.GetAirportDAO().Delete(src)
+
+	departed := &models.Flight{SourceAirportId: src.Id, DestAirportId: dest.Id, Seats: 2, Number: "VP200",
+		Departure: time.Now().Add(-2 * time.Hour), Arrival: time.Now().Add(-time.Hour)}
+	upcoming := &models.Flight{SourceAirportId: src.Id, DestAirportId: dest.Id, Seats: 2, Number: "VP201",
+		Departure: time.Now().Add(time.Hour), Arrival: time.Now().Add(2 * time.Hour)}
+	for _, flight := range []*models.Flight{departed, upcoming} {
+		dao.GetFlightDAO().Insert(flight)
+		flight.Start()
+		defer dao.GetFlightDAO().Delete(flight)
+	}
+
+	admin := registerAdmin(t, conn, "arrigobarnabe")
+
+	response, _ := conn.Do(models.Request{Action: "admin-decommission-airport", Auth: admin, Data: models.DecommissionAirportRequest{AirportId: dest.Id}})
+	assert.Equal(t, "airport is still served by 1 active flights", response.Error, "only the upcoming flight should count")
+
+	response, _ = conn.Do(models.Request{Action: "admin-decommission-airport", Auth: admin, Data: models.DecommissionAirportRequest{
+		AirportId: dest.Id, CancelFlights: true,
+	}})
+	assert.Empty(t, response.Error, "expected no error, got %s", response.Error)
+	assert.Equal(t, float64(1), response.Data["cancelled"])
+	assert.True(t, upcoming.Cancelled, "the upcoming flight should be cancelled")
+	assert.False(t, departed.Cancelled, "a flight already flown should not be cancelled nor refunded")
+}
@@ -0,0 +1,139 @@
+package tests
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+	"vendepass/internal/client"
+	"vendepass/internal/codec"
+	"vendepass/internal/dao"
+	"vendepass/internal/models"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+// loadAirportDAO loads the airport DAO from an empty stub file, since the tests do not run
+// from the repository root where the real stubs are.
+func loadAirportDAO(t *testing.T) {
+	wd, _ := os.Getwd()
+	dir := t.TempDir()
+	os.MkdirAll(filepath.Join(dir, "internal", "stubs"), 0755)
+	os.WriteFile(filepath.Join(dir, "internal", "stubs", "airports.json"), []byte("[]"), 0644)
+
+	os.Chdir(dir)
+	defer os.Chdir(wd)
+	dao.GetAirportDAO()
+}
+
+// registerAdmin registers a client, promotes it to admin and logs it in.
+func registerAdmin(t *testing.T, conn *client.Conn, username string) string {
+	response, _ := conn.Do(models.Request{Action: "register", Data: models.RegisterRequest{
+		Name: username, Username: username, Password: "senhaSegura123",
+	}})
+	assert.Empty(t, response.Error, "expected no error, got %s", response.Error)
+
+	admin, _ := dao.GetClientDAO().FindByUsername(username)
+	admin.Role = models.RoleAdmin
+	dao.GetClientDAO().Update(admin)
+
+	return loginAs(t, conn, username, "senhaSegura123", "")
+}
+
+func TestCancelledFlightRejectsReservations(t *testing.T) {
+	flight := &models.Flight{Id: uuid.New(), Seats: 1}
+	flight.InitQueue()
+	flight.Start()
+
+	cart := &models.Cart{ClientID: uuid.New(), Reservations: make(map[uuid.UUID]models.Reservation)}
+
+	assert.NoError(t, flight.Reserve(cart, "", "", "", nil))
+	assert.Len(t, cart.Reservations, 1)
+
+	assert.True(t, flight.Cancel(), "first cancellation should succeed")
+	assert.False(t, flight.Cancel(), "flight should only be cancelled once")
+	assert.ErrorIs(t, flight.Reserve(cart, "", "", "", nil), models.ErrFlightCancelled)
+}
+
+func TestAdminFlightLifecycle(t *testing.T) {
+	loadAirportDAO(t)
+	address := startServer(t)
+
+	conn, err := client.Dial("tcp", address, codec.New(0), 0)
+	assert.NoError(t, err, "expected no error, got %v", err)
+	defer conn.Close()
+
+	src := &models.Airport{Name: "Aeroporto A"}
+	dest := &models.Airport{Name: "Aeroporto B"}
+	dao.GetAirportDAO().Insert(src)
+	dao.GetAirportDAO().Insert(dest)
+
+	admin := registerAdmin(t, conn, "chicobuarque")
+
+	response, _ := conn.Do(models.Request{Action: "register", Data: models.RegisterRequest{
+		Name: "Gal Costa", Username: "galcosta", Password: "senhaSegura123",
+	}})
+	assert.Empty(t, response.Error, "expected no error, got %s", response.Error)
+	customer := loginAs(t, conn, "galcosta", "senhaSegura123", "")
+
+	response, _ = conn.Do(models.Request{Action: "admin-create-flight", Auth: customer, Data: models.CreateFlightRequest{
+		SourceAirportId: src.Id, DestAirportId: dest.Id, Seats: 3,
+	}})
+	assert.Equal(t, "forbidden", response.Error)
+
+	response, _ = conn.Do(models.Request{Action: "admin-create-flight", Auth: admin, Data: models.CreateFlightRequest{
+		SourceAirportId: src.Id, DestAirportId: dest.Id, Seats: 3,
+	}})
+	assert.Empty(t, response.Error, "expected no error, got %s", response.Error)
+
+	flights, err := dao.GetFlightDAO().FindBySourceAndDest(src.Id, dest.Id, time.Time{})
+	assert.NoError(t, err, "expected no error, got %v", err)
+	flight := flights[0]
+	defer dao.GetFlightDAO().Delete(flight)
+
+	// The new flight serves reservations without a restart.
+	response, _ = conn.Do(models.Request{Action: "reservation", Auth: customer, Data: models.FlightsRequest{FlightIds: []uuid.UUID{flight.Id}}})
+	assert.Empty(t, response.Error, "expected no error, got %s", response.Error)
+
+	galcosta, _ := dao.GetClientDAO().FindByUsername("galcosta")
+	cart, _ := dao.GetCartDAO().FindByClientId(galcosta.Id)
+	var reservationId uuid.UUID
+	for id := range cart.Reservations {
+		reservationId = id
+	}
+
+	response, _ = conn.Do(models.Request{Action: "buy", Auth: customer, Data: models.BuyTicket{ReservationId: reservationId}})
+	assert.Empty(t, response.Error, "expected no error, got %s", response.Error)
+
+	// A second flight on the same route is stored next to the first one.
+	response, _ = conn.Do(models.Request{Action: "admin-create-flight", Auth: admin, Data: models.CreateFlightRequest{
+		SourceAirportId: src.Id, DestAirportId: dest.Id, Seats: 1,
+	}})
+	assert.Empty(t, response.Error, "expected no error, got %s", response.Error)
+	flights, _ = dao.GetFlightDAO().FindBySourceAndDest(src.Id, dest.Id, time.Time{})
+	assert.Equal(t, 2, len(flights), "expected 2 flights, got %d", len(flights))
+	defer dao.GetFlightDAO().Delete(flights[1])
+
+	response, _ = conn.Do(models.Request{Action: "admin-update-flight", Auth: admin, Data: models.UpdateFlightRequest{FlightId: flight.Id, Capacity: 0}})
+	assert.NotEmpty(t, response.Error, "capacity should not drop below the sold seats")
+
+	response, _ = conn.Do(models.Request{Action: "admin-update-flight", Auth: admin, Data: models.UpdateFlightRequest{FlightId: flight.Id, Capacity: 5}})
+	assert.Empty(t, response.Error, "expected no error, got %s", response.Error)
+	assert.Equal(t, uint(4), flight.Seats)
+
+	response, _ = conn.Do(models.Request{Action: "admin-cancel-flight", Auth: admin, Data: models.CancelFlightRequest{FlightId: flight.Id, Reason: "manutenção"}})
+	assert.Empty(t, response.Error, "expected no error, got %s", response.Error)
+	assert.Equal(t, float64(1), response.Data["refunded"])
+
+	galcosta, _ = dao.GetClientDAO().FindByUsername("galcosta")
+	assert.Empty(t, galcosta.Client_flights, "ticket should be refunded")
+
+	response, _ = conn.Do(models.Request{Action: "notifications", Auth: customer})
+	notifications, _ := response.Data["Notifications"].([]interface{})
+	assert.Len(t, notifications, 1, "expected 1 notification, got %d", len(notifications))
+
+	response, _ = conn.Do(models.Request{Action: "notifications", Auth: customer})
+	notifications, _ = response.Data["Notifications"].([]interface{})
+	assert.Empty(t, notifications, "notifications should only be delivered once")
+}
@@ -0,0 +1,202 @@
+package tests
+
+import (
+	"path/filepath"
+	"sync"
+	"testing"
+	"vendepass/internal/dao"
+	"vendepass/internal/models"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBoltFlightSurvivesRestart(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "vendepass.db")
+
+	db, err := dao.OpenBolt(path)
+	assert.NoError(t, err, "expected no error, got %v", err)
+
+	flights := dao.NewBoltFlightDAO(db)
+	flights.New()
+
+	flight := &models.Flight{SourceAirportId: uuid.New(), DestAirportId: uuid.New(), Seats: 10}
+	flights.Insert(flight)
+
+	ticket, err := flight.AcceptReservation("", "", "")
+	assert.NoError(t, err, "expected no error, got %v", err)
+	flight.Passengers = append(flight.Passengers, ticket)
+	assert.NoError(t, flights.Update(flight))
+
+	_, err = flight.AcceptReservation("", "", "")
+	assert.NoError(t, err, "expected no error, got %v", err)
+
+	db.Close()
+
+	db, err = dao.OpenBolt(path)
+	assert.NoError(t, err, "expected no error, got %v", err)
+	defer db.Close()
+
+	flights = dao.NewBoltFlightDAO(db)
+	flights.New()
+
+	restored, err := flights.FindById(flight.Id)
+	assert.NoError(t, err, "expected no error, got %v", err)
+	assert.Equal(t, uint(10), restored.Capacity)
+	assert.Equal(t, uint(9), restored.Seats, "held seats should not survive a restart, sold ones should")
+	assert.Len(t, restored.Passengers, 1)
+	assert.Equal(t, ticket.Id, restored.Passengers[0].Id)
+}
+
+func TestBoltClientSurvivesRestart(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "vendepass.db")
+
+	db, err := dao.OpenBolt(path)
+	assert.NoError(t, err, "expected no error, got %v", err)
+
+	clients := dao.NewBoltClientDAO(db)
+	clients.New()
+
+	client := &models.Client{Name: "João Silva", Username: "joaosilva"}
+	clients.Insert(client)
+
+	client.Client_flights = append(client.Client_flights, &models.Ticket{Id: uuid.New(), ClientId: client.Id})
+	assert.NoError(t, clients.Update(client))
+
+	db.Close()
+
+	db, err = dao.OpenBolt(path)
+	assert.NoError(t, err, "expected no error, got %v", err)
+	defer db.Close()
+
+	clients = dao.NewBoltClientDAO(db)
+	clients.New()
+
+	restored, err := clients.FindById(client.Id)
+	assert.NoError(t, err, "expected no error, got %v", err)
+	assert.Equal(t, client.Username, restored.Username)
+	assert.Len(t, restored.Client_flights, 1)
+}
+
+func TestBoltBookingSurvivesRestart(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "vendepass.db")
+
+	db, err := dao.OpenBolt(path)
+	assert.NoError(t, err, "expected no error, got %v", err)
+
+	bookings := dao.NewBoltBookingDAO(db)
+	bookings.New()
+
+	ticket := &models.Ticket{Id: uuid.New(), Price: models.Price{Amount: 1000, Currency: "BRL"}}
+	booking := models.NewBooking(uuid.New(), uuid.New(), []*models.Ticket{ticket})
+	bookings.Insert(booking)
+
+	booking.CancelTicket(ticket.Id)
+	assert.NoError(t, bookings.Update(booking))
+
+	db.Close()
+
+	db, err = dao.OpenBolt(path)
+	assert.NoError(t, err, "expected no error, got %v", err)
+	defer db.Close()
+
+	bookings = dao.NewBoltBookingDAO(db)
+	bookings.New()
+
+	restored, err := bookings.FindById(booking.Id)
+	assert.NoError(t, err, "expected no error, got %v", err)
+	assert.Equal(t, booking.Reference, restored.Reference)
+	assert.Equal(t, models.BookingCancelled, restored.Status)
+	assert.Len(t, bookings.FindByClientId(booking.ClientId), 1)
+}
+
+func TestBoltClientModifyKeepsOtherFields(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "vendepass.db")
+
+	db, err := dao.OpenBolt(path)
+	assert.NoError(t, err, "expected no error, got %v", err)
+
+	clients := dao.NewBoltClientDAO(db)
+	clients.New()
+
+	client := &models.Client{Name: "Ney Matogrosso", Username: "neymatogrosso"}
+	clients.Insert(client)
+	other := &models.Client{Name: "Secos e Molhados", Username: "secosemolhados"}
+	clients.Insert(other)
+
+	// A ticket bought after the profile was read must survive the profile update.
+	stale := *client
+	client.Client_flights = append(client.Client_flights, &models.Ticket{Id: uuid.New(), ClientId: client.Id})
+	assert.NoError(t, clients.Update(client))
+
+	updated, err := clients.Modify(stale.Id, func(client *models.Client) error {
+		client.Name = "Ney de Souza Pereira"
+		return nil
+	})
+	assert.NoError(t, err, "expected no error, got %v", err)
+	assert.Equal(t, "Ney de Souza Pereira", updated.Name)
+	assert.Len(t, updated.Client_flights, 1, "the ticket should not be overwritten")
+
+	_, err = clients.Modify(client.Id, func(client *models.Client) error {
+		client.Username = "secosemolhados"
+		return nil
+	})
+	assert.Equal(t, dao.ErrUsernameTaken, err)
+	assert.Equal(t, "neymatogrosso", client.Username, "a rejected change should not reach the stored client")
+
+	db.Close()
+
+	db, err = dao.OpenBolt(path)
+	assert.NoError(t, err, "expected no error, got %v", err)
+	defer db.Close()
+
+	clients = dao.NewBoltClientDAO(db)
+	clients.New()
+
+	restored, err := clients.FindById(client.Id)
+	assert.NoError(t, err, "expected no error, got %v", err)
+	assert.Equal(t, "Ney de Souza Pereira", restored.Name)
+	assert.Equal(t, "neymatogrosso", restored.Username)
+	assert.Len(t, restored.Client_flights, 1)
+}
+
+func TestBoltConcurrentUpdatesKeepLastState(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "vendepass.db")
+
+	db, err := dao.OpenBolt(path)
+	assert.NoError(t, err, "expected no error, got %v", err)
+
+	clients := dao.NewBoltClientDAO(db)
+	clients.New()
+
+	client := &models.Client{Name: "Tim Maia", Username: "timmaia"}
+	assert.NoError(t, clients.Insert(client))
+
+	// Every ticket is added by its own update; whatever order they are stored in, the last state holds them all.
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := clients.Modify(client.Id, func(client *models.Client) error {
+				client.Client_flights = append(client.Client_flights, &models.Ticket{Id: uuid.New(), ClientId: client.Id})
+				return nil
+			})
+			assert.NoError(t, err, "expected no error, got %v", err)
+		}()
+	}
+	wg.Wait()
+
+	db.Close()
+
+	db, err = dao.OpenBolt(path)
+	assert.NoError(t, err, "expected no error, got %v", err)
+	defer db.Close()
+
+	clients = dao.NewBoltClientDAO(db)
+	clients.New()
+
+	restored, err := clients.FindById(client.Id)
+	assert.NoError(t, err, "expected no error, got %v", err)
+	assert.Len(t, restored.Client_flights, 50, "no ticket should be lost")
+}
@@ -0,0 +1,184 @@
+package tests
+
+import (
+	"strings"
+	"testing"
+	"time"
+	"vendepass/internal/client"
+	"vendepass/internal/codec"
+	"vendepass/internal/dao"
+	"vendepass/internal/models"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBookingStatus(t *testing.T) {
+	first := &models.Ticket{Id: uuid.New(), Price: models.Price{Amount: 1000, Currency: "BRL"}}
+	second := &models.Ticket{Id: uuid.New(), Price: models.Price{Amount: 2500, Currency: "BRL"}}
+	booking := models.NewBooking(uuid.New(), uuid.New(), []*models.Ticket{first, second})
+
+	assert.Equal(t, models.BookingConfirmed, booking.Status)
+	assert.Equal(t, []models.Price{{Amount: 3500, Currency: "BRL"}}, booking.TotalPaid)
+
+	assert.True(t, booking.CancelTicket(first.Id))
+	assert.False(t, booking.CancelTicket(first.Id), "a leg should only be cancelled once")
+	assert.False(t, booking.CancelTicket(uuid.New()), "tickets of other bookings should be ignored")
+	assert.Equal(t, models.BookingPartiallyCancelled, booking.Status)
+
+	assert.True(t, booking.CancelTicket(second.Id))
+	assert.Equal(t, models.BookingCancelled, booking.Status)
+}
+
+func TestBookingReferencesAreUnique(t *testing.T) {
+	references := make(map[string]bool)
+
+	for i := 0; i < 500; i++ {
+		booking := models.NewBooking(uuid.New(), uuid.New(), nil)
+		dao.GetBookingDAO().Insert(booking)
+		defer dao.GetBookingDAO().Delete(booking)
+
+		assert.Regexp(t, "^[A-Z2-9]{6}$", booking.Reference)
+		assert.False(t, references[booking.Reference], "reference %s given twice", booking.Reference)
+		references[booking.Reference] = true
+
+		found, err := dao.GetBookingDAO().FindByReference(strings.ToLower(booking.Reference))
+		assert.NoError(t, err, "expected no error, got %v", err)
+		assert.Equal(t, booking.Id, found.Id)
+	}
+}
+
+func TestCancelBookingCancelsEveryLeg(t *testing.T) {
+	loadAirportDAO(t)
+	address := startServer(t)
+
+	conn, err := client.Dial("tcp", address, codec.New(0), 0)
+	assert.NoError(t, err, "expected no error, got %v", err)
+	defer conn.Close()
+
+	airports := make([]*models.Airport, 0, 3)
+	for _, city := range []string{"Parintins", "Santarém", "Altamira"} {
+		airport := &models.Airport{Name: "Aeroporto de " + city, City: models.City{Name: city}}
+		dao.GetAirportDAO().Insert(airport)
+		defer dao.GetAirportDAO().Delete(airport)
+		airports = append(airports, airport)
+	}
+
+	first := &models.Flight{SourceAirportId: airports[0].Id, DestAirportId: airports[1].Id, Seats: 2}
+	second := &models.Flight{SourceAirportId: airports[1].Id, DestAirportId: airports[2].Id, Seats: 2}
+	for _, flight := range []*models.Flight{first, second} {
+		dao.GetFlightDAO().Insert(flight)
+		flight.Start()
+		defer dao.GetFlightDAO().Delete(flight)
+	}
+
+	response, _ := conn.Do(models.Request{Action: "register", Data: models.RegisterRequest{
+		Name: "Fafá de Belém", Username: "fafadebelem", Password: "senhaSegura123",
+	}})
+	assert.Empty(t, response.Error, "expected no error, got %s", response.Error)
+	token := loginAs(t, conn, "fafadebelem", "senhaSegura123", "")
+
+	response, _ = conn.Do(models.Request{Action: "reservation", Auth: token, Data: models.FlightsRequest{FlightIds: []uuid.UUID{first.Id, second.Id}}})
+	assert.Empty(t, response.Error, "expected no error, got %s", response.Error)
+
+	response, _ = conn.Do(models.Request{Action: "checkout", Auth: token})
+	assert.Empty(t, response.Error, "expected no error, got %s", response.Error)
+	reference := response.Data["Reference"]
+	bookingId, _ := uuid.Parse(response.Data["OrderId"].(string))
+
+	response, _ = conn.Do(models.Request{Action: "tickets", Auth: token})
+	bookings, _ := response.Data["Bookings"].([]interface{})
+	assert.Len(t, bookings, 1)
+	booking, _ := bookings[0].(map[string]interface{})
+	assert.Equal(t, reference, booking["Reference"])
+	assert.Equal(t, string(models.BookingConfirmed), booking["Status"])
+	legs, _ := booking["Legs"].([]interface{})
+	assert.Len(t, legs, 2, "the booking should hold both legs")
+
+	// The booking can be found without logging in, with the last name and the reference.
+	response, _ = conn.Do(models.Request{Action: "find-booking", Data: models.FindBookingRequest{LastName: "BELÉM", Reference: strings.ToLower(reference.(string))}})
+	assert.Empty(t, response.Error, "expected no error, got %s", response.Error)
+	found, _ := response.Data["Booking"].(map[string]interface{})
+	assert.Equal(t, bookingId.String(), found["Id"])
+
+	response, _ = conn.Do(models.Request{Action: "find-booking", Data: models.FindBookingRequest{LastName: "Silva", Reference: reference.(string)}})
+	assert.Equal(t, "booking not found", response.Error, "the last name should match the buyer's")
+
+	fafa, _ := dao.GetClientDAO().FindByUsername("fafadebelem")
+	response, _ = conn.Do(models.Request{Action: "cancel-buy", Auth: token, Data: models.CancelBuyRequest{TicketId: fafa.Client_flights[0].Id}})
+	assert.Empty(t, response.Error, "expected no error, got %s", response.Error)
+
+	stored, _ := dao.GetBookingDAO().FindById(bookingId)
+	assert.Equal(t, models.BookingPartiallyCancelled, stored.Status)
+
+	response, _ = conn.Do(models.Request{Action: "cancel-booking", Auth: token, Data: models.CancelBookingRequest{Reference: reference.(string)}})
+	assert.Empty(t, response.Error, "expected no error, got %s", response.Error)
+
+	assert.Equal(t, models.BookingCancelled, stored.Status)
+	fafa, _ = dao.GetClientDAO().FindByUsername("fafadebelem")
+	assert.Empty(t, fafa.Client_flights, "every leg should be cancelled")
+	assert.Equal(t, uint(2), first.Seats)
+	assert.Equal(t, uint(2), second.Seats)
+
+	response, _ = conn.Do(models.Request{Action: "cancel-booking", Auth: token, Data: models.CancelBookingRequest{BookingId: bookingId}})
+	assert.Equal(t, "booking already cancelled", response.Error)
+}
+
+func TestDepartedTicketsCannotBeCancelled(t *testing.T) {
+	loadAirportDAO(t)
+	address := startServer(t)
+
+	conn, err := client.Dial("tcp", address, codec.New(0), 0)
+	assert.NoError(t, err, "expected no error, got %v", err)
+	defer conn.Close()
+
+	src := &models.Airport{Name: "Aeroporto de Tefé", City: models.City{Name: "Tefé"}}
+	dest := &models.Airport{Name: "Aeroporto de Tabatinga", City: models.City{Name: "Tabatinga"}}
+	dao.GetAirportDAO().Insert(src)
+	dao.GetAirportDAO().Insert(dest)
+	defer dao.GetAirportDAO().Delete(src)
+	defer dao.GetAirportDAO().Delete(dest)
+
+	departure := time.Now().Add(time.Hour)
+	flight := &models.Flight{SourceAirportId: src.Id, DestAirportId: dest.Id, Seats: 2, Number: "VP100", Departure: departure, Arrival: departure.Add(time.Hour)}
+	dao.GetFlightDAO().Insert(flight)
+	flight.Start()
+	defer dao.GetFlightDAO().Delete(flight)
+
+	response, _ := conn.Do(models.Request{Action: "register", Data: models.RegisterRequest{
+		Name: "Hermeto Pascoal", Username: "hermetopascoal", Password: "senhaSegura123",
+	}})
+	assert.Empty(t, response.Error, "expected no error, got %s", response.Error)
+	token := loginAs(t, conn, "hermetopascoal", "senhaSegura123", "")
+
+	response, _ = conn.Do(models.Request{Action: "reservation", Auth: token, Data: models.FlightsRequest{FlightIds: []uuid.UUID{flight.Id}}})
+	assert.Empty(t, response.Error, "expected no error, got %s", response.Error)
+
+	response, _ = conn.Do(models.Request{Action: "checkout", Auth: token})
+	assert.Empty(t, response.Error, "expected no error, got %s", response.Error)
+	reference := response.Data["Reference"].(string)
+
+	// The flight departs after the ticket was bought.
+	flight.Mu.Lock()
+	flight.Departure = time.Now().Add(-time.Hour)
+	flight.Arrival = time.Now()
+	flight.Mu.Unlock()
+
+	hermeto, _ := dao.GetClientDAO().FindByUsername("hermetopascoal")
+	ticketId := hermeto.Client_flights[0].Id
+
+	response, _ = conn.Do(models.Request{Action: "cancel-buy", Auth: token, Data: models.CancelBuyRequest{TicketId: ticketId}})
+	assert.Equal(t, "flight already departed", response.Error)
+
+	response, _ = conn.Do(models.Request{Action: "cancel-booking", Auth: token, Data: models.CancelBookingRequest{Reference: reference}})
+	assert.Equal(t, "flight already departed", response.Error)
+
+	hermeto, _ = dao.GetClientDAO().FindByUsername("hermetopascoal")
+	assert.Len(t, hermeto.Client_flights, 1, "the flown ticket should be kept")
+	booking, _ := dao.GetBookingDAO().FindByReference(reference)
+	assert.Equal(t, models.BookingConfirmed, booking.Status)
+
+	flight.Mu.Lock()
+	assert.Equal(t, uint(1), flight.Seats, "the seat of a flown ticket should not be returned")
+	flight.Mu.Unlock()
+}
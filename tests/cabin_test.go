@@ -0,0 +1,169 @@
+package tests
+
+import (
+	"testing"
+	"time"
+	"vendepass/internal/client"
+	"vendepass/internal/codec"
+	"vendepass/internal/dao"
+	"vendepass/internal/models"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+// twoCabins sells two economy seats at BRL 100.00 and one business seat at BRL 500.00.
+var twoCabins = []models.Cabin{
+	{Class: models.CabinEconomy, Seats: 2, Capacity: 2, Fare: models.Fare{BasePrice: 10000, Currency: "BRL"}},
+	{Class: models.CabinBusiness, Seats: 1, Capacity: 1, Fare: models.Fare{BasePrice: 50000, Currency: "BRL"}},
+}
+
+func TestCabinInventories(t *testing.T) {
+	flight := &models.Flight{Id: uuid.New(), Seats: 3, Capacity: 3, Cabins: append([]models.Cabin(nil), twoCabins...)}
+
+	business, err := flight.AcceptReservation(models.CabinBusiness, "", "")
+	assert.NoError(t, err, "expected no error, got %v", err)
+	assert.Equal(t, models.CabinBusiness, business.Cabin)
+	assert.Equal(t, int64(50000), business.Price.Amount, "business should be priced by its own fare")
+
+	_, err = flight.AcceptReservation(models.CabinBusiness, "", "")
+	assert.Error(t, err, "business should be sold out, got %v", err)
+
+	economy, err := flight.AcceptReservation("", "", "")
+	assert.NoError(t, err, "expected no error, got %v", err)
+	assert.Equal(t, models.CabinEconomy, economy.Cabin, "the first cabin should be reserved by default")
+	assert.Equal(t, int64(10000), economy.Price.Amount)
+
+	_, err = flight.AcceptReservation(models.CabinPremium, "", "")
+	assert.ErrorIs(t, err, models.ErrCabinNotSold)
+
+	assert.Equal(t, uint(1), flight.Seats)
+	assert.Equal(t, uint(1), flight.SeatsLeft(models.CabinEconomy))
+	assert.Equal(t, uint(0), flight.SeatsLeft(models.CabinBusiness))
+
+	// Sold tickets keep counting against their cabin after a restart.
+	flight.Passengers = append(flight.Passengers, business)
+	restored := models.NewFlight(flight.ToJSON())
+	assert.Equal(t, uint(2), restored.SeatsLeft(models.CabinEconomy))
+	assert.Equal(t, uint(0), restored.SeatsLeft(models.CabinBusiness))
+
+	flight.ReleaseSeat(business)
+	assert.Equal(t, uint(1), flight.SeatsLeft(models.CabinBusiness), "the seat should go back to its own cabin")
+	assert.Equal(t, uint(2), flight.Seats)
+
+	// Flights without cabins sell every seat in economy.
+	legacy := &models.Flight{Id: uuid.New(), Seats: 1, Capacity: 1}
+	assert.Equal(t, uint(1), legacy.SeatsLeft(models.CabinEconomy))
+	assert.Equal(t, uint(0), legacy.SeatsLeft(models.CabinBusiness))
+
+	_, _, err = models.NewCabins([]models.Cabin{{Class: models.CabinEconomy, Seats: 2}, {Class: models.CabinEconomy, Seats: 1}}, nil)
+	assert.Error(t, err, "expected error, got %v", err)
+
+	seatMap, _ := models.NewSeatMap(smallSeatMap)
+	cabins, seats, err := models.NewCabins([]models.Cabin{{Class: models.CabinBusiness}, {Class: models.CabinEconomy, Seats: 10}}, seatMap)
+	assert.NoError(t, err, "expected no error, got %v", err)
+	assert.Equal(t, uint(18), seats, "the business cabin should sell every seat of its map")
+	assert.Equal(t, uint(8), cabins[0].Capacity)
+	assert.Equal(t, models.DefaultCurrency, cabins[0].Fare.Currency)
+
+	_, _, err = models.NewCabins([]models.Cabin{{Class: models.CabinPremium}}, seatMap)
+	assert.Error(t, err, "the seat map has no premium cabin, got %v", err)
+}
+
+func TestRouteRequiresCabin(t *testing.T) {
+	flightDAO := dao.GetFlightDAO()
+	defer flightDAO.DeleteAll()
+
+	sourceID := uuid.New()
+	destID := uuid.New()
+	middleID := uuid.New()
+
+	cabins := append([]models.Cabin(nil), twoCabins...)
+	cabins[1].Seats = 0
+	flightDAO.Insert(&models.Flight{SourceAirportId: sourceID, DestAirportId: destID, Seats: 2, Capacity: 3, Cabins: cabins})
+	flightDAO.Insert(&models.Flight{SourceAirportId: sourceID, DestAirportId: middleID, Seats: 3, Capacity: 3, Cabins: append([]models.Cabin(nil), twoCabins...)})
+	flightDAO.Insert(&models.Flight{SourceAirportId: middleID, DestAirportId: destID, Seats: 3, Capacity: 3, Cabins: append([]models.Cabin(nil), twoCabins...)})
+
+	path, err := flightDAO.BreadthFirstSearch(sourceID, destID, time.Time{}, models.CabinEconomy)
+	assert.NoError(t, err, "expected no error, got %v", err)
+	assert.Len(t, path, 1, "the direct flight has economy seats left")
+
+	path, err = flightDAO.BreadthFirstSearch(sourceID, destID, time.Time{}, models.CabinBusiness)
+	assert.NoError(t, err, "expected no error, got %v", err)
+	assert.Len(t, path, 2, "business should only be left through the connection")
+
+	_, err = flightDAO.BreadthFirstSearch(sourceID, destID, time.Time{}, models.CabinPremium)
+	assert.Error(t, err, "no flight sells premium, got %v", err)
+}
+
+func TestReserveCabin(t *testing.T) {
+	loadAirportDAO(t)
+	address := startServer(t)
+
+	conn, err := client.Dial("tcp", address, codec.New(0), 0)
+	assert.NoError(t, err, "expected no error, got %v", err)
+	defer conn.Close()
+
+	src := &models.Airport{Name: "Aeroporto de Ilhéus", City: models.City{Name: "Ilhéus"}}
+	dest := &models.Airport{Name: "Aeroporto de Porto Seguro", City: models.City{Name: "Porto Seguro"}}
+	dao.GetAirportDAO().Insert(src)
+	dao.GetAirportDAO().Insert(dest)
+	defer dao.GetAirportDAO().Delete(src)
+	defer dao.GetAirportDAO().Delete(dest)
+
+	admin := registerAdmin(t, conn, "naraleao")
+	response, _ := conn.Do(models.Request{Action: "admin-create-flight", Auth: admin, Data: models.CreateFlightRequest{
+		SourceAirportId: src.Id, DestAirportId: dest.Id, Cabins: twoCabins,
+	}})
+	assert.Empty(t, response.Error, "expected no error, got %s", response.Error)
+
+	flights, _ := dao.GetFlightDAO().FindBySourceAndDest(src.Id, dest.Id, time.Time{})
+	flight := flights[0]
+	defer dao.GetFlightDAO().Delete(flight)
+	assert.Equal(t, uint(3), flight.Capacity, "the flight should add its cabins up")
+
+	response, _ = conn.Do(models.Request{Action: "flights", Auth: admin, Data: models.FlightsRequest{
+		FlightIds: []uuid.UUID{flight.Id}, Cabins: []models.CabinClass{models.CabinBusiness},
+	}})
+	assert.Empty(t, response.Error, "expected no error, got %s", response.Error)
+	flightData := response.Data["Flights"].([]interface{})[0].(map[string]interface{})
+	assert.Len(t, flightData["Cabins"], 2)
+	quotes := flightData["Quotes"].([]interface{})
+	assert.Len(t, quotes, 1, "only the requested cabin should be quoted")
+	assert.Equal(t, string(models.CabinBusiness), quotes[0].(map[string]interface{})["Cabin"])
+
+	response, _ = conn.Do(models.Request{Action: "reservation", Auth: admin, Data: models.FlightsRequest{
+		FlightIds: []uuid.UUID{flight.Id}, Cabins: []models.CabinClass{models.CabinBusiness},
+	}})
+	assert.Empty(t, response.Error, "expected no error, got %s", response.Error)
+
+	response, _ = conn.Do(models.Request{Action: "reservation", Auth: admin, Data: models.FlightsRequest{
+		FlightIds: []uuid.UUID{flight.Id}, Cabins: []models.CabinClass{models.CabinBusiness},
+	}})
+	assert.Equal(t, "at least one flight is not available", response.Error, "business should be sold out")
+
+	response, _ = conn.Do(models.Request{Action: "reservation", Auth: admin, Data: models.FlightsRequest{
+		FlightIds: []uuid.UUID{flight.Id}, Cabins: []models.CabinClass{models.CabinPremium},
+	}})
+	assert.Equal(t, models.ErrCabinNotSold.Error()+": "+flight.Id.String(), response.Error)
+
+	response, _ = conn.Do(models.Request{Action: "cart", Auth: admin})
+	reservations, _ := response.Data["Reservations"].([]interface{})
+	assert.Len(t, reservations, 1)
+	reservation := reservations[0].(map[string]interface{})
+	assert.Equal(t, string(models.CabinBusiness), reservation["Cabin"])
+	assert.Equal(t, float64(50000), reservation["Price"].(map[string]interface{})["Amount"])
+
+	response, _ = conn.Do(models.Request{Action: "admin-update-flight", Auth: admin, Data: models.UpdateFlightRequest{FlightId: flight.Id, Capacity: 4}})
+	assert.NotEmpty(t, response.Error, "the cabin should be needed on flights with cabins")
+
+	response, _ = conn.Do(models.Request{Action: "admin-update-flight", Auth: admin, Data: models.UpdateFlightRequest{
+		FlightId: flight.Id, Cabin: models.CabinBusiness, Capacity: 2,
+	}})
+	assert.Empty(t, response.Error, "expected no error, got %s", response.Error)
+	flight.Mu.Lock()
+	assert.Equal(t, uint(1), flight.SeatsLeft(models.CabinBusiness))
+	assert.Equal(t, uint(4), flight.Capacity)
+	assert.Equal(t, uint(3), flight.Seats)
+	flight.Mu.Unlock()
+}
@@ -0,0 +1,149 @@
+package tests
+
+import (
+	"sync"
+	"testing"
+	"time"
+	"vendepass/internal/client"
+	"vendepass/internal/codec"
+	"vendepass/internal/dao"
+	"vendepass/internal/models"
+	"vendepass/internal/payment"
+	"vendepass/internal/server"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckoutIsAllOrNothing(t *testing.T) {
+	loadAirportDAO(t)
+	address := startServer(t)
+
+	provider := payment.NewFake()
+	server.Payments = provider
+	defer func() { server.Payments = payment.NewFake() }()
+
+	conn, err := client.Dial("tcp", address, codec.New(0), 0)
+	assert.NoError(t, err, "expected no error, got %v", err)
+	defer conn.Close()
+
+	airports := make([]*models.Airport, 0, 3)
+	for _, city := range []string{"Ilhéus", "Vitória da Conquista", "Feira de Santana"} {
+		airport := &models.Airport{Name: "Aeroporto de " + city, City: models.City{Name: city}}
+		dao.GetAirportDAO().Insert(airport)
+		defer dao.GetAirportDAO().Delete(airport)
+		airports = append(airports, airport)
+	}
+
+	fare := models.Fare{BasePrice: 30000, Currency: "BRL"}
+	first := &models.Flight{SourceAirportId: airports[0].Id, DestAirportId: airports[1].Id, Seats: 2, Fare: fare}
+	second := &models.Flight{SourceAirportId: airports[1].Id, DestAirportId: airports[2].Id, Seats: 2, Fare: fare}
+	replacement := &models.Flight{SourceAirportId: airports[1].Id, DestAirportId: airports[2].Id, Seats: 2, Fare: fare}
+	for _, flight := range []*models.Flight{first, second, replacement} {
+		dao.GetFlightDAO().Insert(flight)
+		flight.Start()
+		defer dao.GetFlightDAO().Delete(flight)
+	}
+
+	response, _ := conn.Do(models.Request{Action: "register", Data: models.RegisterRequest{
+		Name: "Djavan", Username: "djavan", Password: "senhaSegura123",
+	}})
+	assert.Empty(t, response.Error, "expected no error, got %s", response.Error)
+	token := loginAs(t, conn, "djavan", "senhaSegura123", "")
+
+	response, _ = conn.Do(models.Request{Action: "checkout", Auth: token})
+	assert.Equal(t, "cart is empty", response.Error)
+
+	response, _ = conn.Do(models.Request{Action: "reservation", Auth: token, Data: models.FlightsRequest{FlightIds: []uuid.UUID{first.Id, second.Id}}})
+	assert.Empty(t, response.Error, "expected no error, got %s", response.Error)
+
+	// The second leg is cancelled after the reservation, so the first leg must not be bought alone.
+	second.Cancel()
+
+	response, _ = conn.Do(models.Request{Action: "checkout", Auth: token})
+	assert.Equal(t, models.ErrFlightCancelled.Error(), response.Error)
+
+	djavan, _ := dao.GetClientDAO().FindByUsername("djavan")
+	assert.Empty(t, djavan.Client_flights, "no ticket should be issued")
+	intents := provider.Intents()
+	assert.Len(t, intents, 2)
+	for _, intent := range intents {
+		assert.Equal(t, payment.StatusRefunded, intent.Status, "every leg paid should be refunded")
+	}
+
+	cart, _ := dao.GetCartDAO().FindByClientId(djavan.Id)
+	assert.Len(t, cart.Reservations, 1, "the reservation of the first leg should go back to the cart")
+
+	response, _ = conn.Do(models.Request{Action: "reservation", Auth: token, Data: models.FlightsRequest{FlightIds: []uuid.UUID{replacement.Id}}})
+	assert.Empty(t, response.Error, "expected no error, got %s", response.Error)
+
+	response, _ = conn.Do(models.Request{Action: "checkout", Auth: token})
+	assert.Empty(t, response.Error, "expected no error, got %s", response.Error)
+	assert.Equal(t, []interface{}{map[string]interface{}{"Amount": float64(60000), "Currency": "BRL"}}, response.Data["Total"])
+	orderId, _ := uuid.Parse(response.Data["OrderId"].(string))
+
+	djavan, _ = dao.GetClientDAO().FindByUsername("djavan")
+	assert.Len(t, djavan.Client_flights, 2)
+	for _, ticket := range djavan.Client_flights {
+		assert.Equal(t, orderId, ticket.OrderId, "the legs should share the order")
+	}
+	assert.Empty(t, cart.Reservations, "the cart should be empty after the checkout")
+}
+
+func TestCheckoutDuringFlightCancellationIsRefunded(t *testing.T) {
+	loadAirportDAO(t)
+	address := startServer(t)
+
+	provider := payment.NewFake()
+	server.Payments = provider
+	defer func() { server.Payments = payment.NewFake() }()
+
+	conn, err := client.Dial("tcp", address, codec.New(0), 0)
+	assert.NoError(t, err, "expected no error, got %v", err)
+	defer conn.Close()
+
+	src := &models.Airport{Name: "Aeroporto de Porto Seguro", City: models.City{Name: "Porto Seguro"}}
+	dest := &models.Airport{Name: "Aeroporto de Barreiras", City: models.City{Name: "Barreiras"}}
+	dao.GetAirportDAO().Insert(src)
+	dao.GetAirportDAO().Insert(dest)
+	defer dao.GetAirportDAO().Delete(src)
+	defer dao.GetAirportDAO().Delete(dest)
+
+	token := registerAdmin(t, conn, "carlinhosbrown")
+	account, _ := dao.GetClientDAO().FindByUsername("carlinhosbrown")
+
+	for i := 0; i < 200; i++ {
+		flight := &models.Flight{SourceAirportId: src.Id, DestAirportId: dest.Id, Seats: 1, Fare: models.Fare{BasePrice: 30000, Currency: "BRL"}}
+		dao.GetFlightDAO().Insert(flight)
+		flight.Start()
+		defer dao.GetFlightDAO().Delete(flight)
+
+		response, _ := conn.Do(models.Request{Action: "reservation", Auth: token, Data: models.FlightsRequest{FlightIds: []uuid.UUID{flight.Id}}})
+		assert.Empty(t, response.Error, "expected no error, got %s", response.Error)
+
+		// The checkout and the cancellation race, the cancellation starting at a different point of the checkout
+		// each time; whichever wins, no charge may be kept.
+		var wg sync.WaitGroup
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			conn.Do(models.Request{Action: "checkout", Auth: token})
+		}()
+		go func(delay time.Duration) {
+			defer wg.Done()
+			time.Sleep(delay)
+			conn.Do(models.Request{Action: "admin-cancel-flight", Auth: token, Data: models.CancelFlightRequest{FlightId: flight.Id}})
+		}(time.Duration(i%50) * 5 * time.Microsecond)
+		wg.Wait()
+	}
+
+	for _, intent := range provider.Intents() {
+		assert.NotEqual(t, payment.StatusCaptured, intent.Status, "the payment of a cancelled flight should be refunded")
+	}
+
+	account, _ = dao.GetClientDAO().FindById(account.Id)
+	assert.Empty(t, account.Client_flights, "no ticket of a cancelled flight should be kept")
+	for _, booking := range dao.GetBookingDAO().FindByClientId(account.Id) {
+		assert.Equal(t, models.BookingCancelled, booking.Status)
+	}
+}
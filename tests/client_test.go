@@ -0,0 +1,149 @@
+package tests
+
+import (
+	"fmt"
+	"net"
+	"sync"
+	"testing"
+	"time"
+	"vendepass/internal/client"
+	"vendepass/internal/codec"
+	"vendepass/internal/models"
+	"vendepass/internal/server"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func startServer(t *testing.T) string {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err, "expected no error, got %v", err)
+	t.Cleanup(func() { listener.Close() })
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go server.HandleConn(conn)
+		}
+	}()
+
+	return listener.Addr().String()
+}
+
+func TestPipelinedRequests(t *testing.T) {
+	address := startServer(t)
+
+	conn, err := client.Dial("tcp", address, codec.New(0), 0)
+	assert.NoError(t, err, "expected no error, got %v", err)
+	defer conn.Close()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func(id string) {
+			defer wg.Done()
+			response, err := conn.Do(models.Request{Id: id, Action: "not-an-action"})
+			assert.NoError(t, err, "expected no error, got %v", err)
+			assert.Equal(t, id, response.Id, "response should echo the request ID")
+			assert.Equal(t, "unknown action", response.Error)
+		}(fmt.Sprintf("request-%d", i))
+	}
+	wg.Wait()
+}
+
+func TestRequestsInFlightAreBounded(t *testing.T) {
+	previous := server.MaxRequestsInFlight
+	server.MaxRequestsInFlight = 1
+	defer func() { server.MaxRequestsInFlight = previous }()
+
+	address := startServer(t)
+
+	conn, err := net.Dial("tcp", address)
+	assert.NoError(t, err, "expected no error, got %v", err)
+	defer conn.Close()
+
+	frames := codec.New(0)
+	for i := 0; i < 20; i++ {
+		err := frames.Write(conn, models.Request{Id: fmt.Sprintf("request-%d", i), Action: "not-an-action"})
+		assert.NoError(t, err, "expected no error, got %v", err)
+	}
+
+	// With a single request in flight, each request is only read once the previous one was answered.
+	for i := 0; i < 20; i++ {
+		var response models.Response
+		err := frames.Read(conn, &response)
+		assert.NoError(t, err, "expected no error, got %v", err)
+		assert.Equal(t, fmt.Sprintf("request-%d", i), response.Id, "responses should follow the requests' order")
+	}
+}
+
+func TestPoolReusesConnections(t *testing.T) {
+	address := startServer(t)
+
+	pool := client.NewPool("tcp", address, 2, codec.New(0), 0)
+	defer pool.Close()
+
+	for i := 0; i < 10; i++ {
+		response, err := pool.Do(models.Request{Action: "not-an-action"})
+		assert.NoError(t, err, "expected no error, got %v", err)
+		assert.NotEmpty(t, response.Id, "pool should tag requests with an ID")
+	}
+}
+
+func TestOversizedResponseIsReported(t *testing.T) {
+	address := startServer(t)
+
+	conn, err := client.Dial("tcp", address, codec.New(0), 2*time.Second)
+	assert.NoError(t, err, "expected no error, got %v", err)
+	defer conn.Close()
+
+	response, _ := conn.Do(models.Request{Action: "register", Data: models.RegisterRequest{
+		Name: "Paulinho da Viola", Username: "paulinhodaviola", Password: "senhaSegura123",
+	}})
+	assert.Empty(t, response.Error, "expected no error, got %s", response.Error)
+
+	// The login response carries a token, which does not fit in the limit, while the request does.
+	server.Codec = codec.New(200)
+	defer func() { server.Codec = codec.New(codec.DefaultMaxMessageSize) }()
+
+	response, err = conn.Do(models.Request{Id: "oversized", Action: "login", Data: models.LoginCredentials{
+		Username: "paulinhodaviola", Password: "senhaSegura123",
+	}})
+	assert.NoError(t, err, "the request should be answered instead of timing out, got %v", err)
+	assert.Equal(t, "oversized", response.Id)
+	assert.Equal(t, "response too large", response.Error)
+}
+
+func TestUndecodableResponseFailsRequest(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err, "expected no error, got %v", err)
+	defer listener.Close()
+
+	// The server answers every request with a response whose Data is not an object.
+	go func() {
+		serverConn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer serverConn.Close()
+
+		c := codec.New(0)
+		for {
+			var request models.Request
+			if err := c.Read(serverConn, &request); err != nil {
+				return
+			}
+			c.Write(serverConn, map[string]interface{}{"Id": request.Id, "Data": "not an object"})
+		}
+	}()
+
+	conn, err := client.Dial("tcp", listener.Addr().String(), codec.New(0), 2*time.Second)
+	assert.NoError(t, err, "expected no error, got %v", err)
+	defer conn.Close()
+
+	_, err = conn.Do(models.Request{Action: "login"})
+	assert.ErrorIs(t, err, client.ErrInvalidResponse)
+	assert.False(t, conn.Broken(), "the connection should still be usable")
+}
@@ -0,0 +1,71 @@
+package tests
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"vendepass/internal/codec"
+	"vendepass/internal/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCodecRoundTrip(t *testing.T) {
+	c := codec.New(codec.DefaultMaxMessageSize)
+	var buffer bytes.Buffer
+
+	sent := models.Response{Data: map[string]interface{}{"msg": strings.Repeat("a", 10000)}}
+
+	err := c.Write(&buffer, sent)
+	assert.NoError(t, err, "expected no error, got %v", err)
+
+	var received models.Response
+	err = c.Read(&buffer, &received)
+
+	assert.NoError(t, err, "expected no error, got %v", err)
+	assert.Equal(t, sent.Data["msg"], received.Data["msg"], "messages larger than the old buffer should not be truncated")
+}
+
+func TestCodecMultipleMessages(t *testing.T) {
+	c := codec.New(0)
+	var buffer bytes.Buffer
+
+	c.Write(&buffer, models.Request{Action: "login"})
+	c.Write(&buffer, models.Request{Action: "logout"})
+
+	var first, second models.Request
+	assert.NoError(t, c.Read(&buffer, &first))
+	assert.NoError(t, c.Read(&buffer, &second))
+	assert.Equal(t, "login", first.Action)
+	assert.Equal(t, "logout", second.Action)
+
+	err := c.Read(&buffer, &first)
+	assert.True(t, errors.Is(err, io.EOF), "expected EOF after the last message, got %v", err)
+}
+
+func TestCodecMaxMessageSize(t *testing.T) {
+	c := codec.New(16)
+	var buffer bytes.Buffer
+
+	err := c.Write(&buffer, models.Request{Action: "all-routes"})
+	assert.ErrorIs(t, err, codec.ErrMessageTooLarge)
+
+	codec.New(1024).Write(&buffer, models.Request{Action: "all-routes"})
+
+	var request models.Request
+	err = c.Read(&buffer, &request)
+	assert.ErrorIs(t, err, codec.ErrMessageTooLarge)
+}
+
+func TestCodecFormatError(t *testing.T) {
+	c := codec.New(0)
+	var buffer bytes.Buffer
+
+	c.Write(&buffer, "not a request")
+
+	var request models.Request
+	err := c.Read(&buffer, &request)
+	assert.True(t, codec.IsFormatError(err), "expected a format error, got %v", err)
+}
@@ -0,0 +1,93 @@
+package tests
+
+import (
+	"testing"
+	"vendepass/internal/client"
+	"vendepass/internal/codec"
+	"vendepass/internal/dao"
+	"vendepass/internal/models"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFareQuote(t *testing.T) {
+	fare := models.Fare{BasePrice: 40000, Currency: "BRL", Classes: []models.FareClass{
+		{Code: "light"}, {Code: "flex", Markup: 50},
+	}}
+
+	price, code, err := fare.Quote("flex")
+	assert.NoError(t, err, "expected no error, got %v", err)
+	assert.Equal(t, "flex", code)
+	assert.Equal(t, models.Price{Amount: 60000, Currency: "BRL"}, price)
+	assert.Equal(t, "BRL 600.00", price.String())
+
+	_, code, _ = fare.Quote("")
+	assert.Equal(t, "light", code, "the first class should be quoted by default")
+
+	_, _, err = fare.Quote("business")
+	assert.ErrorIs(t, err, models.ErrFareClassNotSold)
+
+	totals := models.Total([]models.Price{{Amount: 100, Currency: "BRL"}, {Amount: 50, Currency: "USD"}, {Amount: 25, Currency: "BRL"}})
+	assert.Equal(t, []models.Price{{Amount: 125, Currency: "BRL"}, {Amount: 50, Currency: "USD"}}, totals)
+}
+
+func TestTicketKeepsPricePaid(t *testing.T) {
+	loadAirportDAO(t)
+	address := startServer(t)
+
+	conn, err := client.Dial("tcp", address, codec.New(0), 0)
+	assert.NoError(t, err, "expected no error, got %v", err)
+	defer conn.Close()
+
+	src := &models.Airport{Name: "Aeroporto de Juazeiro", City: models.City{Name: "Juazeiro"}}
+	dest := &models.Airport{Name: "Aeroporto de Petrolina", City: models.City{Name: "Petrolina"}}
+	dao.GetAirportDAO().Insert(src)
+	dao.GetAirportDAO().Insert(dest)
+	defer dao.GetAirportDAO().Delete(src)
+	defer dao.GetAirportDAO().Delete(dest)
+
+	flight := &models.Flight{SourceAirportId: src.Id, DestAirportId: dest.Id, Seats: 5, Fare: models.Fare{
+		BasePrice: 20000, Currency: "BRL", Classes: []models.FareClass{{Code: "light"}, {Code: "flex", Markup: 50}},
+	}}
+	dao.GetFlightDAO().Insert(flight)
+	flight.Start()
+	defer dao.GetFlightDAO().Delete(flight)
+
+	admin := registerAdmin(t, conn, "joaogilberto")
+
+	response, _ := conn.Do(models.Request{Action: "register", Data: models.RegisterRequest{
+		Name: "Maria Bethânia", Username: "mariabethania", Password: "senhaSegura123",
+	}})
+	assert.Empty(t, response.Error, "expected no error, got %s", response.Error)
+	customer := loginAs(t, conn, "mariabethania", "senhaSegura123", "")
+
+	response, _ = conn.Do(models.Request{Action: "reservation", Auth: customer, Data: models.FlightsRequest{FlightIds: []uuid.UUID{flight.Id}, FareClass: "first"}})
+	assert.NotEmpty(t, response.Error, "a fare class the flight does not sell should be rejected")
+
+	response, _ = conn.Do(models.Request{Action: "reservation", Auth: customer, Data: models.FlightsRequest{FlightIds: []uuid.UUID{flight.Id}, FareClass: "flex"}})
+	assert.Empty(t, response.Error, "expected no error, got %s", response.Error)
+
+	response, _ = conn.Do(models.Request{Action: "cart", Auth: customer})
+	reservations, _ := response.Data["Reservations"].([]interface{})
+	assert.Len(t, reservations, 1)
+	item, _ := reservations[0].(map[string]interface{})
+	assert.Equal(t, "flex", item["FareClass"])
+	assert.Equal(t, map[string]interface{}{"Amount": float64(30000), "Currency": "BRL"}, item["Price"])
+	assert.Equal(t, []interface{}{map[string]interface{}{"Amount": float64(30000), "Currency": "BRL"}}, response.Data["Total"])
+
+	reservationId, _ := uuid.Parse(item["Id"].(string))
+	response, _ = conn.Do(models.Request{Action: "buy", Auth: customer, Data: models.BuyTicket{ReservationId: reservationId}})
+	assert.Empty(t, response.Error, "expected no error, got %s", response.Error)
+
+	response, _ = conn.Do(models.Request{Action: "admin-update-fare", Auth: admin, Data: models.UpdateFareRequest{
+		FlightId: flight.Id, Fare: models.Fare{BasePrice: 90000, Currency: "BRL", Classes: []models.FareClass{{Code: "flex"}}},
+	}})
+	assert.Empty(t, response.Error, "expected no error, got %s", response.Error)
+
+	response, _ = conn.Do(models.Request{Action: "tickets", Auth: customer})
+	tickets, _ := response.Data["Tickets"].([]interface{})
+	assert.Len(t, tickets, 1)
+	ticket, _ := tickets[0].(map[string]interface{})
+	assert.Equal(t, map[string]interface{}{"Amount": float64(30000), "Currency": "BRL"}, ticket["Price"], "the ticket should keep the price paid")
+}
@@ -2,6 +2,9 @@ package tests
 
 import (
 	"testing"
+	"time"
+	"vendepass/internal/client"
+	"vendepass/internal/codec"
 	"vendepass/internal/dao"
 	"vendepass/internal/models"
 
@@ -115,11 +118,12 @@ func TestFindBySourceAndDest(t *testing.T) {
 
 	flightDAO.Insert(&models.Flight{SourceAirportId: sourceID, DestAirportId: destID, Seats: 10})
 
-	flight, err := flightDAO.FindBySourceAndDest(sourceID, destID)
+	flights, err := flightDAO.FindBySourceAndDest(sourceID, destID, time.Now())
 
 	assert.NoError(t, err, "expected no error, got %v", err)
-	assert.Equal(t, sourceID, flight.SourceAirportId)
-	assert.Equal(t, destID, flight.DestAirportId)
+	assert.Equal(t, 1, len(flights), "expected 1 flight, got %d", len(flights))
+	assert.Equal(t, sourceID, flights[0].SourceAirportId)
+	assert.Equal(t, destID, flights[0].DestAirportId)
 }
 
 func TestFindBySource(t *testing.T) {
@@ -141,11 +145,42 @@ func TestFindBySource(t *testing.T) {
 	}
 }
 
+func TestMultipleFlightsPerPair(t *testing.T) {
+	flightDAO := dao.GetFlightDAO()
+	defer flightDAO.DeleteAll()
+
+	sourceID := uuid.New()
+	destID := uuid.New()
+
+	first := &models.Flight{SourceAirportId: sourceID, DestAirportId: destID, Seats: 10}
+	second := &models.Flight{SourceAirportId: sourceID, DestAirportId: destID, Seats: 5}
+	flightDAO.Insert(first)
+	flightDAO.Insert(second)
+
+	flights, err := flightDAO.FindBySourceAndDest(sourceID, destID, time.Time{})
+	assert.NoError(t, err, "expected no error, got %v", err)
+	assert.Equal(t, 2, len(flights), "the second flight should not overwrite the first")
+
+	for _, flight := range []*models.Flight{first, second} {
+		retrievedFlight, err := flightDAO.FindById(flight.Id)
+		assert.NoError(t, err, "expected no error, got %v", err)
+		assert.Equal(t, flight, retrievedFlight, "flights should be the same")
+	}
+
+	flightDAO.Delete(first)
+
+	flights, _ = flightDAO.FindBySourceAndDest(sourceID, destID, time.Time{})
+	assert.Equal(t, []*models.Flight{second}, flights, "only the deleted flight should be removed")
+
+	_, err = flightDAO.FindById(first.Id)
+	assert.Error(t, err, "expected error upon trying to find deleted flight, got %v", err)
+}
+
 func TestFindBySourceAndDestWithInvalidIDs(t *testing.T) {
 	flightDAO := dao.GetFlightDAO()
 	defer flightDAO.DeleteAll()
 
-	_, err := flightDAO.FindBySourceAndDest(uuid.Nil, uuid.Nil)
+	_, err := flightDAO.FindBySourceAndDest(uuid.Nil, uuid.Nil, time.Time{})
 	assert.Error(t, err, "expected error, got %v", err)
 
 	_, err = flightDAO.FindBySource(uuid.Nil)
@@ -163,7 +198,7 @@ func TestBFS(t *testing.T) {
 	flightDAO.Insert(&models.Flight{SourceAirportId: sourceID, DestAirportId: middleID, Seats: 10})
 	flightDAO.Insert(&models.Flight{SourceAirportId: middleID, DestAirportId: destID, Seats: 5})
 
-	path, err := flightDAO.BreadthFirstSearch(sourceID, destID)
+	path, err := flightDAO.BreadthFirstSearch(sourceID, destID, time.Time{}, "")
 
 	assert.NoError(t, err, "expected no errors, got %v", err)
 	assert.NotNil(t, path, "expected path, got %v", path)
@@ -179,8 +214,38 @@ func TestBFS(t *testing.T) {
 	}
 
 	unreachableID := uuid.New()
-	path, err = flightDAO.BreadthFirstSearch(sourceID, unreachableID)
+	path, err = flightDAO.BreadthFirstSearch(sourceID, unreachableID, time.Time{}, "")
 
 	assert.Error(t, err, "expected error, got %v", err)
 	assert.Nil(t, path, "expected path to be nil, got %v")
 }
+
+func TestAllRoutes(t *testing.T) {
+	address := startServer(t)
+	defer dao.GetFlightDAO().DeleteAll()
+
+	conn, err := client.Dial("tcp", address, codec.New(0), 2*time.Second)
+	assert.NoError(t, err, "expected no error, got %v", err)
+	defer conn.Close()
+
+	flight := &models.Flight{SourceAirportId: uuid.New(), DestAirportId: uuid.New(), Seats: 10}
+	dao.GetFlightDAO().Insert(flight)
+	flight.Start()
+
+	ticket, err := flight.AcceptReservation("", "", "")
+	assert.NoError(t, err, "expected no error, got %v", err)
+	ticket.Passenger = &models.Passenger{Name: "Maria Souza", Document: "12345678900", DateOfBirth: "1980-01-01"}
+	flight.Passengers = append(flight.Passengers, ticket)
+
+	token := registerAdmin(t, conn, "caetanoveloso")
+	response, err := conn.Do(models.Request{Action: "all-routes", Auth: token})
+	assert.NoError(t, err, "expected no error, got %v", err)
+	assert.Empty(t, response.Error, "expected no error, got %s", response.Error)
+
+	routes, _ := response.Data["all-routes"].([]interface{})
+	assert.Len(t, routes, 1)
+	route, _ := routes[0].(map[string]interface{})
+	assert.Equal(t, flight.Id.String(), route["Id"])
+	assert.Equal(t, float64(9), route["Seats"])
+	assert.NotContains(t, route, "Passengers", "the passengers' personal data should not be sent")
+}
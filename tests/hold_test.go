@@ -0,0 +1,135 @@
+package tests
+
+import (
+	"testing"
+	"time"
+	"vendepass/internal/client"
+	"vendepass/internal/codec"
+	"vendepass/internal/dao"
+	"vendepass/internal/models"
+	"vendepass/internal/server"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReservationHoldExpires(t *testing.T) {
+	loadAirportDAO(t)
+	address := startServer(t)
+
+	server.HoldDuration = 300 * time.Millisecond
+	server.MaxHoldExtensions = 1
+	defer func() {
+		server.HoldDuration = server.DefaultHoldDuration
+		server.MaxHoldExtensions = server.DefaultMaxHoldExtensions
+	}()
+
+	conn, err := client.Dial("tcp", address, codec.New(0), 0)
+	assert.NoError(t, err, "expected no error, got %v", err)
+	defer conn.Close()
+
+	src := &models.Airport{Name: "Aeroporto de Petrolina", City: models.City{Name: "Petrolina"}}
+	dest := &models.Airport{Name: "Aeroporto de Mossoró", City: models.City{Name: "Mossoró"}}
+	dao.GetAirportDAO().Insert(src)
+	dao.GetAirportDAO().Insert(dest)
+	defer dao.GetAirportDAO().Delete(src)
+	defer dao.GetAirportDAO().Delete(dest)
+
+	token := registerAdmin(t, conn, "moraesmoreira")
+	response, _ := conn.Do(models.Request{Action: "admin-create-flight", Auth: token, Data: models.CreateFlightRequest{
+		SourceAirportId: src.Id, DestAirportId: dest.Id, Seats: 1,
+	}})
+	assert.Empty(t, response.Error, "expected no error, got %s", response.Error)
+
+	flights, _ := dao.GetFlightDAO().FindBySourceAndDest(src.Id, dest.Id, time.Time{})
+	flight := flights[0]
+	defer dao.GetFlightDAO().Delete(flight)
+
+	response, _ = conn.Do(models.Request{Action: "reservation", Auth: token, Data: models.FlightsRequest{FlightIds: []uuid.UUID{flight.Id}}})
+	assert.Empty(t, response.Error, "expected no error, got %s", response.Error)
+	assert.NotEmpty(t, response.Data["ExpiresAt"])
+
+	response, _ = conn.Do(models.Request{Action: "cart", Auth: token})
+	reservations, _ := response.Data["Reservations"].([]interface{})
+	assert.Len(t, reservations, 1)
+	reservation := reservations[0].(map[string]interface{})
+	assert.NotEmpty(t, reservation["ExpiresAt"])
+	assert.Equal(t, float64(1), reservation["ExtensionsLeft"])
+
+	reservationId, _ := uuid.Parse(reservation["Id"].(string))
+	response, _ = conn.Do(models.Request{Action: "extend-reservation", Auth: token, Data: models.ExtendReservationRequest{ReservationId: reservationId}})
+	assert.Empty(t, response.Error, "expected no error, got %s", response.Error)
+	assert.Equal(t, float64(0), response.Data["ExtensionsLeft"])
+
+	response, _ = conn.Do(models.Request{Action: "extend-reservation", Auth: token, Data: models.ExtendReservationRequest{ReservationId: reservationId}})
+	assert.Equal(t, "reservation cannot be extended any further", response.Error)
+
+	// The seat returns to sale as soon as the hold expires, without waiting for the session cleanup.
+	account, _ := dao.GetClientDAO().FindByUsername("moraesmoreira")
+	cart, _ := dao.GetCartDAO().FindByClientId(account.Id)
+	assert.Eventually(t, func() bool {
+		cart.Mu.RLock()
+		defer cart.Mu.RUnlock()
+		return len(cart.Reservations) == 0
+	}, 2*time.Second, 10*time.Millisecond, "the reservation should expire")
+
+	flight.Mu.Lock()
+	assert.Equal(t, uint(1), flight.Seats)
+	flight.Mu.Unlock()
+
+	response, _ = conn.Do(models.Request{Action: "extend-reservation", Auth: token, Data: models.ExtendReservationRequest{ReservationId: reservationId}})
+	assert.Equal(t, "reservation do not exists", response.Error)
+}
+
+func TestExpiredReservationIsNotBought(t *testing.T) {
+	loadAirportDAO(t)
+	address := startServer(t)
+
+	conn, err := client.Dial("tcp", address, codec.New(0), 0)
+	assert.NoError(t, err, "expected no error, got %v", err)
+	defer conn.Close()
+
+	src := &models.Airport{Name: "Aeroporto de Garanhuns", City: models.City{Name: "Garanhuns"}}
+	dest := &models.Airport{Name: "Aeroporto de Serra Talhada", City: models.City{Name: "Serra Talhada"}}
+	dao.GetAirportDAO().Insert(src)
+	dao.GetAirportDAO().Insert(dest)
+	defer dao.GetAirportDAO().Delete(src)
+	defer dao.GetAirportDAO().Delete(dest)
+
+	token := registerAdmin(t, conn, "geraldoazevedo")
+	response, _ := conn.Do(models.Request{Action: "admin-create-flight", Auth: token, Data: models.CreateFlightRequest{
+		SourceAirportId: src.Id, DestAirportId: dest.Id, Seats: 1,
+	}})
+	assert.Empty(t, response.Error, "expected no error, got %s", response.Error)
+
+	flights, _ := dao.GetFlightDAO().FindBySourceAndDest(src.Id, dest.Id, time.Time{})
+	flight := flights[0]
+	defer dao.GetFlightDAO().Delete(flight)
+
+	response, _ = conn.Do(models.Request{Action: "reservation", Auth: token, Data: models.FlightsRequest{FlightIds: []uuid.UUID{flight.Id}}})
+	assert.Empty(t, response.Error, "expected no error, got %s", response.Error)
+
+	// The hold expires while the expiry loop has not run yet, as when it expires during a payment.
+	account, _ := dao.GetClientDAO().FindByUsername("geraldoazevedo")
+	cart, _ := dao.GetCartDAO().FindByClientId(account.Id)
+	var reservationId uuid.UUID
+	cart.Mu.Lock()
+	for id, reservation := range cart.Reservations {
+		reservationId = id
+		reservation.ExpiresAt = time.Now().Add(-time.Second)
+		cart.Reservations[id] = reservation
+	}
+	cart.Mu.Unlock()
+
+	response, _ = conn.Do(models.Request{Action: "buy", Auth: token, Data: models.BuyTicket{ReservationId: reservationId}})
+	assert.Equal(t, "reservation expired", response.Error)
+
+	cart.Mu.RLock()
+	assert.Empty(t, cart.Reservations, "the expired reservation should not go back to the cart")
+	cart.Mu.RUnlock()
+
+	flight.Mu.Lock()
+	assert.Equal(t, uint(1), flight.Seats, "the seat should be released")
+	assert.Empty(t, flight.Passengers)
+	flight.Mu.Unlock()
+}
@@ -0,0 +1,157 @@
+package tests
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"vendepass/internal/dao"
+	"vendepass/internal/journal"
+	"vendepass/internal/models"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJournalFlightRecovery(t *testing.T) {
+	dir := t.TempDir()
+
+	j, err := journal.Open(dir)
+	assert.NoError(t, err, "expected no error, got %v", err)
+	assert.True(t, j.Fresh(), "a new journal should be fresh")
+
+	flights := dao.NewJournalFlightDAO(j)
+	flights.New()
+
+	flight := &models.Flight{SourceAirportId: uuid.New(), DestAirportId: uuid.New(), Seats: 3}
+	flights.Insert(flight)
+
+	ticket, _ := flight.AcceptReservation("", "", "")
+	flight.Passengers = append(flight.Passengers, ticket)
+	assert.NoError(t, flights.Update(flight))
+
+	removed := &models.Flight{SourceAirportId: uuid.New(), DestAirportId: uuid.New(), Seats: 1}
+	flights.Insert(removed)
+	assert.NoError(t, flights.Delete(removed))
+
+	j.Close()
+
+	j, err = journal.Open(dir)
+	assert.NoError(t, err, "expected no error, got %v", err)
+	defer j.Close()
+	assert.False(t, j.Fresh(), "a journal with entries should not be fresh")
+
+	flights = dao.NewJournalFlightDAO(j)
+	flights.New()
+
+	restored, err := flights.FindById(flight.Id)
+	assert.NoError(t, err, "expected no error, got %v", err)
+	assert.Equal(t, uint(2), restored.Seats)
+	assert.Len(t, restored.Passengers, 1)
+
+	_, err = flights.FindById(removed.Id)
+	assert.Error(t, err, "deleted flight should not be recovered")
+}
+
+func TestJournalCompaction(t *testing.T) {
+	dir := t.TempDir()
+
+	j, err := journal.Open(dir)
+	assert.NoError(t, err, "expected no error, got %v", err)
+
+	id := uuid.New()
+	for seats := 10; seats > 0; seats-- {
+		j.Append(journal.Put, "flight", id, models.FlightJSON{Id: id, Seats: uint(seats)})
+	}
+	assert.Equal(t, 10, j.Pending())
+
+	data, _ := json.Marshal(models.FlightJSON{Id: id, Seats: 1})
+	err = j.Compact(func() []journal.Entry {
+		return []journal.Entry{{Kind: "flight", Id: id, Data: data}}
+	})
+	assert.NoError(t, err, "expected no error, got %v", err)
+	assert.Equal(t, 0, j.Pending())
+
+	info, _ := os.Stat(filepath.Join(dir, "journal.log"))
+	assert.Equal(t, int64(0), info.Size(), "journal should be empty after compaction")
+
+	j.Append(journal.Put, "flight", id, models.FlightJSON{Id: id, Seats: 0})
+
+	var replayed []uint
+	j.Replay("flight", func(entry journal.Entry) error {
+		var f models.FlightJSON
+		json.Unmarshal(entry.Data, &f)
+		replayed = append(replayed, f.Seats)
+		return nil
+	})
+	assert.Equal(t, []uint{1, 0}, replayed, "replay should apply the snapshot and then the newer entries")
+	j.Close()
+}
+
+func TestJournalIgnoresTornEntry(t *testing.T) {
+	dir := t.TempDir()
+
+	j, _ := journal.Open(dir)
+	j.Append(journal.Put, "client", uuid.New(), models.Client{Username: "joaosilva"})
+	j.Close()
+
+	f, _ := os.OpenFile(filepath.Join(dir, "journal.log"), os.O_APPEND|os.O_WRONLY, 0644)
+	f.WriteString(`{"Seq":2,"Op":"put","Ki`)
+	f.Close()
+
+	j, err := journal.Open(dir)
+	assert.NoError(t, err, "expected no error, got %v", err)
+	defer j.Close()
+
+	j.Append(journal.Put, "client", uuid.New(), models.Client{Username: "mariasouza"})
+
+	count := 0
+	err = j.Replay("client", func(journal.Entry) error {
+		count++
+		return nil
+	})
+	assert.NoError(t, err, "expected no error, got %v", err)
+	assert.Equal(t, 2, count, "torn entry should be dropped and new entries kept")
+}
+
+func TestJournalConcurrentUpdatesKeepLastState(t *testing.T) {
+	dir := t.TempDir()
+
+	j, err := journal.Open(dir)
+	assert.NoError(t, err, "expected no error, got %v", err)
+
+	clients := dao.NewJournalClientDAO(j)
+	clients.New()
+
+	client := &models.Client{Name: "Jorge Ben", Username: "jorgeben"}
+	assert.NoError(t, clients.Insert(client))
+
+	// Every ticket is added by its own update; whatever order they are journaled in, the last entry holds them all.
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := clients.Modify(client.Id, func(client *models.Client) error {
+				client.Client_flights = append(client.Client_flights, &models.Ticket{Id: uuid.New(), ClientId: client.Id})
+				return nil
+			})
+			assert.NoError(t, err, "expected no error, got %v", err)
+		}()
+	}
+	wg.Wait()
+
+	j.Close()
+
+	j, err = journal.Open(dir)
+	assert.NoError(t, err, "expected no error, got %v", err)
+	defer j.Close()
+
+	clients = dao.NewJournalClientDAO(j)
+	clients.New()
+
+	restored, err := clients.FindById(client.Id)
+	assert.NoError(t, err, "expected no error, got %v", err)
+	assert.Len(t, restored.Client_flights, 50, "no ticket should be lost on replay")
+}
@@ -0,0 +1,80 @@
+package tests
+
+import (
+	"testing"
+	"vendepass/internal/client"
+	"vendepass/internal/codec"
+	"vendepass/internal/dao"
+	"vendepass/internal/models"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func loginAs(t *testing.T, conn *client.Conn, username string, password string, device string) string {
+	response, err := conn.Do(models.Request{Action: "login", Data: models.LoginCredentials{
+		Username: username, Password: password, Device: device,
+	}})
+	assert.NoError(t, err, "expected no error, got %v", err)
+	token, _ := response.Data["token"].(string)
+	assert.NotEmpty(t, token, "expected a session token, got %v", response)
+	return token
+}
+
+func TestReservationsAreSharedBetweenSessions(t *testing.T) {
+	address := startServer(t)
+
+	conn, err := client.Dial("tcp", address, codec.New(0), 0)
+	assert.NoError(t, err, "expected no error, got %v", err)
+	defer conn.Close()
+
+	flight := &models.Flight{Id: uuid.New(), SourceAirportId: uuid.New(), DestAirportId: uuid.New(), Seats: 2}
+	dao.GetFlightDAO().Insert(flight)
+	defer dao.GetFlightDAO().Delete(flight)
+	go flight.ProcessReservations()
+
+	response, _ := conn.Do(models.Request{Action: "register", Data: models.RegisterRequest{
+		Name: "Clara Nunes", Username: "claranunes", Password: "senhaSegura123",
+	}})
+	assert.Empty(t, response.Error, "expected no error, got %s", response.Error)
+
+	phone := loginAs(t, conn, "claranunes", "senhaSegura123", "phone")
+	laptop := loginAs(t, conn, "claranunes", "senhaSegura123", "laptop")
+
+	response, _ = conn.Do(models.Request{Action: "sessions", Auth: laptop})
+	sessions, _ := response.Data["Sessions"].([]interface{})
+	assert.Len(t, sessions, 2, "expected 2 sessions, got %d", len(sessions))
+
+	response, _ = conn.Do(models.Request{Action: "reservation", Auth: phone, Data: models.FlightsRequest{
+		FlightIds: []uuid.UUID{flight.Id},
+	}})
+	assert.Empty(t, response.Error, "expected no error, got %s", response.Error)
+
+	var phoneSession uuid.UUID
+	for _, s := range sessions {
+		if s, _ := s.(map[string]interface{}); s["Device"] == "phone" {
+			phoneSession = uuid.MustParse(s["Id"].(string))
+		}
+	}
+
+	response, _ = conn.Do(models.Request{Action: "revoke-session", Auth: laptop, Data: models.RevokeSessionRequest{
+		SessionId: phoneSession,
+	}})
+	assert.Empty(t, response.Error, "expected no error, got %s", response.Error)
+
+	response, _ = conn.Do(models.Request{Action: "get-user", Auth: phone})
+	assert.NotEmpty(t, response.Error, "revoked session should not be usable")
+
+	user, _ := dao.GetClientDAO().FindByUsername("claranunes")
+	cart, err := dao.GetCartDAO().FindByClientId(user.Id)
+	assert.NoError(t, err, "expected no error, got %v", err)
+	assert.Len(t, cart.Reservations, 1, "reservation should outlive the session that made it")
+	assert.Equal(t, uint(1), flight.Seats)
+
+	response, _ = conn.Do(models.Request{Action: "logout", Auth: laptop})
+	assert.Empty(t, response.Error, "expected no error, got %s", response.Error)
+
+	_, err = dao.GetCartDAO().FindByClientId(user.Id)
+	assert.Error(t, err, "cart should be released with the last session")
+	assert.Equal(t, uint(2), flight.Seats, "seat should return to the flight")
+}
@@ -0,0 +1,45 @@
+package tests
+
+import (
+	"sync"
+	"testing"
+	"vendepass/internal/dao"
+	"vendepass/internal/models"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTakeNotificationsLosesNone(t *testing.T) {
+	notificationDAO := dao.GetNotificationDAO()
+	defer notificationDAO.DeleteAll()
+
+	clientId := uuid.New()
+	const sent = 200
+
+	var wg sync.WaitGroup
+	done := make(chan struct{})
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < sent; i++ {
+			notificationDAO.Insert(&models.Notification{ClientId: clientId, Message: "voo alterado"})
+		}
+	}()
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	delivered := 0
+	for taking := true; taking; {
+		select {
+		case <-done:
+			taking = false
+		default:
+		}
+		delivered += len(notificationDAO.TakeByClientId(clientId))
+	}
+
+	assert.Equal(t, sent, delivered, "every notification should be delivered exactly once")
+}
@@ -0,0 +1,139 @@
+package tests
+
+import (
+	"fmt"
+	"testing"
+	"time"
+	"vendepass/internal/client"
+	"vendepass/internal/codec"
+	"vendepass/internal/dao"
+	"vendepass/internal/models"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+// family is a couple traveling with their daughter.
+var family = []models.Passenger{
+	{Name: "Maria Souza", Document: "12345678900", DateOfBirth: "1980-05-17"},
+	{Name: "João Souza", Document: "98765432100", DateOfBirth: "1978-11-02"},
+	{Name: "Ana Souza", Document: "45678912300", DateOfBirth: "2015-03-09"},
+}
+
+func TestValidatePassengers(t *testing.T) {
+	assert.NoError(t, models.ValidatePassengers(family))
+	assert.NoError(t, models.ValidatePassengers(nil), "the client alone needs no passengers")
+
+	invalid := []models.Passenger{
+		{Document: "123", DateOfBirth: "1980-01-01"},
+		{Name: "Sem Documento", DateOfBirth: "1980-01-01"},
+		{Name: "Data Inválida", Document: "123", DateOfBirth: "01/01/1980"},
+		{Name: "Do Futuro", Document: "123", DateOfBirth: time.Now().AddDate(1, 0, 0).Format(models.DateLayout)},
+	}
+	for _, passenger := range invalid {
+		assert.Error(t, passenger.Validate(), "expected error for %v", passenger)
+	}
+
+	twice := append([]models.Passenger{}, family[0], family[0])
+	assert.Error(t, models.ValidatePassengers(twice), "the same document should not fly twice")
+
+	crowd := make([]models.Passenger, models.MaxPassengers+1)
+	for i := range crowd {
+		crowd[i] = models.Passenger{Name: "Passageiro", Document: fmt.Sprint(i), DateOfBirth: "1990-01-01"}
+	}
+	assert.Error(t, models.ValidatePassengers(crowd))
+}
+
+func TestAcceptReservationsIsAtomic(t *testing.T) {
+	seatMap, _ := models.NewSeatMap(smallSeatMap)
+	flight := &models.Flight{Id: uuid.New(), Seats: 2, Capacity: 20, SeatMap: seatMap}
+
+	_, err := flight.AcceptReservations("", "", []string{"", "", ""})
+	assert.Error(t, err, "three seats should not fit in two, got %v", err)
+	assert.Equal(t, uint(2), flight.Seats, "no seat should be reserved")
+
+	_, err = flight.AcceptReservations("", "", []string{"1A", "1a"})
+	assert.ErrorIs(t, err, models.ErrSeatTaken)
+	assert.False(t, flight.SeatTaken("1A"), "no seat should be taken")
+
+	tickets, err := flight.AcceptReservations("", "", []string{"1A", ""})
+	assert.NoError(t, err, "expected no error, got %v", err)
+	assert.Len(t, tickets, 2)
+	assert.NotEqual(t, tickets[0].Id, tickets[1].Id)
+	assert.Equal(t, "1A", tickets[0].Seat)
+	assert.True(t, flight.SeatTaken("1A"))
+	assert.Equal(t, uint(0), flight.Seats)
+}
+
+func TestReserveForPassengers(t *testing.T) {
+	loadAirportDAO(t)
+	address := startServer(t)
+
+	conn, err := client.Dial("tcp", address, codec.New(0), 0)
+	assert.NoError(t, err, "expected no error, got %v", err)
+	defer conn.Close()
+
+	src := &models.Airport{Name: "Aeroporto de Juazeiro do Norte", City: models.City{Name: "Juazeiro do Norte"}}
+	dest := &models.Airport{Name: "Aeroporto de Sobral", City: models.City{Name: "Sobral"}}
+	dao.GetAirportDAO().Insert(src)
+	dao.GetAirportDAO().Insert(dest)
+	defer dao.GetAirportDAO().Delete(src)
+	defer dao.GetAirportDAO().Delete(dest)
+
+	buyer := registerAdmin(t, conn, "belchior")
+	response, _ := conn.Do(models.Request{Action: "admin-create-flight", Auth: buyer, Data: models.CreateFlightRequest{
+		SourceAirportId: src.Id, DestAirportId: dest.Id, SeatMap: smallSeatMap, Seats: 4,
+	}})
+	assert.Empty(t, response.Error, "expected no error, got %s", response.Error)
+
+	flights, _ := dao.GetFlightDAO().FindBySourceAndDest(src.Id, dest.Id, time.Time{})
+	flight := flights[0]
+	defer dao.GetFlightDAO().Delete(flight)
+
+	response, _ = conn.Do(models.Request{Action: "reservation", Auth: buyer, Data: models.FlightsRequest{
+		FlightIds: []uuid.UUID{flight.Id}, Passengers: []models.Passenger{family[0], {Name: "Sem Documento", DateOfBirth: "1980-01-01"}},
+	}})
+	assert.NotEmpty(t, response.Error, "invalid passengers should be rejected")
+
+	response, _ = conn.Do(models.Request{Action: "reservation", Auth: buyer, Data: models.FlightsRequest{
+		FlightIds: []uuid.UUID{flight.Id}, Passengers: family, PassengerSeats: [][]string{{"3A"}, {"3B"}, {"3C"}},
+	}})
+	assert.Empty(t, response.Error, "expected no error, got %s", response.Error)
+
+	response, _ = conn.Do(models.Request{Action: "reservation", Auth: buyer, Data: models.FlightsRequest{
+		FlightIds: []uuid.UUID{flight.Id}, Passengers: family[:2],
+	}})
+	assert.Equal(t, "at least one flight is not available", response.Error, "two seats should not fit in the one left")
+
+	account, _ := dao.GetClientDAO().FindByUsername("belchior")
+	cart, _ := dao.GetCartDAO().FindByClientId(account.Id)
+	var reservationId uuid.UUID
+	seats := map[string]string{}
+	for id, reservation := range cart.Reservations {
+		reservationId = id
+		seats[reservation.Passenger.Name] = reservation.Seat
+	}
+	assert.Equal(t, map[string]string{"Maria Souza": "3A", "João Souza": "3B", "Ana Souza": "3C"}, seats)
+
+	// Buying one reservation of the group buys the whole family in a single booking.
+	response, _ = conn.Do(models.Request{Action: "buy", Auth: buyer, Data: models.BuyTicket{ReservationId: reservationId}})
+	assert.Empty(t, response.Error, "expected no error, got %s", response.Error)
+	assert.Empty(t, cart.Reservations)
+
+	bookings := dao.GetBookingDAO().FindByClientId(account.Id)
+	assert.Len(t, bookings, 1)
+	assert.Len(t, bookings[0].Tickets, 3)
+	for _, ticket := range bookings[0].Tickets {
+		assert.Equal(t, account.Id, ticket.ClientId, "every ticket should belong to the buyer")
+		assert.NotNil(t, ticket.Passenger)
+	}
+	assert.Len(t, flight.Passengers, 3)
+
+	// Any passenger can find the booking by their own last name, as well as the buyer.
+	for _, name := range []string{"souza", "Belchior"} {
+		response, _ = conn.Do(models.Request{Action: "find-booking", Data: models.FindBookingRequest{LastName: name, Reference: bookings[0].Reference}})
+		assert.Empty(t, response.Error, "expected no error for %s, got %s", name, response.Error)
+	}
+	response, _ = conn.Do(models.Request{Action: "find-booking", Data: models.FindBookingRequest{LastName: "Silva", Reference: bookings[0].Reference}})
+	assert.Equal(t, "booking not found", response.Error)
+}
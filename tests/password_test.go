@@ -0,0 +1,37 @@
+package tests
+
+import (
+	"encoding/json"
+	"testing"
+	"vendepass/internal/auth"
+	"vendepass/internal/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPasswordHashing(t *testing.T) {
+	hash, err := auth.HashPassword("senhaSegura123")
+
+	assert.NoError(t, err, "expected no error, got %v", err)
+	assert.NotEqual(t, "senhaSegura123", hash, "password should not be stored in plain text")
+	assert.True(t, auth.IsHashed(hash), "expected a bcrypt hash, got %s", hash)
+	assert.True(t, auth.PasswordMatches(hash, "senhaSegura123"))
+	assert.False(t, auth.PasswordMatches(hash, "senhaErrada"))
+}
+
+func TestPlainTextPasswordNeverMatches(t *testing.T) {
+	assert.False(t, auth.IsHashed("senhaSegura123"))
+	assert.False(t, auth.PasswordMatches("senhaSegura123", "senhaSegura123"), "unmigrated passwords should be rejected")
+}
+
+func TestClientResponseHidesPassword(t *testing.T) {
+	client := &models.Client{Name: "João Silva", Username: "joaosilva", Password: "$2a$10$hash"}
+
+	b, _ := json.Marshal(models.NewClientResponse(client))
+
+	var fields map[string]interface{}
+	json.Unmarshal(b, &fields)
+
+	assert.Equal(t, "joaosilva", fields["Username"])
+	assert.NotContains(t, fields, "Password", "response should never expose the password")
+}
@@ -0,0 +1,187 @@
+package tests
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+	"vendepass/internal/client"
+	"vendepass/internal/codec"
+	"vendepass/internal/dao"
+	"vendepass/internal/models"
+	"vendepass/internal/payment"
+	"vendepass/internal/server"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFakePaymentProvider(t *testing.T) {
+	provider := payment.NewFake()
+	amount := models.Price{Amount: 5000, Currency: "BRL"}
+
+	intent, err := provider.CreateIntent(uuid.New(), amount)
+	assert.NoError(t, err, "expected no error, got %v", err)
+	assert.Equal(t, payment.StatusPending, intent.Status)
+
+	assert.ErrorIs(t, provider.Refund(intent.Id), payment.ErrInvalidStatus, "pending intents cannot be refunded")
+	assert.NoError(t, provider.Capture(context.Background(), intent.Id))
+	assert.NoError(t, provider.Refund(intent.Id))
+
+	stored, _ := provider.Intent(intent.Id)
+	assert.Equal(t, payment.StatusRefunded, stored.Status)
+
+	provider.SetDecline(true)
+	intent, _ = provider.CreateIntent(uuid.New(), amount)
+	assert.ErrorIs(t, provider.Capture(context.Background(), intent.Id), payment.ErrDeclined)
+
+	provider.SetDecline(false)
+	provider.SetLatency(time.Second)
+	intent, _ = provider.CreateIntent(uuid.New(), amount)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, provider.Capture(ctx, intent.Id), context.DeadlineExceeded)
+	assert.NoError(t, provider.Cancel(intent.Id))
+
+	stored, _ = provider.Intent(intent.Id)
+	assert.Equal(t, payment.StatusCanceled, stored.Status)
+}
+
+func TestBuyTicketCapturesPayment(t *testing.T) {
+	loadAirportDAO(t)
+	address := startServer(t)
+
+	provider := payment.NewFake()
+	server.Payments = provider
+	defer func() { server.Payments = payment.NewFake() }()
+
+	conn, err := client.Dial("tcp", address, codec.New(0), 0)
+	assert.NoError(t, err, "expected no error, got %v", err)
+	defer conn.Close()
+
+	src := &models.Airport{Name: "Aeroporto de Petrolina", City: models.City{Name: "Petrolina"}}
+	dest := &models.Airport{Name: "Aeroporto de Juazeiro do Norte", City: models.City{Name: "Juazeiro do Norte"}}
+	dao.GetAirportDAO().Insert(src)
+	dao.GetAirportDAO().Insert(dest)
+	defer dao.GetAirportDAO().Delete(src)
+	defer dao.GetAirportDAO().Delete(dest)
+
+	flight := &models.Flight{SourceAirportId: src.Id, DestAirportId: dest.Id, Seats: 2, Fare: models.Fare{BasePrice: 25000, Currency: "BRL"}}
+	dao.GetFlightDAO().Insert(flight)
+	flight.Start()
+	defer dao.GetFlightDAO().Delete(flight)
+
+	response, _ := conn.Do(models.Request{Action: "register", Data: models.RegisterRequest{
+		Name: "Alceu Valença", Username: "alceuvalenca", Password: "senhaSegura123",
+	}})
+	assert.Empty(t, response.Error, "expected no error, got %s", response.Error)
+	token := loginAs(t, conn, "alceuvalenca", "senhaSegura123", "")
+
+	response, _ = conn.Do(models.Request{Action: "reservation", Auth: token, Data: models.FlightsRequest{FlightIds: []uuid.UUID{flight.Id}}})
+	assert.Empty(t, response.Error, "expected no error, got %s", response.Error)
+
+	alceu, _ := dao.GetClientDAO().FindByUsername("alceuvalenca")
+	cart, _ := dao.GetCartDAO().FindByClientId(alceu.Id)
+	var reservationId uuid.UUID
+	for id := range cart.Reservations {
+		reservationId = id
+	}
+
+	provider.SetDecline(true)
+	response, _ = conn.Do(models.Request{Action: "buy", Auth: token, Data: models.BuyTicket{ReservationId: reservationId}})
+	assert.Equal(t, "payment declined", response.Error)
+	assert.Contains(t, cart.Reservations, reservationId, "the seat should go back to the cart")
+	assert.Equal(t, uint(1), flight.Seats, "the seat should stay held")
+
+	provider.SetDecline(false)
+	provider.SetLatency(time.Second)
+	server.PaymentTimeout = 20 * time.Millisecond
+	response, _ = conn.Do(models.Request{Action: "buy", Auth: token, Data: models.BuyTicket{ReservationId: reservationId}})
+	server.PaymentTimeout = 30 * time.Second
+	provider.SetLatency(0)
+	assert.Equal(t, "payment timed out", response.Error)
+	assert.Contains(t, cart.Reservations, reservationId, "the seat should go back to the cart")
+
+	alceu, _ = dao.GetClientDAO().FindByUsername("alceuvalenca")
+	assert.Empty(t, alceu.Client_flights, "no ticket should be issued without a payment")
+
+	response, _ = conn.Do(models.Request{Action: "buy", Auth: token, Data: models.BuyTicket{ReservationId: reservationId}})
+	assert.Empty(t, response.Error, "expected no error, got %s", response.Error)
+
+	alceu, _ = dao.GetClientDAO().FindByUsername("alceuvalenca")
+	assert.Len(t, alceu.Client_flights, 1)
+	ticket := alceu.Client_flights[0]
+	intent, err := provider.Intent(ticket.PaymentId)
+	assert.NoError(t, err, "expected no error, got %v", err)
+	assert.Equal(t, payment.StatusCaptured, intent.Status)
+	assert.Equal(t, models.Price{Amount: 25000, Currency: "BRL"}, intent.Amount)
+
+	response, _ = conn.Do(models.Request{Action: "cancel-buy", Auth: token, Data: models.CancelBuyRequest{TicketId: ticket.Id}})
+	assert.Empty(t, response.Error, "expected no error, got %s", response.Error)
+
+	intent, _ = provider.Intent(ticket.PaymentId)
+	assert.Equal(t, payment.StatusRefunded, intent.Status, "the payment should be refunded")
+}
+
+func TestConcurrentCancelBuyRefundsOnce(t *testing.T) {
+	loadAirportDAO(t)
+	address := startServer(t)
+
+	conn, err := client.Dial("tcp", address, codec.New(0), 0)
+	assert.NoError(t, err, "expected no error, got %v", err)
+	defer conn.Close()
+
+	src := &models.Airport{Name: "Aeroporto de Paulo Afonso", City: models.City{Name: "Paulo Afonso"}}
+	dest := &models.Airport{Name: "Aeroporto de Lençóis", City: models.City{Name: "Lençóis"}}
+	dao.GetAirportDAO().Insert(src)
+	dao.GetAirportDAO().Insert(dest)
+	defer dao.GetAirportDAO().Delete(src)
+	defer dao.GetAirportDAO().Delete(dest)
+
+	flight := &models.Flight{SourceAirportId: src.Id, DestAirportId: dest.Id, Seats: 2, Fare: models.Fare{BasePrice: 25000, Currency: "BRL"}}
+	dao.GetFlightDAO().Insert(flight)
+	flight.Start()
+	defer dao.GetFlightDAO().Delete(flight)
+
+	response, _ := conn.Do(models.Request{Action: "register", Data: models.RegisterRequest{
+		Name: "Ivete Sangalo", Username: "ivetesangalo", Password: "senhaSegura123",
+	}})
+	assert.Empty(t, response.Error, "expected no error, got %s", response.Error)
+	token := loginAs(t, conn, "ivetesangalo", "senhaSegura123", "")
+
+	response, _ = conn.Do(models.Request{Action: "reservation", Auth: token, Data: models.FlightsRequest{FlightIds: []uuid.UUID{flight.Id}}})
+	assert.Empty(t, response.Error, "expected no error, got %s", response.Error)
+
+	ivete, _ := dao.GetClientDAO().FindByUsername("ivetesangalo")
+	cart, _ := dao.GetCartDAO().FindByClientId(ivete.Id)
+	var reservationId uuid.UUID
+	for id := range cart.Reservations {
+		reservationId = id
+	}
+
+	response, _ = conn.Do(models.Request{Action: "buy", Auth: token, Data: models.BuyTicket{ReservationId: reservationId}})
+	assert.Empty(t, response.Error, "expected no error, got %s", response.Error)
+
+	ivete, _ = dao.GetClientDAO().FindByUsername("ivetesangalo")
+	ticket := ivete.Client_flights[0]
+
+	// Both cancellations are pipelined on the connection before either is answered.
+	errs := make([]string, 2)
+	var wg sync.WaitGroup
+	for i := range errs {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			response, _ := conn.Do(models.Request{Action: "cancel-buy", Auth: token, Data: models.CancelBuyRequest{TicketId: ticket.Id}})
+			errs[i] = response.Error
+		}(i)
+	}
+	wg.Wait()
+
+	assert.ElementsMatch(t, []string{"", "ticket not found"}, errs, "only one cancellation should succeed")
+
+	flight.Mu.Lock()
+	assert.Equal(t, uint(2), flight.Seats, "the seat should be released only once")
+	assert.Empty(t, flight.Passengers)
+	flight.Mu.Unlock()
+}
@@ -0,0 +1,126 @@
+package tests
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+	"vendepass/internal/client"
+	"vendepass/internal/codec"
+	"vendepass/internal/dao"
+	"vendepass/internal/models"
+	"vendepass/internal/pricing"
+	"vendepass/internal/server"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+// loadPricingRules writes pricing rules to a temporary file and loads them.
+func loadPricingRules(t *testing.T, rules string) *pricing.Rules {
+	path := filepath.Join(t.TempDir(), "pricing.json")
+	os.WriteFile(path, []byte(rules), 0644)
+
+	engine, err := pricing.LoadRules(path)
+	assert.NoError(t, err, "expected no error, got %v", err)
+	return engine
+}
+
+func TestPricingRules(t *testing.T) {
+	rules := loadPricingRules(t, `{
+		"LoadFactor": [{"From": 0.75, "Markup": 20}, {"From": 0, "Markup": -10}],
+		"TimeToDeparture": [{"WithinHours": 72, "Markup": 15}, {"WithinHours": 24, "Markup": 40}],
+		"MinMarkup": -10,
+		"MaxMarkup": 50
+	}`)
+	base := models.Price{Amount: 10000, Currency: "BRL"}
+
+	price := rules.Price(pricing.Input{Base: base, LoadFactor: 0.1})
+	assert.Equal(t, int64(9000), price.Amount, "empty flights should be discounted")
+
+	price = rules.Price(pricing.Input{Base: base, LoadFactor: 0.8, Dated: true, TimeToDeparture: 48 * time.Hour})
+	assert.Equal(t, int64(13500), price.Amount, "load and time markups should add up")
+
+	price = rules.Price(pricing.Input{Base: base, LoadFactor: 0.8, Dated: true, TimeToDeparture: 2 * time.Hour})
+	assert.Equal(t, int64(15000), price.Amount, "the markup should be limited to the maximum")
+
+	price = rules.Price(pricing.Input{Base: base, LoadFactor: 0.8, TimeToDeparture: 2 * time.Hour})
+	assert.Equal(t, int64(12000), price.Amount, "flights without a date should not be priced by time")
+
+	_, err := pricing.LoadRules(filepath.Join(t.TempDir(), "missing.json"))
+	assert.Error(t, err, "expected error, got %v", err)
+}
+
+func TestReservationChargesQuotedPrice(t *testing.T) {
+	loadAirportDAO(t)
+	address := startServer(t)
+
+	server.Pricing = loadPricingRules(t, `{"LoadFactor": [{"From": 0, "Markup": -10}, {"From": 0.5, "Markup": 20}], "MinMarkup": -10}`)
+	defer func() { server.Pricing = pricing.Static{} }()
+
+	conn, err := client.Dial("tcp", address, codec.New(0), 0)
+	assert.NoError(t, err, "expected no error, got %v", err)
+	defer conn.Close()
+
+	src := &models.Airport{Name: "Aeroporto de Campina Grande", City: models.City{Name: "Campina Grande"}}
+	dest := &models.Airport{Name: "Aeroporto de Caruaru", City: models.City{Name: "Caruaru"}}
+	dao.GetAirportDAO().Insert(src)
+	dao.GetAirportDAO().Insert(dest)
+	defer dao.GetAirportDAO().Delete(src)
+	defer dao.GetAirportDAO().Delete(dest)
+
+	flight := &models.Flight{SourceAirportId: src.Id, DestAirportId: dest.Id, Seats: 6, Capacity: 6, Fare: models.Fare{BasePrice: 10000, Currency: "BRL"}}
+	dao.GetFlightDAO().Insert(flight)
+	flight.Start()
+	defer dao.GetFlightDAO().Delete(flight)
+
+	tokens := make(map[string]string)
+	for _, username := range []string{"luizgonzaga", "dominguinhos"} {
+		response, _ := conn.Do(models.Request{Action: "register", Data: models.RegisterRequest{
+			Name: username, Username: username, Password: "senhaSegura123",
+		}})
+		assert.Empty(t, response.Error, "expected no error, got %s", response.Error)
+		tokens[username] = loginAs(t, conn, username, "senhaSegura123", "")
+	}
+
+	response, _ := conn.Do(models.Request{Action: "flights", Auth: tokens["luizgonzaga"], Data: models.FlightsRequest{FlightIds: []uuid.UUID{flight.Id}}})
+	assert.Empty(t, response.Error, "expected no error, got %s", response.Error)
+	flights, _ := response.Data["Flights"].([]interface{})
+	quotes, _ := flights[0].(map[string]interface{})["Quotes"].([]interface{})
+	assert.Len(t, quotes, 1)
+	quote, _ := quotes[0].(map[string]interface{})
+	assert.Equal(t, map[string]interface{}{"Amount": float64(9000), "Currency": "BRL"}, quote["Price"])
+	quoteId, _ := uuid.Parse(quote["Id"].(string))
+
+	// Someone else fills the flight, raising its price.
+	for i := 0; i < 3; i++ {
+		response, _ = conn.Do(models.Request{Action: "reservation", Auth: tokens["dominguinhos"], Data: models.FlightsRequest{FlightIds: []uuid.UUID{flight.Id}}})
+		assert.Empty(t, response.Error, "expected no error, got %s", response.Error)
+	}
+
+	response, _ = conn.Do(models.Request{Action: "reservation", Auth: tokens["dominguinhos"], Data: models.FlightsRequest{FlightIds: []uuid.UUID{flight.Id}, QuoteIds: []uuid.UUID{quoteId}}})
+	assert.Empty(t, response.Error, "expected no error, got %s", response.Error)
+
+	response, _ = conn.Do(models.Request{Action: "reservation", Auth: tokens["luizgonzaga"], Data: models.FlightsRequest{FlightIds: []uuid.UUID{flight.Id}, QuoteIds: []uuid.UUID{quoteId}}})
+	assert.Empty(t, response.Error, "expected no error, got %s", response.Error)
+
+	response, _ = conn.Do(models.Request{Action: "cart", Auth: tokens["luizgonzaga"]})
+	assert.Equal(t, []interface{}{map[string]interface{}{"Amount": float64(9000), "Currency": "BRL"}}, response.Data["Total"], "the quoted price should be charged")
+
+	response, _ = conn.Do(models.Request{Action: "reservation", Auth: tokens["luizgonzaga"], Data: models.FlightsRequest{FlightIds: []uuid.UUID{flight.Id}, QuoteIds: []uuid.UUID{quoteId}}})
+	assert.Empty(t, response.Error, "expected no error, got %s", response.Error)
+
+	response, _ = conn.Do(models.Request{Action: "cart", Auth: tokens["luizgonzaga"]})
+	assert.Equal(t, []interface{}{map[string]interface{}{"Amount": float64(21000), "Currency": "BRL"}}, response.Data["Total"], "a used quote should not lock the price again")
+
+	response, _ = conn.Do(models.Request{Action: "cart", Auth: tokens["dominguinhos"]})
+	reservations, _ := response.Data["Reservations"].([]interface{})
+	var highest float64
+	for _, reservation := range reservations {
+		price := reservation.(map[string]interface{})["Price"].(map[string]interface{})
+		if amount := price["Amount"].(float64); amount > highest {
+			highest = amount
+		}
+	}
+	assert.Equal(t, float64(12000), highest, "another client's quote should not be honored")
+}
@@ -0,0 +1,60 @@
+package tests
+
+import (
+	"testing"
+	"vendepass/internal/client"
+	"vendepass/internal/codec"
+	"vendepass/internal/dao"
+	"vendepass/internal/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRoleHierarchy(t *testing.T) {
+	assert.True(t, models.RoleAdmin.Allows(models.RoleAgent))
+	assert.True(t, models.RoleAgent.Allows(models.RoleCustomer))
+	assert.False(t, models.RoleAgent.Allows(models.RoleAdmin))
+	assert.False(t, models.RoleCustomer.Allows(models.RoleAgent))
+	assert.False(t, models.Role("pilot").Valid())
+
+	legacy := &models.Client{}
+	assert.Equal(t, models.RoleCustomer, legacy.GetRole(), "clients without a role should be customers")
+}
+
+func TestOnlyAdminsSeeOtherUsersTickets(t *testing.T) {
+	address := startServer(t)
+
+	conn, err := client.Dial("tcp", address, codec.New(0), 0)
+	assert.NoError(t, err, "expected no error, got %v", err)
+	defer conn.Close()
+
+	for _, username := range []string{"elisregina", "tomjobim"} {
+		response, _ := conn.Do(models.Request{Action: "register", Data: models.RegisterRequest{
+			Name: username, Username: username, Password: "senhaSegura123",
+		}})
+		assert.Empty(t, response.Error, "expected no error, got %s", response.Error)
+	}
+
+	customer := loginAs(t, conn, "tomjobim", "senhaSegura123", "")
+
+	response, _ := conn.Do(models.Request{Action: "user-tickets", Auth: customer, Data: models.UserTicketsRequest{Username: "elisregina"}})
+	assert.Equal(t, "forbidden", response.Error)
+
+	admin, _ := dao.GetClientDAO().FindByUsername("elisregina")
+	admin.Role = models.RoleAdmin
+	dao.GetClientDAO().Update(admin)
+
+	adminToken := loginAs(t, conn, "elisregina", "senhaSegura123", "")
+
+	response, _ = conn.Do(models.Request{Action: "user-tickets", Auth: adminToken, Data: models.UserTicketsRequest{Username: "tomjobim"}})
+	assert.Empty(t, response.Error, "expected no error, got %s", response.Error)
+
+	response, _ = conn.Do(models.Request{Action: "set-role", Auth: adminToken, Data: models.SetRoleRequest{Username: "tomjobim", Role: models.RoleAgent}})
+	assert.Empty(t, response.Error, "expected no error, got %s", response.Error)
+
+	agent, _ := dao.GetClientDAO().FindByUsername("tomjobim")
+	assert.Equal(t, models.RoleAgent, agent.Role)
+
+	response, _ = conn.Do(models.Request{Action: "user-tickets", Auth: customer, Data: models.UserTicketsRequest{Username: "elisregina"}})
+	assert.Equal(t, "forbidden", response.Error, "agents should not see other users' tickets")
+}
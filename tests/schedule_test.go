@@ -0,0 +1,126 @@
+package tests
+
+import (
+	"testing"
+	"time"
+	"vendepass/internal/client"
+	"vendepass/internal/codec"
+	"vendepass/internal/dao"
+	"vendepass/internal/models"
+	"vendepass/internal/server"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScheduleInstance(t *testing.T) {
+	saoPaulo, _ := time.LoadLocation("America/Sao_Paulo")
+	manaus, _ := time.LoadLocation("America/Manaus")
+
+	schedule := &models.Schedule{
+		Id:              uuid.New(),
+		FlightNumber:    "VP1500",
+		SourceAirportId: uuid.New(),
+		DestAirportId:   uuid.New(),
+		DepartureTime:   "22:30",
+		DurationMinutes: 180,
+		Weekdays:        []time.Weekday{time.Monday, time.Wednesday},
+		StartDate:       "2030-01-01",
+		Seats:           120,
+	}
+	assert.NoError(t, schedule.Validate())
+
+	monday := time.Date(2030, time.January, 7, 0, 0, 0, 0, saoPaulo)
+	assert.True(t, schedule.OperatesOn(monday))
+	assert.False(t, schedule.OperatesOn(monday.AddDate(0, 0, 1)), "the schedule does not operate on tuesdays")
+	assert.False(t, schedule.OperatesOn(monday.AddDate(0, 0, -7)), "the schedule starts in 2030")
+
+	flight := schedule.Instance(monday, saoPaulo, manaus)
+	assert.Equal(t, schedule.InstanceId(monday), flight.Id, "a day's flight should always get the same ID")
+	assert.Equal(t, "2030-01-07T22:30:00-03:00", flight.Departure.Format(time.RFC3339))
+	assert.Equal(t, "2030-01-08T00:30:00-04:00", flight.Arrival.Format(time.RFC3339), "arrival should be in the destination's time zone")
+	assert.True(t, flight.DepartsOn(monday))
+	assert.False(t, flight.DepartsOn(monday.AddDate(0, 0, 1)))
+}
+
+func TestFindFlightsByDay(t *testing.T) {
+	flightDAO := dao.GetFlightDAO()
+	defer flightDAO.DeleteAll()
+
+	sourceID, destID := uuid.New(), uuid.New()
+	day := time.Now().AddDate(0, 0, 2)
+
+	flightDAO.Insert(&models.Flight{SourceAirportId: sourceID, DestAirportId: destID, Seats: 10, Departure: day, Arrival: day.Add(time.Hour)})
+	flightDAO.Insert(&models.Flight{SourceAirportId: sourceID, DestAirportId: destID, Seats: 10, Departure: day.AddDate(0, 0, 1), Arrival: day.AddDate(0, 0, 1).Add(time.Hour)})
+
+	flights, err := flightDAO.FindBySourceAndDest(sourceID, destID, day)
+	assert.NoError(t, err, "expected no error, got %v", err)
+	assert.Equal(t, 1, len(flights), "expected 1 flight, got %d", len(flights))
+
+	flights, _ = flightDAO.FindBySourceAndDest(sourceID, destID, time.Time{})
+	assert.Equal(t, 2, len(flights), "expected 2 flights, got %d", len(flights))
+
+	_, err = flightDAO.FindBySourceAndDest(sourceID, destID, day.AddDate(0, 0, 5))
+	assert.Error(t, err, "expected error, got %v", err)
+}
+
+func TestBFSRespectsConnections(t *testing.T) {
+	flightDAO := dao.GetFlightDAO()
+	defer flightDAO.DeleteAll()
+
+	sourceID, middleID, destID := uuid.New(), uuid.New(), uuid.New()
+	day := time.Now().AddDate(0, 0, 1).Truncate(24 * time.Hour).Add(8 * time.Hour)
+
+	flightDAO.Insert(&models.Flight{SourceAirportId: sourceID, DestAirportId: middleID, Seats: 10, Departure: day, Arrival: day.Add(2 * time.Hour)})
+	early := &models.Flight{SourceAirportId: middleID, DestAirportId: destID, Seats: 10, Departure: day.Add(time.Hour), Arrival: day.Add(3 * time.Hour)}
+	late := &models.Flight{SourceAirportId: middleID, DestAirportId: destID, Seats: 10, Departure: day.Add(4 * time.Hour), Arrival: day.Add(6 * time.Hour)}
+	flightDAO.Insert(early)
+	flightDAO.Insert(late)
+
+	path, err := flightDAO.BreadthFirstSearch(sourceID, destID, day, "")
+	assert.NoError(t, err, "expected no errors, got %v", err)
+	assert.Equal(t, 2, len(path), "expected 2 flights, got %d", len(path))
+	assert.Equal(t, late.Id, path[1].Id, "the connection should depart after the first flight arrives")
+
+	_, err = flightDAO.BreadthFirstSearch(sourceID, destID, day.AddDate(0, 0, 1), "")
+	assert.Error(t, err, "no flight departs on the next day")
+}
+
+func TestAdminScheduleGeneratesFlights(t *testing.T) {
+	loadAirportDAO(t)
+	address := startServer(t)
+	defer dao.GetFlightDAO().DeleteAll()
+
+	conn, err := client.Dial("tcp", address, codec.New(0), 0)
+	assert.NoError(t, err, "expected no error, got %v", err)
+	defer conn.Close()
+
+	src := &models.Airport{Name: "Aeroporto de Ilhéus", City: models.City{Name: "Ilhéus", TimeZone: "America/Bahia"}}
+	dest := &models.Airport{Name: "Aeroporto de Porto Seguro", City: models.City{Name: "Porto Seguro", TimeZone: "America/Bahia"}}
+	dao.GetAirportDAO().Insert(src)
+	dao.GetAirportDAO().Insert(dest)
+	defer dao.GetAirportDAO().Delete(src)
+	defer dao.GetAirportDAO().Delete(dest)
+
+	admin := registerAdmin(t, conn, "gilbertogil")
+
+	bahia, _ := time.LoadLocation("America/Bahia")
+	tomorrow := time.Now().In(bahia).AddDate(0, 0, 1).Format(models.DateLayout)
+	response, _ := conn.Do(models.Request{Action: "admin-create-schedule", Auth: admin, Data: models.CreateScheduleRequest{
+		FlightNumber: "VP2024", SourceAirportId: src.Id, DestAirportId: dest.Id,
+		DepartureTime: "09:15", DurationMinutes: 50, StartDate: tomorrow, Seats: 80,
+	}})
+	assert.Empty(t, response.Error, "expected no error, got %s", response.Error)
+	assert.Equal(t, float64(server.ScheduleHorizon-1), response.Data["flights"], "a daily schedule should fill the rest of the horizon")
+
+	response, _ = conn.Do(models.Request{Action: "route", Auth: admin, Data: models.RouteRequest{Source: "Ilhéus", Dest: "Porto Seguro", Date: tomorrow}})
+	assert.Empty(t, response.Error, "expected no error, got %s", response.Error)
+	path, _ := response.Data["path"].([]interface{})
+	assert.Len(t, path, 1)
+	leg, _ := path[0].(map[string]interface{})
+	assert.Equal(t, "VP2024", leg["Number"])
+	assert.Contains(t, leg["Departure"], tomorrow+"T09:15:00-03:00")
+
+	response, _ = conn.Do(models.Request{Action: "route", Auth: admin, Data: models.RouteRequest{Source: "Ilhéus", Dest: "Porto Seguro", Date: "amanhã"}})
+	assert.Equal(t, "invalid date", response.Error)
+}
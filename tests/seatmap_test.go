@@ -0,0 +1,151 @@
+package tests
+
+import (
+	"encoding/json"
+	"sync"
+	"testing"
+	"vendepass/internal/client"
+	"vendepass/internal/codec"
+	"vendepass/internal/dao"
+	"vendepass/internal/models"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+// smallSeatMap is a business cabin of two rows of 4 seats ahead of an economy cabin of two rows of 6 seats.
+var smallSeatMap = []models.CabinLayout{
+	{Cabin: models.CabinBusiness, Rows: 2, Letters: "AC DF"},
+	{Cabin: models.CabinEconomy, Rows: 2, Letters: "ABC DEF"},
+}
+
+func TestSeatMapLayout(t *testing.T) {
+	seatMap, err := models.NewSeatMap(smallSeatMap)
+	assert.NoError(t, err, "expected no error, got %v", err)
+	assert.Equal(t, uint(20), seatMap.Capacity())
+
+	seat, exists := seatMap.Seat("1a")
+	assert.True(t, exists)
+	assert.Equal(t, models.Seat{Code: "1A", Row: 1, Letter: "A", Cabin: models.CabinBusiness, Window: true}, seat)
+
+	seat, _ = seatMap.Seat("3C")
+	assert.Equal(t, models.CabinEconomy, seat.Cabin, "rows should be numbered across the cabins")
+	assert.True(t, seat.Aisle)
+	assert.False(t, seat.Window)
+
+	seat, _ = seatMap.Seat("4B")
+	assert.False(t, seat.Aisle || seat.Window, "middle seats are neither window nor aisle")
+
+	_, exists = seatMap.Seat("5A")
+	assert.False(t, exists)
+
+	stored, _ := json.Marshal(seatMap)
+	restored := new(models.SeatMap)
+	assert.NoError(t, json.Unmarshal(stored, restored))
+	assert.Equal(t, seatMap.Seats(), restored.Seats())
+
+	_, err = models.NewSeatMap([]models.CabinLayout{{Cabin: models.CabinEconomy, Rows: 10, Letters: "AB  C"}})
+	assert.Error(t, err, "expected error, got %v", err)
+	_, err = models.NewSeatMap([]models.CabinLayout{{Cabin: "first", Rows: 1, Letters: "A"}})
+	assert.Error(t, err, "expected error, got %v", err)
+}
+
+func TestConcurrentSeatSelection(t *testing.T) {
+	seatMap, _ := models.NewSeatMap(smallSeatMap)
+	flight := &models.Flight{Id: uuid.New(), Seats: 20, Capacity: 20, SeatMap: seatMap}
+	flight.InitQueue()
+	flight.Start()
+	defer flight.Cancel()
+
+	var wg sync.WaitGroup
+	results := make(chan error, 10)
+	carts := make([]*models.Cart, 10)
+
+	for i := range carts {
+		carts[i] = &models.Cart{ClientID: uuid.New(), Reservations: make(map[uuid.UUID]models.Reservation)}
+		wg.Add(1)
+		go func(cart *models.Cart) {
+			defer wg.Done()
+			results <- flight.Reserve(cart, "", "", "2f", nil)
+		}(carts[i])
+	}
+	wg.Wait()
+	close(results)
+
+	reserved := 0
+	for err := range results {
+		if err == nil {
+			reserved++
+		} else {
+			assert.ErrorIs(t, err, models.ErrSeatTaken)
+		}
+	}
+	assert.Equal(t, 1, reserved, "the seat should go to a single reservation")
+	assert.Equal(t, uint(19), flight.Seats)
+
+	var ticket *models.Ticket
+	for _, cart := range carts {
+		for _, reservation := range cart.Reservations {
+			ticket = reservation.Ticket
+		}
+	}
+	assert.Equal(t, "2F", ticket.Seat)
+
+	// A bought seat stays taken after a restart.
+	flight.Passengers = append(flight.Passengers, ticket)
+	restored := models.NewFlight(flight.ToJSON())
+	assert.True(t, restored.SeatTaken("2F"))
+
+	flight.Mu.Lock()
+	flight.ReleaseSeat(ticket)
+	taken := flight.SeatTaken("2F")
+	flight.Mu.Unlock()
+	assert.False(t, taken, "a released seat should be free again")
+	assert.Equal(t, uint(20), flight.Seats)
+}
+
+func TestReserveChosenSeat(t *testing.T) {
+	loadAirportDAO(t)
+	address := startServer(t)
+
+	conn, err := client.Dial("tcp", address, codec.New(0), 0)
+	assert.NoError(t, err, "expected no error, got %v", err)
+	defer conn.Close()
+
+	src := &models.Airport{Name: "Aeroporto de Bonito", City: models.City{Name: "Bonito"}}
+	dest := &models.Airport{Name: "Aeroporto de Corumbá", City: models.City{Name: "Corumbá"}}
+	dao.GetAirportDAO().Insert(src)
+	dao.GetAirportDAO().Insert(dest)
+	defer dao.GetAirportDAO().Delete(src)
+	defer dao.GetAirportDAO().Delete(dest)
+
+	admin := registerAdmin(t, conn, "almirsater")
+	response, _ := conn.Do(models.Request{Action: "admin-create-flight", Auth: admin, Data: models.CreateFlightRequest{
+		SourceAirportId: src.Id, DestAirportId: dest.Id, SeatMap: smallSeatMap,
+	}})
+	assert.Empty(t, response.Error, "expected no error, got %s", response.Error)
+
+	flights, _ := dao.GetFlightDAO().FindBySourceAndDest(src.Id, dest.Id, models.Flight{}.Departure)
+	flight := flights[0]
+	defer dao.GetFlightDAO().Delete(flight)
+	assert.Equal(t, uint(20), flight.Capacity, "the flight should sell every seat of its map")
+
+	response, _ = conn.Do(models.Request{Action: "reservation", Auth: admin, Data: models.FlightsRequest{FlightIds: []uuid.UUID{flight.Id}, Seats: []string{"1C"}}})
+	assert.Empty(t, response.Error, "expected no error, got %s", response.Error)
+
+	response, _ = conn.Do(models.Request{Action: "reservation", Auth: admin, Data: models.FlightsRequest{FlightIds: []uuid.UUID{flight.Id}, Seats: []string{"1C"}}})
+	assert.Equal(t, models.ErrSeatTaken.Error()+": "+flight.Id.String(), response.Error)
+
+	response, _ = conn.Do(models.Request{Action: "seat-map", Auth: admin, Data: models.SeatMapRequest{FlightId: flight.Id}})
+	assert.Empty(t, response.Error, "expected no error, got %s", response.Error)
+	seats, _ := response.Data["Seats"].([]interface{})
+	assert.Len(t, seats, 20)
+	for _, seat := range seats {
+		seat := seat.(map[string]interface{})
+		assert.Equal(t, seat["Code"] != "1C", seat["Available"], "only 1C should be taken, got %v", seat)
+	}
+
+	response, _ = conn.Do(models.Request{Action: "cart", Auth: admin})
+	reservations, _ := response.Data["Reservations"].([]interface{})
+	assert.Equal(t, "1C", reservations[0].(map[string]interface{})["Seat"])
+}
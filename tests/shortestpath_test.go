@@ -0,0 +1,105 @@
+package tests
+
+import (
+	"math"
+	"testing"
+	"time"
+	"vendepass/internal/client"
+	"vendepass/internal/codec"
+	"vendepass/internal/dao"
+	"vendepass/internal/models"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+// flightCost weighs each flight by a fixed cost, by flight ID.
+type flightCost map[uuid.UUID]float64
+
+func (c flightCost) Cost(_ *models.Flight, flight *models.Flight) float64 { return c[flight.Id] }
+
+func (c flightCost) Estimate(uuid.UUID) float64 { return 0 }
+
+func TestDistanceTo(t *testing.T) {
+	saoPaulo := models.City{Name: "São Paulo", Latitude: -23.5505, Longitude: -46.6333}
+	rio := models.City{Name: "Rio de Janeiro", Latitude: -22.9068, Longitude: -43.1729}
+
+	assert.InDelta(t, 361, saoPaulo.DistanceTo(rio), 5)
+	assert.InDelta(t, saoPaulo.DistanceTo(rio), rio.DistanceTo(saoPaulo), 1e-9)
+	assert.Zero(t, rio.DistanceTo(rio))
+}
+
+func TestShortestPath(t *testing.T) {
+	flightDAO := dao.GetFlightDAO()
+	defer flightDAO.DeleteAll()
+
+	sourceID := uuid.New()
+	middleID := uuid.New()
+	destID := uuid.New()
+
+	direct := &models.Flight{Id: uuid.New(), SourceAirportId: sourceID, DestAirportId: destID, Seats: 10}
+	first := &models.Flight{Id: uuid.New(), SourceAirportId: sourceID, DestAirportId: middleID, Seats: 10}
+	second := &models.Flight{Id: uuid.New(), SourceAirportId: middleID, DestAirportId: destID, Seats: 10}
+	for _, flight := range []*models.Flight{direct, first, second} {
+		flightDAO.Insert(flight)
+	}
+
+	path, err := flightDAO.ShortestPath(sourceID, destID, time.Time{}, "", flightCost{direct.Id: 10, first.Id: 3, second.Id: 3})
+	assert.NoError(t, err, "expected no error, got %v", err)
+	assert.Equal(t, []*models.Flight{first, second}, path, "the cheaper connection should be taken")
+
+	path, err = flightDAO.ShortestPath(sourceID, destID, time.Time{}, "", flightCost{direct.Id: 5, first.Id: 3, second.Id: 3})
+	assert.NoError(t, err, "expected no error, got %v", err)
+	assert.Equal(t, []*models.Flight{direct}, path)
+
+	path, err = flightDAO.ShortestPath(sourceID, destID, time.Time{}, "", flightCost{direct.Id: math.Inf(1), first.Id: 3, second.Id: math.Inf(1)})
+	assert.Error(t, err, "flights costing +Inf should never be taken, got %v", path)
+
+	_, err = flightDAO.ShortestPath(sourceID, uuid.New(), time.Time{}, "", flightCost{})
+	assert.Error(t, err, "expected error, got %v", err)
+}
+
+func TestRouteSort(t *testing.T) {
+	loadAirportDAO(t)
+	address := startServer(t)
+	defer dao.GetFlightDAO().DeleteAll()
+
+	conn, err := client.Dial("tcp", address, codec.New(0), 0)
+	assert.NoError(t, err, "expected no error, got %v", err)
+	defer conn.Close()
+
+	parnaiba := &models.Airport{Name: "Aeroporto de Parnaíba", City: models.City{Name: "Parnaíba", Latitude: -2.9055, Longitude: -41.7769}}
+	floriano := &models.Airport{Name: "Aeroporto de Floriano", City: models.City{Name: "Floriano", Latitude: -6.7717, Longitude: -43.0225}}
+	picos := &models.Airport{Name: "Aeroporto de Picos", City: models.City{Name: "Picos", Latitude: -7.0772, Longitude: -41.4669}}
+	for _, airport := range []*models.Airport{parnaiba, floriano, picos} {
+		dao.GetAirportDAO().Insert(airport)
+		defer dao.GetAirportDAO().Delete(airport)
+	}
+
+	admin := registerAdmin(t, conn, "zecabaleiro")
+	legs := []models.CreateFlightRequest{
+		{SourceAirportId: parnaiba.Id, DestAirportId: picos.Id, Seats: 10, Fare: models.Fare{BasePrice: 90000}},
+		{SourceAirportId: parnaiba.Id, DestAirportId: floriano.Id, Seats: 10, Fare: models.Fare{BasePrice: 20000}},
+		{SourceAirportId: floriano.Id, DestAirportId: picos.Id, Seats: 10, Fare: models.Fare{BasePrice: 20000}},
+	}
+	for _, leg := range legs {
+		response, _ := conn.Do(models.Request{Action: "admin-create-flight", Auth: admin, Data: leg})
+		assert.Empty(t, response.Error, "expected no error, got %s", response.Error)
+	}
+
+	flights := map[models.RouteSort]int{
+		models.SortFlights:  1,
+		models.SortDistance: 1, // the connection in Floriano is a detour
+		models.SortDuration: 1,
+		models.SortPrice:    2, // the connection is cheaper than the direct flight
+	}
+	for sort, count := range flights {
+		response, _ := conn.Do(models.Request{Action: "route", Auth: admin, Data: models.RouteRequest{Source: "Parnaíba", Dest: "Picos", Sort: sort}})
+		assert.Empty(t, response.Error, "expected no error, got %s", response.Error)
+		path, _ := response.Data["path"].([]interface{})
+		assert.Len(t, path, count, "sorting by %q", sort)
+	}
+
+	response, _ := conn.Do(models.Request{Action: "route", Auth: admin, Data: models.RouteRequest{Source: "Parnaíba", Dest: "Picos", Sort: "scenic"}})
+	assert.Equal(t, "invalid sort", response.Error)
+}
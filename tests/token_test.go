@@ -0,0 +1,80 @@
+package tests
+
+import (
+	"strings"
+	"testing"
+	"time"
+	"vendepass/internal/auth"
+	"vendepass/internal/client"
+	"vendepass/internal/codec"
+	"vendepass/internal/models"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIssueAndVerifyToken(t *testing.T) {
+	signer := auth.NewSigner([]byte("segredo-de-teste"), time.Minute)
+	sessionId, clientId := uuid.New(), uuid.New()
+
+	token, issued := signer.Issue(sessionId, clientId)
+	claims, err := signer.Verify(token)
+
+	assert.NoError(t, err, "expected no error, got %v", err)
+	assert.Equal(t, issued, claims)
+	assert.Equal(t, sessionId, claims.SessionId)
+	assert.Equal(t, clientId, claims.ClientId)
+	assert.True(t, claims.Expiry().After(time.Now()), "token should expire in the future")
+}
+
+func TestRejectForgedToken(t *testing.T) {
+	signer := auth.NewSigner([]byte("segredo-de-teste"), time.Minute)
+	other := auth.NewSigner([]byte("outro-segredo"), time.Minute)
+
+	token, _ := other.Issue(uuid.New(), uuid.New())
+	_, err := signer.Verify(token)
+	assert.ErrorIs(t, err, auth.ErrInvalidToken)
+
+	token, _ = signer.Issue(uuid.New(), uuid.New())
+	parts := strings.Split(token, ".")
+	forged, _ := signer.Issue(uuid.New(), uuid.New())
+	_, err = signer.Verify(parts[0] + "." + strings.Split(forged, ".")[1] + "." + parts[2])
+	assert.ErrorIs(t, err, auth.ErrInvalidToken, "payload swapped under another signature should be rejected")
+
+	_, err = signer.Verify(uuid.New().String())
+	assert.ErrorIs(t, err, auth.ErrInvalidToken, "raw session IDs should no longer be accepted")
+}
+
+func TestRejectExpiredToken(t *testing.T) {
+	signer := auth.NewSigner([]byte("segredo-de-teste"), -time.Second)
+
+	token, _ := signer.Issue(uuid.New(), uuid.New())
+	_, err := signer.Verify(token)
+
+	assert.ErrorIs(t, err, auth.ErrExpiredToken)
+}
+
+func TestRefreshToken(t *testing.T) {
+	address := startServer(t)
+
+	conn, err := client.Dial("tcp", address, codec.New(0), 0)
+	assert.NoError(t, err, "expected no error, got %v", err)
+	defer conn.Close()
+
+	response, _ := conn.Do(models.Request{Action: "register", Data: models.RegisterRequest{
+		Name: "Rita Lee", Username: "ritalee", Password: "senhaSegura123",
+	}})
+	assert.Empty(t, response.Error, "expected no error, got %s", response.Error)
+
+	token := loginAs(t, conn, "ritalee", "senhaSegura123", "")
+
+	response, _ = conn.Do(models.Request{Action: "refresh-token", Auth: token})
+	refreshed, _ := response.Data["token"].(string)
+	assert.NotEmpty(t, refreshed, "expected a new token, got %v", response)
+
+	response, _ = conn.Do(models.Request{Action: "get-user", Auth: refreshed})
+	assert.Empty(t, response.Error, "expected no error, got %s", response.Error)
+
+	response, _ = conn.Do(models.Request{Action: "get-user", Auth: "not-a-token"})
+	assert.Equal(t, "not authorized", response.Error)
+}
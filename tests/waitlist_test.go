@@ -0,0 +1,133 @@
+package tests
+
+import (
+	"testing"
+	"time"
+	"vendepass/internal/client"
+	"vendepass/internal/codec"
+	"vendepass/internal/dao"
+	"vendepass/internal/models"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOverbooking(t *testing.T) {
+	flight := &models.Flight{Id: uuid.New(), Seats: 1, Capacity: 1, Overbooking: 1}
+
+	first, err := flight.AcceptReservation("", "", "")
+	assert.NoError(t, err, "expected no error, got %v", err)
+	assert.True(t, flight.Bookable("", 1), "the flight should still be overbookable")
+
+	second, err := flight.AcceptReservation("", "", "")
+	assert.NoError(t, err, "expected no error, got %v", err)
+	assert.Equal(t, uint(0), flight.Seats)
+	assert.Equal(t, uint(1), flight.Overbooked())
+
+	_, err = flight.AcceptReservation("", "", "")
+	assert.Error(t, err, "the allowance should be used up, got %v", err)
+	assert.False(t, flight.Bookable("", 1))
+
+	// Sold tickets beyond the capacity stay overbooked after a restart.
+	flight.Passengers = []*models.Ticket{first, second}
+	restored := models.NewFlight(flight.ToJSON())
+	assert.Equal(t, uint(1), restored.Overbooked())
+	assert.Equal(t, uint(0), restored.Seats)
+
+	flight.ReleaseSeat(first)
+	assert.Equal(t, uint(0), flight.Overbooked(), "the freed seat should go to the overbooked passenger")
+	assert.Equal(t, uint(0), flight.Seats)
+
+	flight.ReleaseSeat(second)
+	assert.Equal(t, uint(1), flight.Seats)
+
+	seatMap, _ := models.NewSeatMap(smallSeatMap)
+	full := &models.Flight{Id: uuid.New(), Seats: 0, Capacity: 20, Overbooking: 5, SeatMap: seatMap}
+	_, err = full.AcceptReservation("", "", "1A")
+	assert.Error(t, err, "overbooked reservations should not choose a seat, got %v", err)
+}
+
+func TestWaitlistGetsFreedSeat(t *testing.T) {
+	loadAirportDAO(t)
+	address := startServer(t)
+
+	conn, err := client.Dial("tcp", address, codec.New(0), 0)
+	assert.NoError(t, err, "expected no error, got %v", err)
+	defer conn.Close()
+
+	src := &models.Airport{Name: "Aeroporto de Campina Grande", City: models.City{Name: "Campina Grande"}}
+	dest := &models.Airport{Name: "Aeroporto de Caruaru", City: models.City{Name: "Caruaru"}}
+	dao.GetAirportDAO().Insert(src)
+	dao.GetAirportDAO().Insert(dest)
+	defer dao.GetAirportDAO().Delete(src)
+	defer dao.GetAirportDAO().Delete(dest)
+
+	first := registerAdmin(t, conn, "zeramalho")
+	response, _ := conn.Do(models.Request{Action: "register", Data: models.RegisterRequest{
+		Name: "Elba Ramalho", Username: "elbaramalho", Password: "senhaSegura123",
+	}})
+	assert.Empty(t, response.Error, "expected no error, got %s", response.Error)
+	second := loginAs(t, conn, "elbaramalho", "senhaSegura123", "")
+
+	response, _ = conn.Do(models.Request{Action: "admin-create-flight", Auth: first, Data: models.CreateFlightRequest{
+		SourceAirportId: src.Id, DestAirportId: dest.Id, Seats: 1,
+	}})
+	assert.Empty(t, response.Error, "expected no error, got %s", response.Error)
+
+	flights, _ := dao.GetFlightDAO().FindBySourceAndDest(src.Id, dest.Id, time.Time{})
+	flight := flights[0]
+	defer dao.GetFlightDAO().Delete(flight)
+
+	response, _ = conn.Do(models.Request{Action: "join-waitlist", Auth: second, Data: models.JoinWaitlistRequest{FlightId: flight.Id}})
+	assert.Equal(t, "flight is not full", response.Error)
+
+	response, _ = conn.Do(models.Request{Action: "reservation", Auth: first, Data: models.FlightsRequest{FlightIds: []uuid.UUID{flight.Id}}})
+	assert.Empty(t, response.Error, "expected no error, got %s", response.Error)
+
+	response, _ = conn.Do(models.Request{Action: "join-waitlist", Auth: second, Data: models.JoinWaitlistRequest{FlightId: flight.Id}})
+	assert.Empty(t, response.Error, "expected no error, got %s", response.Error)
+	assert.Equal(t, float64(1), response.Data["Position"])
+
+	response, _ = conn.Do(models.Request{Action: "join-waitlist", Auth: second, Data: models.JoinWaitlistRequest{FlightId: flight.Id}})
+	assert.Equal(t, "already on the waitlist", response.Error)
+
+	// Releasing the reservation hands the seat to the waitlist.
+	zeramalho, _ := dao.GetClientDAO().FindByUsername("zeramalho")
+	cart, _ := dao.GetCartDAO().FindByClientId(zeramalho.Id)
+	var reservationId uuid.UUID
+	for id := range cart.Reservations {
+		reservationId = id
+	}
+	response, _ = conn.Do(models.Request{Action: "cancel-reservation", Auth: first, Data: models.CancelReservationRequest{ReservationId: reservationId}})
+	assert.Empty(t, response.Error, "expected no error, got %s", response.Error)
+
+	elbaramalho, _ := dao.GetClientDAO().FindByUsername("elbaramalho")
+	cart, _ = dao.GetCartDAO().FindByClientId(elbaramalho.Id)
+	assert.Len(t, cart.Reservations, 1, "the waitlisted client should get the seat")
+	for id := range cart.Reservations {
+		reservationId = id
+	}
+
+	response, _ = conn.Do(models.Request{Action: "notifications", Auth: second})
+	notifications, _ := response.Data["Notifications"].([]interface{})
+	assert.Len(t, notifications, 1, "expected 1 notification, got %d", len(notifications))
+
+	response, _ = conn.Do(models.Request{Action: "waitlist", Auth: second})
+	entries, _ := response.Data["Waitlist"].([]interface{})
+	assert.Empty(t, entries, "the served entry should leave the waitlist")
+
+	// Cancelling a bought ticket hands the seat to the waitlist as well.
+	response, _ = conn.Do(models.Request{Action: "buy", Auth: second, Data: models.BuyTicket{ReservationId: reservationId}})
+	assert.Empty(t, response.Error, "expected no error, got %s", response.Error)
+
+	response, _ = conn.Do(models.Request{Action: "join-waitlist", Auth: first, Data: models.JoinWaitlistRequest{FlightId: flight.Id}})
+	assert.Empty(t, response.Error, "expected no error, got %s", response.Error)
+
+	elbaramalho, _ = dao.GetClientDAO().FindByUsername("elbaramalho")
+	response, _ = conn.Do(models.Request{Action: "cancel-buy", Auth: second, Data: models.CancelBuyRequest{TicketId: elbaramalho.Client_flights[0].Id}})
+	assert.Empty(t, response.Error, "expected no error, got %s", response.Error)
+
+	cart, _ = dao.GetCartDAO().FindByClientId(zeramalho.Id)
+	assert.Len(t, cart.Reservations, 1, "the waitlisted client should get the seat")
+	assert.Equal(t, uint(0), flight.Seats)
+}